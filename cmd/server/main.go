@@ -1,18 +1,25 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
+	_ "time/tzdata"
 
 	"github.com/grewalsk/task-api/internal/config"
 	"github.com/grewalsk/task-api/internal/db"
+	"github.com/grewalsk/task-api/internal/events"
 	"github.com/grewalsk/task-api/internal/handlers"
 	"github.com/grewalsk/task-api/internal/models"
 	"github.com/grewalsk/task-api/internal/routes"
 	"github.com/grewalsk/task-api/internal/services"
+	"github.com/grewalsk/task-api/internal/storage"
+	"github.com/grewalsk/task-api/internal/tenant"
+	"github.com/grewalsk/task-api/internal/utils"
 	"go.uber.org/zap"
 )
 
@@ -31,24 +38,102 @@ func main() {
 	}
 	defer database.Close()
 
+	workspaceDAO := models.NewWorkspaceDAO(database.Database)
+	defaultWorkspace, err := workspaceDAO.EnsureDefault(context.Background())
+	if err != nil {
+		logger.Fatal("Failed to create default workspace", zap.Error(err))
+	}
+
+	if err := database.Migrate(defaultWorkspace.ID); err != nil {
+		logger.Fatal("Failed to migrate database", zap.Error(err))
+	}
+
 	if err := database.CreateIndexes(); err != nil {
 		logger.Error("Failed to create indexes", zap.Error(err))
 	}
 
 	taskDAO := models.NewTaskDAO(database.Database)
+	commentDAO := models.NewCommentDAO(database.Database)
+	attachmentDAO := models.NewAttachmentDAO(database.Database)
+	activityDAO := models.NewActivityDAO(database.Database)
+	worklogDAO := models.NewWorklogDAO(database.Database)
+	customFieldDAO := models.NewCustomFieldDAO(database.Database)
+	templateDAO := models.NewTemplateDAO(database.Database)
+	projectDAO := models.NewProjectDAO(database.Database)
+	userDAO := models.NewUserDAO(database.Database)
+	groupDAO := models.NewGroupDAO(database.Database)
+	sprintDAO := models.NewSprintDAO(database.Database)
+	milestoneDAO := models.NewMilestoneDAO(database.Database)
+	viewDAO := models.NewViewDAO(database.Database)
+	idempotencyDAO := models.NewIdempotencyDAO(database.Database)
 	authService := services.NewAuthService(cfg.JWT.Secret, cfg.JWT.ExpiryHours)
+	workspaceService := services.NewWorkspaceService(workspaceDAO, userDAO, authService)
+	if err := workspaceService.SeedAdmin(context.Background(), defaultWorkspace); err != nil {
+		logger.Fatal("Failed to seed default admin", zap.Error(err))
+	}
+	accessService := services.NewAccessService(taskDAO, projectDAO, groupDAO)
+	recurrenceService := services.NewRecurrenceService(taskDAO, logger)
+	templateService := services.NewTemplateService(taskDAO)
+	burndownService := services.NewBurndownService(taskDAO)
+	metricsService := services.NewMetricsService(taskDAO, accessService)
 
-	taskHandler := handlers.NewTaskHandler(taskDAO, logger)
-	authHandler := handlers.NewAuthHandler(authService, logger)
+	eventBus := events.NewBus()
+	eventBus.Subscribe(events.TaskSnoozeExpired, func(ctx context.Context, event events.Event) {
+		logger.Info("Task snooze expired",
+			zap.String("task_id", event.TaskID.Hex()),
+			zap.String("tenant_id", event.TenantID.Hex()),
+		)
+	})
+	snoozeService := services.NewSnoozeService(taskDAO, eventBus, logger)
+
+	blobStore, err := storage.New(cfg.Storage.Driver, cfg.Storage.LocalPath, database.Database)
+	if err != nil {
+		logger.Fatal("Failed to initialize attachment storage", zap.Error(err))
+	}
+	attachmentService := services.NewAttachmentService(attachmentDAO, blobStore, cfg.Storage.MaxUploadBytes, cfg.Storage.AllowedTypes, logger)
+	cloneService := services.NewCloneService(taskDAO, commentDAO, attachmentService, accessService)
+	bulkService := services.NewBulkService(taskDAO, userDAO, accessService, recurrenceService, logger)
+	purgeService := services.NewPurgeService(taskDAO, commentDAO, worklogDAO, attachmentService, cfg.Trash.RetentionDays, logger)
+
+	taskHandler := handlers.NewTaskHandler(taskDAO, userDAO, accessService, customFieldDAO, sprintDAO, milestoneDAO, recurrenceService, purgeService, cfg.Tasks.RequireIfMatch, utils.NewCursorSigner(cfg.JWT.Secret), logger)
+	bulkHandler := handlers.NewBulkHandler(bulkService, logger)
+	cloneHandler := handlers.NewCloneHandler(cloneService, accessService, logger)
+	commentHandler := handlers.NewCommentHandler(commentDAO, accessService, logger)
+	attachmentHandler := handlers.NewAttachmentHandler(attachmentService, attachmentDAO, accessService, logger)
+	activityHandler := handlers.NewActivityHandler(activityDAO, accessService, logger)
+	worklogHandler := handlers.NewWorklogHandler(worklogDAO, accessService, logger)
+	customFieldHandler := handlers.NewCustomFieldHandler(customFieldDAO, logger)
+	projectHandler := handlers.NewProjectHandler(projectDAO, userDAO, accessService, logger)
+	sprintHandler := handlers.NewSprintHandler(sprintDAO, accessService, burndownService, logger)
+	milestoneHandler := handlers.NewMilestoneHandler(milestoneDAO, accessService, burndownService, logger)
+	shareHandler := handlers.NewShareHandler(taskDAO, userDAO, groupDAO, accessService, logger)
+	groupHandler := handlers.NewGroupHandler(groupDAO, userDAO, logger)
+	metricsHandler := handlers.NewMetricsHandler(metricsService, logger)
+	viewHandler := handlers.NewViewHandler(viewDAO, projectDAO, taskHandler, accessService, logger)
+	templateHandler := handlers.NewTemplateHandler(templateDAO, accessService, customFieldDAO, templateService, logger)
+	workspaceHandler := handlers.NewWorkspaceHandler(workspaceService, logger)
+	userHandler := handlers.NewUserHandler(userDAO, authService, logger)
+	authHandler := handlers.NewAuthHandler(authService, workspaceService, logger)
 	healthHandler := handlers.NewHealthHandler(database)
 
-	router := routes.Setup(taskHandler, authHandler, healthHandler, cfg.JWT.Secret, logger)
+	router := routes.Setup(taskHandler, bulkHandler, cloneHandler, commentHandler, attachmentHandler, activityHandler, worklogHandler, customFieldHandler, templateHandler, projectHandler, sprintHandler, milestoneHandler, shareHandler, groupHandler, metricsHandler, viewHandler, workspaceHandler, userHandler, authHandler, healthHandler, cfg.JWT.Secret, idempotencyDAO, time.Duration(cfg.Idempotency.TTLHours)*time.Hour,
+		cfg.OpenAPI.Validate, logger)
 
 	server := &http.Server{
 		Addr:    fmt.Sprintf("%s:%s", cfg.Server.Host, cfg.Server.Port),
 		Handler: router,
 	}
 
+	// Background jobs read across workspaces and scope each write to the
+	// workspace of the document they act on.
+	ctx, cancel := context.WithCancel(tenant.System(context.Background()))
+	defer cancel()
+
+	go attachmentService.RunBlobSweeper(ctx, time.Hour)
+	go recurrenceService.Run(ctx, time.Minute)
+	go snoozeService.Run(ctx, time.Minute)
+	go purgeService.Run(ctx, time.Hour)
+
 	go func() {
 		logger.Info("Starting server", zap.String("addr", server.Addr))
 		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
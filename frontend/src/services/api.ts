@@ -32,10 +32,12 @@ class ApiService {
 
     if (!response.ok) {
       const errorData: ApiError = await response.json().catch(() => ({
-        error: 'network_error',
-        message: 'Network error occurred'
+        type: 'about:blank',
+        title: 'Network error occurred',
+        status: response.status,
+        code: 'network_error'
       }));
-      throw new Error(errorData.message || 'Request failed');
+      throw new Error(errorData.detail || errorData.title || 'Request failed');
     }
 
     return response.json();
@@ -43,15 +45,15 @@ class ApiService {
 
   // Auth methods
   async login(credentials: LoginRequest): Promise<LoginResponse> {
-    const response = await this.request<LoginResponse>('/login', {
+    const response = await this.request<ApiResponse<LoginResponse>>('/login', {
       method: 'POST',
       body: JSON.stringify(credentials),
     });
     
-    this.token = response.token;
-    localStorage.setItem('auth_token', response.token);
+    this.token = response.data.token;
+    localStorage.setItem('auth_token', response.data.token);
     
-    return response;
+    return response.data;
   }
 
   logout(): void {
@@ -1,11 +1,27 @@
+// These types mirror the schemas in the API's OpenAPI document, served at
+// /openapi.json.
+
 export interface Task {
   id: string;
   title: string;
   description: string;
   status: 'open' | 'in_progress' | 'done';
   owner_id: string;
+  project_id?: string;
+  parent_id?: string;
+  sprint_id?: string;
+  milestone_id?: string;
+  assignee_ids?: string[];
+  labels?: string[];
+  original_estimate_minutes?: number;
+  remaining_estimate_minutes?: number;
+  custom_fields?: Record<string, unknown>;
+  snoozed_until?: string;
+  started_at?: string;
+  completed_at?: string;
   created_at: string;
   updated_at: string;
+  version: number;
 }
 
 export interface CreateTaskRequest {
@@ -21,26 +37,43 @@ export interface UpdateTaskRequest {
 }
 
 export interface LoginRequest {
+  workspace?: string;
   email: string;
   password: string;
 }
 
+export interface User {
+  id: string;
+  email: string;
+  role: 'user' | 'admin';
+  created_at: string;
+  updated_at: string;
+}
+
 export interface LoginResponse {
   token: string;
-  user: {
-    id: string;
-    username: string;
-    role: string;
-  };
+  user: User;
 }
 
+// ApiResponse is the envelope of successful 200 responses.
 export interface ApiResponse<T> {
-  success: boolean;
   data: T;
-  message?: string;
 }
 
-export interface ApiError {
-  error: string;
+export interface FieldError {
+  field: string;
+  rule: string;
+  param?: string;
   message: string;
+}
+
+// ApiError is an RFC 7807 problem details body (application/problem+json).
+export interface ApiError {
+  type: string;
+  title: string;
+  status: number;
+  detail?: string;
+  code: string;
+  request_id?: string;
+  errors?: FieldError[];
 } 
\ No newline at end of file
@@ -5,9 +5,14 @@ import (
 )
 
 type Config struct {
-	Server   ServerConfig   `mapstructure:"server"`
-	Database DatabaseConfig `mapstructure:"database"`
-	JWT      JWTConfig      `mapstructure:"jwt"`
+	Server      ServerConfig      `mapstructure:"server"`
+	Database    DatabaseConfig    `mapstructure:"database"`
+	JWT         JWTConfig         `mapstructure:"jwt"`
+	Storage     StorageConfig     `mapstructure:"storage"`
+	Trash       TrashConfig       `mapstructure:"trash"`
+	Tasks       TasksConfig       `mapstructure:"tasks"`
+	Idempotency IdempotencyConfig `mapstructure:"idempotency"`
+	OpenAPI     OpenAPIConfig     `mapstructure:"openapi"`
 }
 
 type ServerConfig struct {
@@ -25,6 +30,31 @@ type JWTConfig struct {
 	ExpiryHours int   `mapstructure:"expiry_hours"`
 }
 
+type StorageConfig struct {
+	Driver         string   `mapstructure:"driver"`
+	LocalPath      string   `mapstructure:"local_path"`
+	MaxUploadBytes int64    `mapstructure:"max_upload_bytes"`
+	AllowedTypes   []string `mapstructure:"allowed_types"`
+}
+
+type TrashConfig struct {
+	RetentionDays int `mapstructure:"retention_days"`
+}
+
+type TasksConfig struct {
+	RequireIfMatch bool `mapstructure:"require_if_match"`
+}
+
+type IdempotencyConfig struct {
+	TTLHours int `mapstructure:"ttl_hours"`
+}
+
+// OpenAPIConfig enables checking requests and responses against the
+// OpenAPI document, which is meant for development and tests.
+type OpenAPIConfig struct {
+	Validate bool `mapstructure:"validate"`
+}
+
 func Load() (*Config, error) {
 	viper.SetDefault("server.port", "8080")
 	viper.SetDefault("server.host", "0.0.0.0")
@@ -32,6 +62,20 @@ func Load() (*Config, error) {
 	viper.SetDefault("database.database", "taskdb")
 	viper.SetDefault("jwt.secret", "your_secret_key")
 	viper.SetDefault("jwt.expiry_hours", 24)
+	viper.SetDefault("storage.driver", "local")
+	viper.SetDefault("storage.local_path", "./data/attachments")
+	viper.SetDefault("storage.max_upload_bytes", 25<<20)
+	viper.SetDefault("storage.allowed_types", []string{
+		"image/*",
+		"text/plain",
+		"application/pdf",
+		"application/zip",
+		"application/json",
+	})
+	viper.SetDefault("trash.retention_days", 30)
+	viper.SetDefault("tasks.require_if_match", false)
+	viper.SetDefault("idempotency.ttl_hours", 24)
+	viper.SetDefault("openapi.validate", false)
 
 	viper.AutomaticEnv()
 	viper.SetEnvPrefix("TASKAPI")
@@ -2,9 +2,11 @@ package db
 
 import (
 	"context"
+	"errors"
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
@@ -42,25 +44,533 @@ func (db *DB) Close() error {
 	return db.Client.Disconnect(ctx)
 }
 
+// tenantCollections hold workspace-owned documents.
+var tenantCollections = []string{
+	"tasks",
+	"task_activity",
+	"comments",
+	"attachments",
+	"worklogs",
+	"custom_fields",
+	"projects",
+	"templates",
+	"users",
+	"groups",
+	"sprints",
+	"milestones",
+	"views",
+}
+
+// legacyIndexes were replaced by tenant-prefixed equivalents.
+var legacyIndexes = map[string][]string{
+	"tasks": {"owner_id_1_status_1", "title_text_description_text"},
+}
+
+// Migrate backfills fields introduced after documents were first written and
+// drops indexes that have been replaced. Documents written before workspaces
+// existed are assigned to defaultTenantID. It is safe to run on every start.
+func (db *DB) Migrate(defaultTenantID primitive.ObjectID) error {
+	ctx := context.Background()
+	tasks := db.Database.Collection("tasks")
+
+	if _, err := tasks.UpdateMany(ctx,
+		bson.M{"deleted": bson.M{"$exists": false}, "deleted_at": bson.M{"$exists": true}},
+		bson.M{"$set": bson.M{"deleted": true}},
+	); err != nil {
+		return err
+	}
+	if _, err := tasks.UpdateMany(ctx,
+		bson.M{"deleted": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"deleted": false}},
+	); err != nil {
+		return err
+	}
+
+	if _, err := tasks.UpdateMany(ctx,
+		bson.M{"version": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"version": 1}},
+	); err != nil {
+		return err
+	}
+
+	for _, name := range tenantCollections {
+		if _, err := db.Database.Collection(name).UpdateMany(ctx,
+			bson.M{"tenant_id": bson.M{"$exists": false}},
+			bson.M{"$set": bson.M{"tenant_id": defaultTenantID}},
+		); err != nil {
+			return err
+		}
+	}
+
+	if err := backfillLifecycle(ctx, tasks, db.Database.Collection("task_activity")); err != nil {
+		return err
+	}
+
+	for collection, names := range legacyIndexes {
+		for _, name := range names {
+			if err := dropIndexIfExists(ctx, db.Database.Collection(collection), name); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// backfillLifecycle derives started_at and completed_at for tasks written
+// before they were stamped, from the status changes in the activity log. A
+// task whose log has a creation entry but no status change kept the status it
+// was created with. Tasks without any activity are left untouched.
+func backfillLifecycle(ctx context.Context, tasks, activity *mongo.Collection) error {
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"$or": bson.A{
+			bson.M{"status": bson.M{"$in": bson.A{"in_progress", "done"}}, "started_at": bson.M{"$exists": false}},
+			bson.M{"status": "done", "completed_at": bson.M{"$exists": false}},
+		}}}},
+		{{Key: "$project", Value: bson.M{"tenant_id": 1, "status": 1, "created_at": 1, "started_at": 1, "completed_at": 1}}},
+		{{Key: "$lookup", Value: bson.M{
+			"from": activity.Name(),
+			"let":  bson.M{"tenant_id": "$tenant_id", "task_id": "$_id"},
+			"pipeline": bson.A{
+				bson.M{"$match": bson.M{"$expr": bson.M{"$and": bson.A{
+					bson.M{"$eq": bson.A{"$tenant_id", "$$tenant_id"}},
+					bson.M{"$eq": bson.A{"$task_id", "$$task_id"}},
+				}}}},
+				bson.M{"$sort": bson.D{{Key: "created_at", Value: 1}, {Key: "_id", Value: 1}}},
+				bson.M{"$project": bson.M{
+					"action":     1,
+					"created_at": 1,
+					"changes": bson.M{"$filter": bson.M{
+						"input": bson.M{"$ifNull": bson.A{"$changes", bson.A{}}},
+						"cond":  bson.M{"$eq": bson.A{"$$this.field", "status"}},
+					}},
+				}},
+			},
+			"as": "activity",
+		}}},
+	}
+
+	cursor, err := tasks.Aggregate(ctx, pipeline)
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	type statusChange struct {
+		Before string `bson:"before"`
+		After  string `bson:"after"`
+	}
+	type entry struct {
+		Action    string         `bson:"action"`
+		CreatedAt time.Time      `bson:"created_at"`
+		Changes   []statusChange `bson:"changes"`
+	}
+	type candidate struct {
+		ID          primitive.ObjectID `bson:"_id"`
+		Status      string             `bson:"status"`
+		CreatedAt   time.Time          `bson:"created_at"`
+		StartedAt   *time.Time         `bson:"started_at"`
+		CompletedAt *time.Time         `bson:"completed_at"`
+		Activity    []entry            `bson:"activity"`
+	}
+
+	var writes []mongo.WriteModel
+	flush := func() error {
+		if len(writes) == 0 {
+			return nil
+		}
+		_, err := tasks.BulkWrite(ctx, writes, options.BulkWrite().SetOrdered(false))
+		writes = writes[:0]
+		return err
+	}
+
+	for cursor.Next(ctx) {
+		var task candidate
+		if err := cursor.Decode(&task); err != nil {
+			return err
+		}
+		if len(task.Activity) == 0 {
+			continue
+		}
+
+		// The status held at creation is the "before" of the first change,
+		// or the current status if it never changed.
+		initial := task.Status
+		var started, completed *time.Time
+		for _, e := range task.Activity {
+			for _, change := range e.Changes {
+				if started == nil && change.After == "in_progress" {
+					at := e.CreatedAt
+					started = &at
+				}
+				if change.After == "done" {
+					at := e.CreatedAt
+					completed = &at
+				}
+			}
+		}
+		for _, e := range task.Activity {
+			if len(e.Changes) > 0 {
+				initial = e.Changes[0].Before
+				break
+			}
+		}
+		if task.Activity[0].Action == "created" {
+			switch initial {
+			case "in_progress":
+				started = &task.CreatedAt
+			case "done":
+				if completed == nil {
+					completed = &task.CreatedAt
+				}
+			}
+		}
+
+		set := bson.M{}
+		if task.StartedAt == nil && started != nil {
+			set["started_at"] = *started
+		}
+		if task.Status == "done" && task.CompletedAt == nil && completed != nil {
+			set["completed_at"] = *completed
+		}
+		if len(set) == 0 {
+			continue
+		}
+
+		writes = append(writes, mongo.NewUpdateOneModel().
+			SetFilter(bson.M{"_id": task.ID}).
+			SetUpdate(bson.M{"$set": set}))
+		if len(writes) == 500 {
+			if err := flush(); err != nil {
+				return err
+			}
+		}
+	}
+	if err := cursor.Err(); err != nil {
+		return err
+	}
+
+	return flush()
+}
+
+func dropIndexIfExists(ctx context.Context, collection *mongo.Collection, name string) error {
+	_, err := collection.Indexes().DropOne(ctx, name)
+	var cmdErr mongo.CommandError
+	if errors.As(err, &cmdErr) && (cmdErr.Code == 26 || cmdErr.Code == 27) {
+		// NamespaceNotFound or IndexNotFound.
+		return nil
+	}
+	return err
+}
+
 func (db *DB) CreateIndexes() error {
 	ctx := context.Background()
-	collection := db.Database.Collection("tasks")
 
-	indexes := []mongo.IndexModel{
-		{
-			Keys: bson.D{
-				{Key: "owner_id", Value: 1},
-				{Key: "status", Value: 1},
+	// Indexes serving request queries lead with tenant_id because every such
+	// query is scoped to one workspace. Indexes used only by cross-workspace
+	// background jobs are left unprefixed.
+	indexes := map[string][]mongo.IndexModel{
+		// Active-task indexes are partial on deleted=false so that list
+		// queries never scan trashed documents. A separate flag is needed
+		// because partial filters cannot express deleted_at $exists: false.
+		"tasks": {
+			{
+				Keys: bson.D{
+					{Key: "tenant_id", Value: 1},
+					{Key: "owner_id", Value: 1},
+					{Key: "status", Value: 1},
+				},
+				Options: options.Index().
+					SetName("tenant_active_owner_id_status").
+					SetPartialFilterExpression(bson.M{"deleted": false}),
+			},
+			{
+				Keys: bson.D{
+					{Key: "tenant_id", Value: 1},
+					{Key: "owner_id", Value: 1},
+					{Key: "created_at", Value: -1},
+				},
+				Options: options.Index().
+					SetName("tenant_active_owner_id_created_at").
+					SetPartialFilterExpression(bson.M{"deleted": false}),
+			},
+			{
+				Keys: bson.D{
+					{Key: "tenant_id", Value: 1},
+					{Key: "project_id", Value: 1},
+					{Key: "created_at", Value: -1},
+				},
+				Options: options.Index().
+					SetName("tenant_active_project_id_created_at").
+					SetPartialFilterExpression(bson.M{"deleted": false}),
+			},
+			{
+				Keys: bson.D{
+					{Key: "tenant_id", Value: 1},
+					{Key: "completed_at", Value: -1},
+				},
+				Options: options.Index().
+					SetName("tenant_done_completed_at").
+					SetPartialFilterExpression(bson.M{"deleted": false, "status": "done"}),
+			},
+			{
+				Keys: bson.D{
+					{Key: "tenant_id", Value: 1},
+					{Key: "shares.principal_id", Value: 1},
+				},
+			},
+			{
+				Keys: bson.D{
+					{Key: "tenant_id", Value: 1},
+					{Key: "sprint_id", Value: 1},
+				},
+			},
+			{
+				Keys: bson.D{
+					{Key: "tenant_id", Value: 1},
+					{Key: "parent_id", Value: 1},
+				},
+			},
+			{
+				Keys: bson.D{
+					{Key: "tenant_id", Value: 1},
+					{Key: "milestone_id", Value: 1},
+				},
+			},
+			{
+				Keys: bson.D{
+					{Key: "tenant_id", Value: 1},
+					{Key: "owner_id", Value: 1},
+					{Key: "deleted_at", Value: -1},
+				},
+				Options: options.Index().
+					SetName("tenant_trash_owner_id_deleted_at").
+					SetPartialFilterExpression(bson.M{"deleted": true}),
+			},
+			{
+				Keys: bson.D{
+					{Key: "tenant_id", Value: 1},
+					{Key: "title", Value: "text"},
+					{Key: "description", Value: "text"},
+				},
+			},
+			{
+				Keys: bson.D{
+					{Key: "recurrence.series_id", Value: 1},
+					{Key: "recurrence.index", Value: 1},
+				},
+				Options: options.Index().
+					SetUnique(true).
+					SetPartialFilterExpression(bson.M{"recurrence.series_id": bson.M{"$exists": true}}),
+			},
+			{
+				Keys: bson.D{{Key: "snoozed_until", Value: 1}},
+				Options: options.Index().
+					SetPartialFilterExpression(bson.M{"deleted": false, "snoozed_until": bson.M{"$exists": true}}),
+			},
+			{
+				Keys: bson.D{{Key: "recurrence.next_at", Value: 1}},
+				Options: options.Index().
+					SetPartialFilterExpression(bson.M{"recurrence.spawned": false}),
+			},
+		},
+		"comments": {
+			{
+				Keys: bson.D{
+					{Key: "tenant_id", Value: 1},
+					{Key: "task_id", Value: 1},
+					{Key: "created_at", Value: 1},
+				},
+			},
+		},
+		"attachments": {
+			{
+				Keys: bson.D{
+					{Key: "tenant_id", Value: 1},
+					{Key: "task_id", Value: 1},
+					{Key: "created_at", Value: 1},
+				},
+			},
+			{
+				Keys: bson.D{
+					{Key: "storage_key", Value: 1},
+					{Key: "tenant_id", Value: 1},
+				},
+			},
+		},
+		"worklogs": {
+			{
+				Keys: bson.D{
+					{Key: "tenant_id", Value: 1},
+					{Key: "user_id", Value: 1},
+				},
+				Options: options.Index().
+					SetName("tenant_one_running_timer_per_user").
+					SetUnique(true).
+					SetPartialFilterExpression(bson.M{"running": true}),
+			},
+			{
+				Keys: bson.D{
+					{Key: "tenant_id", Value: 1},
+					{Key: "task_id", Value: 1},
+					{Key: "started_at", Value: -1},
+				},
+			},
+			{
+				Keys: bson.D{
+					{Key: "tenant_id", Value: 1},
+					{Key: "user_id", Value: 1},
+					{Key: "started_at", Value: 1},
+				},
+			},
+		},
+		"custom_fields": {
+			{
+				Keys: bson.D{
+					{Key: "tenant_id", Value: 1},
+					{Key: "project_id", Value: 1},
+					{Key: "key", Value: 1},
+				},
+				Options: options.Index().SetUnique(true),
+			},
+		},
+		"projects": {
+			{
+				Keys: bson.D{
+					{Key: "tenant_id", Value: 1},
+					{Key: "key", Value: 1},
+				},
+				Options: options.Index().SetUnique(true),
+			},
+			{
+				Keys: bson.D{
+					{Key: "tenant_id", Value: 1},
+					{Key: "members.user_id", Value: 1},
+				},
 			},
 		},
-		{
-			Keys: bson.D{
-				{Key: "title", Value: "text"},
-				{Key: "description", Value: "text"},
+		"sprints": {
+			{
+				Keys: bson.D{
+					{Key: "tenant_id", Value: 1},
+					{Key: "project_id", Value: 1},
+					{Key: "start_date", Value: 1},
+				},
+			},
+			{
+				Keys: bson.D{
+					{Key: "tenant_id", Value: 1},
+					{Key: "project_id", Value: 1},
+				},
+				Options: options.Index().
+					SetName("tenant_project_id_active").
+					SetUnique(true).
+					SetPartialFilterExpression(bson.M{"state": "active"}),
+			},
+		},
+		"milestones": {
+			{
+				Keys: bson.D{
+					{Key: "tenant_id", Value: 1},
+					{Key: "project_id", Value: 1},
+					{Key: "due_date", Value: 1},
+				},
+			},
+		},
+		"groups": {
+			{
+				Keys: bson.D{
+					{Key: "tenant_id", Value: 1},
+					{Key: "member_ids", Value: 1},
+				},
+			},
+		},
+		"views": {
+			{
+				Keys: bson.D{
+					{Key: "tenant_id", Value: 1},
+					{Key: "owner_id", Value: 1},
+					{Key: "name", Value: 1},
+				},
+			},
+			{
+				Keys: bson.D{
+					{Key: "tenant_id", Value: 1},
+					{Key: "project_id", Value: 1},
+				},
+			},
+			{
+				Keys: bson.D{
+					{Key: "tenant_id", Value: 1},
+					{Key: "owner_id", Value: 1},
+				},
+				Options: options.Index().
+					SetName("tenant_one_default_view_per_owner").
+					SetUnique(true).
+					SetPartialFilterExpression(bson.M{"default": true}),
+			},
+		},
+		"templates": {
+			{
+				Keys: bson.D{
+					{Key: "tenant_id", Value: 1},
+					{Key: "template_id", Value: 1},
+					{Key: "version", Value: -1},
+				},
+				Options: options.Index().SetUnique(true),
+			},
+			{
+				Keys: bson.D{
+					{Key: "tenant_id", Value: 1},
+					{Key: "owner_id", Value: 1},
+				},
+			},
+		},
+		"task_activity": {
+			{
+				Keys: bson.D{
+					{Key: "tenant_id", Value: 1},
+					{Key: "task_id", Value: 1},
+					{Key: "created_at", Value: -1},
+				},
+			},
+		},
+		"users": {
+			{
+				Keys: bson.D{
+					{Key: "tenant_id", Value: 1},
+					{Key: "email", Value: 1},
+				},
+				Options: options.Index().SetUnique(true),
+			},
+		},
+		"idempotency_keys": {
+			{
+				Keys: bson.D{
+					{Key: "tenant_id", Value: 1},
+					{Key: "user_id", Value: 1},
+					{Key: "key", Value: 1},
+				},
+				Options: options.Index().SetUnique(true),
+			},
+			{
+				Keys:    bson.D{{Key: "expires_at", Value: 1}},
+				Options: options.Index().SetExpireAfterSeconds(0),
+			},
+		},
+		"workspaces": {
+			{
+				Keys:    bson.D{{Key: "slug", Value: 1}},
+				Options: options.Index().SetUnique(true),
 			},
 		},
 	}
 
-	_, err := collection.Indexes().CreateMany(ctx, indexes)
-	return err
-}
\ No newline at end of file
+	for collection, models := range indexes {
+		if _, err := db.Database.Collection(collection).Indexes().CreateMany(ctx, models); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
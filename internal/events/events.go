@@ -0,0 +1,64 @@
+// Package events delivers domain events to in-process subscribers.
+package events
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type Type string
+
+const (
+	// TaskSnoozeExpired is published when a task's snooze ends and it
+	// reappears in default listings.
+	TaskSnoozeExpired Type = "task.snooze_expired"
+)
+
+type Event struct {
+	ID         primitive.ObjectID     `json:"id"`
+	Type       Type                   `json:"type"`
+	TenantID   primitive.ObjectID     `json:"tenant_id"`
+	TaskID     primitive.ObjectID     `json:"task_id"`
+	OccurredAt time.Time              `json:"occurred_at"`
+	Data       map[string]interface{} `json:"data,omitempty"`
+}
+
+type Handler func(ctx context.Context, event Event)
+
+// Bus calls the handlers subscribed to an event's type synchronously, in
+// subscription order.
+type Bus struct {
+	mu       sync.RWMutex
+	handlers map[Type][]Handler
+}
+
+func NewBus() *Bus {
+	return &Bus{handlers: map[Type][]Handler{}}
+}
+
+func (b *Bus) Subscribe(eventType Type, handler Handler) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.handlers[eventType] = append(b.handlers[eventType], handler)
+}
+
+// Publish fills in the event's ID and time if unset and delivers it.
+func (b *Bus) Publish(ctx context.Context, event Event) {
+	if event.ID.IsZero() {
+		event.ID = primitive.NewObjectID()
+	}
+	if event.OccurredAt.IsZero() {
+		event.OccurredAt = time.Now()
+	}
+
+	b.mu.RLock()
+	handlers := b.handlers[event.Type]
+	b.mu.RUnlock()
+
+	for _, handler := range handlers {
+		handler(ctx, event)
+	}
+}
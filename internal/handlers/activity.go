@@ -0,0 +1,42 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/grewalsk/task-api/internal/models"
+	"github.com/grewalsk/task-api/internal/services"
+	"github.com/grewalsk/task-api/internal/utils"
+	"go.uber.org/zap"
+)
+
+type ActivityHandler struct {
+	activityDAO *models.ActivityDAO
+	access      *services.AccessService
+	logger      *zap.Logger
+}
+
+func NewActivityHandler(activityDAO *models.ActivityDAO, access *services.AccessService, logger *zap.Logger) *ActivityHandler {
+	return &ActivityHandler{
+		activityDAO: activityDAO,
+		access:      access,
+		logger:      logger,
+	}
+}
+
+func (h *ActivityHandler) List(w http.ResponseWriter, r *http.Request) {
+	_, task, ok := loadTask(w, r, h.access, services.PermissionRead)
+	if !ok {
+		return
+	}
+
+	limit, offset := parsePagination(r, 20, 100)
+
+	activities, err := h.activityDAO.ListByTask(r.Context(), task.ID, limit, offset)
+	if err != nil {
+		h.logger.Error("Failed to list task activity", zap.Error(err))
+		utils.WriteError(w, http.StatusInternalServerError, "database_error", "Failed to list task activity")
+		return
+	}
+
+	utils.WriteSuccess(w, activities)
+}
@@ -0,0 +1,231 @@
+package handlers
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/grewalsk/task-api/internal/models"
+	"github.com/grewalsk/task-api/internal/services"
+	"github.com/grewalsk/task-api/internal/storage"
+	"github.com/grewalsk/task-api/internal/utils"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+)
+
+const checksumHeader = "X-Checksum-Sha256"
+
+type AttachmentHandler struct {
+	attachmentService *services.AttachmentService
+	attachmentDAO     *models.AttachmentDAO
+	access            *services.AccessService
+	logger            *zap.Logger
+}
+
+func NewAttachmentHandler(attachmentService *services.AttachmentService, attachmentDAO *models.AttachmentDAO, access *services.AccessService, logger *zap.Logger) *AttachmentHandler {
+	return &AttachmentHandler{
+		attachmentService: attachmentService,
+		attachmentDAO:     attachmentDAO,
+		access:            access,
+		logger:            logger,
+	}
+}
+
+func (h *AttachmentHandler) loadAttachment(w http.ResponseWriter, r *http.Request, task *models.Task) (*models.Attachment, bool) {
+	attachmentID, err := primitive.ObjectIDFromHex(chi.URLParam(r, "attachmentID"))
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "invalid_id", "Invalid attachment ID")
+		return nil, false
+	}
+
+	attachment, err := h.attachmentDAO.GetByID(r.Context(), task.ID, attachmentID)
+	if err != nil {
+		utils.WriteError(w, http.StatusNotFound, "not_found", "Attachment not found")
+		return nil, false
+	}
+
+	return attachment, true
+}
+
+// Upload accepts either a multipart/form-data body with a "file" part or the
+// raw file as the request body, in which case the name comes from the
+// filename query parameter or the Content-Disposition header.
+func (h *AttachmentHandler) Upload(w http.ResponseWriter, r *http.Request) {
+	user, task, ok := loadTask(w, r, h.access, services.PermissionEdit)
+	if !ok {
+		return
+	}
+
+	// Leave headroom for multipart boundaries and part headers.
+	r.Body = http.MaxBytesReader(w, r.Body, h.attachmentService.MaxBytes()+64<<10)
+
+	input := services.UploadInput{
+		TaskID:     task.ID,
+		UploaderID: user.UserID,
+		Checksum:   r.Header.Get(checksumHeader),
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType == "multipart/form-data" {
+		reader, err := r.MultipartReader()
+		if err != nil {
+			utils.WriteError(w, http.StatusBadRequest, "invalid_request", "Invalid multipart body")
+			return
+		}
+
+		for {
+			part, err := reader.NextPart()
+			if err == io.EOF {
+				utils.WriteError(w, http.StatusBadRequest, "invalid_request", "Missing file part")
+				return
+			}
+			if err != nil {
+				utils.WriteError(w, http.StatusBadRequest, "invalid_request", "Invalid multipart body")
+				return
+			}
+			if part.FormName() == "file" && part.FileName() != "" {
+				defer part.Close()
+				input.Filename = part.FileName()
+				input.Body = part
+				break
+			}
+			part.Close()
+		}
+	} else {
+		input.Filename = r.URL.Query().Get("filename")
+		if input.Filename == "" {
+			if _, params, err := mime.ParseMediaType(r.Header.Get("Content-Disposition")); err == nil {
+				input.Filename = params["filename"]
+			}
+		}
+		input.Body = r.Body
+	}
+
+	input.Filename = path.Base(strings.ReplaceAll(input.Filename, "\\", "/"))
+	if input.Filename == "" || input.Filename == "." || input.Filename == "/" {
+		utils.WriteError(w, http.StatusBadRequest, "invalid_request", "Filename is required")
+		return
+	}
+
+	attachment, err := h.attachmentService.Upload(r.Context(), input)
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		switch {
+		case errors.Is(err, services.ErrAttachmentTooLarge), errors.As(err, &maxBytesErr):
+			utils.WriteError(w, http.StatusRequestEntityTooLarge, "attachment_too_large", "Attachment exceeds the maximum size")
+		case errors.Is(err, services.ErrUnsupportedMediaType):
+			utils.WriteError(w, http.StatusUnsupportedMediaType, "unsupported_media_type", "Attachment type is not allowed")
+		case errors.Is(err, services.ErrChecksumMismatch):
+			utils.WriteError(w, http.StatusUnprocessableEntity, "checksum_mismatch", "Attachment checksum does not match")
+		default:
+			h.logger.Error("Failed to upload attachment", zap.Error(err))
+			utils.WriteError(w, http.StatusInternalServerError, "storage_error", "Failed to upload attachment")
+		}
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusCreated, attachment)
+}
+
+func (h *AttachmentHandler) List(w http.ResponseWriter, r *http.Request) {
+	_, task, ok := loadTask(w, r, h.access, services.PermissionRead)
+	if !ok {
+		return
+	}
+
+	attachments, err := h.attachmentDAO.ListByTask(r.Context(), task.ID)
+	if err != nil {
+		h.logger.Error("Failed to list attachments", zap.Error(err))
+		utils.WriteError(w, http.StatusInternalServerError, "database_error", "Failed to list attachments")
+		return
+	}
+
+	utils.WriteSuccess(w, attachments)
+}
+
+func (h *AttachmentHandler) GetByID(w http.ResponseWriter, r *http.Request) {
+	_, task, ok := loadTask(w, r, h.access, services.PermissionRead)
+	if !ok {
+		return
+	}
+
+	attachment, ok := h.loadAttachment(w, r, task)
+	if !ok {
+		return
+	}
+
+	utils.WriteSuccess(w, attachment)
+}
+
+func (h *AttachmentHandler) Download(w http.ResponseWriter, r *http.Request) {
+	_, task, ok := loadTask(w, r, h.access, services.PermissionRead)
+	if !ok {
+		return
+	}
+
+	attachment, ok := h.loadAttachment(w, r, task)
+	if !ok {
+		return
+	}
+
+	blob, err := h.attachmentService.Open(r.Context(), attachment)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			utils.WriteError(w, http.StatusNotFound, "not_found", "Attachment content not found")
+			return
+		}
+		h.logger.Error("Failed to open attachment", zap.Error(err))
+		utils.WriteError(w, http.StatusInternalServerError, "storage_error", "Failed to open attachment")
+		return
+	}
+	defer blob.Close()
+
+	w.Header().Set("Content-Type", attachment.ContentType)
+	w.Header().Set("Content-Length", strconv.FormatInt(attachment.Size, 10))
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": attachment.Filename}))
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Header().Set("ETag", `"`+attachment.Checksum+`"`)
+	w.Header().Set(checksumHeader, attachment.Checksum)
+	w.WriteHeader(http.StatusOK)
+
+	// The status line is already sent, so a corrupted blob can only be logged.
+	hasher := sha256.New()
+	if _, err := io.Copy(w, io.TeeReader(blob, hasher)); err != nil {
+		h.logger.Warn("Attachment download interrupted", zap.String("attachment_id", attachment.ID.Hex()), zap.Error(err))
+		return
+	}
+	if sum := hex.EncodeToString(hasher.Sum(nil)); sum != attachment.Checksum {
+		h.logger.Error("Attachment checksum mismatch on download",
+			zap.String("attachment_id", attachment.ID.Hex()),
+			zap.String("expected", attachment.Checksum),
+			zap.String("actual", sum),
+		)
+	}
+}
+
+func (h *AttachmentHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	_, task, ok := loadTask(w, r, h.access, services.PermissionEdit)
+	if !ok {
+		return
+	}
+
+	attachment, ok := h.loadAttachment(w, r, task)
+	if !ok {
+		return
+	}
+
+	if err := h.attachmentService.Remove(r.Context(), attachment); err != nil {
+		h.logger.Error("Failed to delete attachment", zap.Error(err))
+		utils.WriteError(w, http.StatusInternalServerError, "database_error", "Failed to delete attachment")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -2,6 +2,7 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 
 	"github.com/grewalsk/task-api/internal/models"
@@ -11,14 +12,16 @@ import (
 )
 
 type AuthHandler struct {
-	authService *services.AuthService
-	logger      *zap.Logger
+	authService      *services.AuthService
+	workspaceService *services.WorkspaceService
+	logger           *zap.Logger
 }
 
-func NewAuthHandler(authService *services.AuthService, logger *zap.Logger) *AuthHandler {
+func NewAuthHandler(authService *services.AuthService, workspaceService *services.WorkspaceService, logger *zap.Logger) *AuthHandler {
 	return &AuthHandler{
-		authService: authService,
-		logger:      logger,
+		authService:      authService,
+		workspaceService: workspaceService,
+		logger:           logger,
 	}
 }
 
@@ -30,17 +33,22 @@ func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if err := utils.ValidateStruct(req); err != nil {
-		utils.WriteError(w, http.StatusBadRequest, "validation_error", utils.FormatValidationError(err))
+		utils.WriteValidationError(w, err)
 		return
 	}
 
-	user := h.authService.CreateDefaultUser()
-	if req.Email != user.Email || !h.authService.VerifyPassword(req.Password, user.Password) {
-		utils.WriteError(w, http.StatusUnauthorized, "invalid_credentials", "Invalid email or password")
+	user, err := h.workspaceService.Authenticate(r.Context(), req.Workspace, req.Email, req.Password)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidCredentials) {
+			utils.WriteError(w, http.StatusUnauthorized, "invalid_credentials", "Invalid email or password")
+			return
+		}
+		h.logger.Error("Failed to authenticate user", zap.Error(err))
+		utils.WriteError(w, http.StatusInternalServerError, "database_error", "Failed to authenticate user")
 		return
 	}
 
-	token, err := h.authService.GenerateToken(user.ID, string(user.Role))
+	token, err := h.authService.GenerateToken(user.ID, user.TenantID, string(user.Role))
 	if err != nil {
 		h.logger.Error("Failed to generate token", zap.Error(err))
 		utils.WriteError(w, http.StatusInternalServerError, "token_error", "Failed to generate token")
@@ -0,0 +1,66 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/grewalsk/task-api/internal/middleware"
+	"github.com/grewalsk/task-api/internal/models"
+	"github.com/grewalsk/task-api/internal/services"
+	"github.com/grewalsk/task-api/internal/utils"
+	"go.uber.org/zap"
+)
+
+type BulkHandler struct {
+	bulkService *services.BulkService
+	logger      *zap.Logger
+}
+
+func NewBulkHandler(bulkService *services.BulkService, logger *zap.Logger) *BulkHandler {
+	return &BulkHandler{
+		bulkService: bulkService,
+		logger:      logger,
+	}
+}
+
+// Run applies one operation to many tasks and reports the outcome per task.
+func (h *BulkHandler) Run(w http.ResponseWriter, r *http.Request) {
+	user, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		utils.WriteError(w, http.StatusUnauthorized, "unauthorized", "User not found in context")
+		return
+	}
+
+	var req services.BulkRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "invalid_request", "Invalid JSON")
+		return
+	}
+
+	if err := utils.ValidateStruct(req); err != nil {
+		utils.WriteValidationError(w, err)
+		return
+	}
+
+	if (len(req.IDs) == 0) == (req.Filter == nil) {
+		utils.WriteError(w, http.StatusBadRequest, "validation_error", "Provide either ids or filter")
+		return
+	}
+
+	result, err := h.bulkService.Run(r.Context(), user, &req)
+	switch {
+	case errors.Is(err, services.ErrBulkTooLarge), errors.Is(err, services.ErrInvalidAssignees):
+		utils.WriteError(w, http.StatusBadRequest, "validation_error", err.Error())
+		return
+	case errors.Is(err, models.ErrConcurrentModification):
+		utils.WriteError(w, http.StatusConflict, "conflict", "Some tasks were modified concurrently; no changes were applied")
+		return
+	case err != nil:
+		h.logger.Error("Failed to run bulk operation", zap.Error(err))
+		utils.WriteError(w, http.StatusInternalServerError, "database_error", "Failed to run bulk operation")
+		return
+	}
+
+	utils.WriteSuccess(w, result)
+}
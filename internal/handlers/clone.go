@@ -0,0 +1,75 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/grewalsk/task-api/internal/models"
+	"github.com/grewalsk/task-api/internal/services"
+	"github.com/grewalsk/task-api/internal/utils"
+	"go.uber.org/zap"
+)
+
+type CloneHandler struct {
+	cloneService *services.CloneService
+	access       *services.AccessService
+	logger       *zap.Logger
+}
+
+func NewCloneHandler(cloneService *services.CloneService, access *services.AccessService, logger *zap.Logger) *CloneHandler {
+	return &CloneHandler{
+		cloneService: cloneService,
+		access:       access,
+		logger:       logger,
+	}
+}
+
+// taskTreeResponse is a created task with the subtasks created with it.
+type taskTreeResponse struct {
+	Task     *models.Task   `json:"task"`
+	Subtasks []*models.Task `json:"subtasks"`
+}
+
+// Clone duplicates a task the user can read. The copy stays in the task's
+// project, which must not be archived.
+func (h *CloneHandler) Clone(w http.ResponseWriter, r *http.Request) {
+	user, task, ok := loadTask(w, r, h.access, services.PermissionRead)
+	if !ok {
+		return
+	}
+
+	var opts services.CloneOptions
+	if err := json.NewDecoder(r.Body).Decode(&opts); err != nil && !errors.Is(err, io.EOF) {
+		utils.WriteError(w, http.StatusBadRequest, "invalid_request", "Invalid JSON")
+		return
+	}
+
+	if err := utils.ValidateStruct(opts); err != nil {
+		utils.WriteValidationError(w, err)
+		return
+	}
+
+	if task.ProjectID != nil {
+		if _, ok := loadProjectForNewTask(w, r, h.access, *task.ProjectID); !ok {
+			return
+		}
+	}
+
+	tasks, err := h.cloneService.Clone(r.Context(), user, task, opts)
+	if err != nil {
+		if errors.Is(err, services.ErrCloneTooLarge) {
+			utils.WriteError(w, http.StatusBadRequest, "clone_too_large", err.Error())
+			return
+		}
+		h.logger.Error("Failed to clone task", zap.Error(err))
+		utils.WriteError(w, http.StatusInternalServerError, "database_error", "Failed to clone task")
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusCreated, taskTreeResponse{
+		Task:     tasks[0],
+		Subtasks: tasks[1:],
+	})
+}
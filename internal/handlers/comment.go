@@ -0,0 +1,181 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/grewalsk/task-api/internal/models"
+	"github.com/grewalsk/task-api/internal/services"
+	"github.com/grewalsk/task-api/internal/utils"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.uber.org/zap"
+)
+
+type CommentHandler struct {
+	commentDAO *models.CommentDAO
+	access     *services.AccessService
+	logger     *zap.Logger
+}
+
+func NewCommentHandler(commentDAO *models.CommentDAO, access *services.AccessService, logger *zap.Logger) *CommentHandler {
+	return &CommentHandler{
+		commentDAO: commentDAO,
+		access:     access,
+		logger:     logger,
+	}
+}
+
+type commentRequest struct {
+	Body string `json:"body" validate:"required,min=1,max=5000"`
+}
+
+func (h *CommentHandler) loadComment(w http.ResponseWriter, r *http.Request, task *models.Task) (*models.Comment, bool) {
+	commentID, err := primitive.ObjectIDFromHex(chi.URLParam(r, "commentID"))
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "invalid_id", "Invalid comment ID")
+		return nil, false
+	}
+
+	comment, err := h.commentDAO.GetByID(r.Context(), task.ID, commentID)
+	if err != nil {
+		utils.WriteError(w, http.StatusNotFound, "not_found", "Comment not found")
+		return nil, false
+	}
+
+	return comment, true
+}
+
+func (h *CommentHandler) Create(w http.ResponseWriter, r *http.Request) {
+	user, task, ok := loadTask(w, r, h.access, services.PermissionComment)
+	if !ok {
+		return
+	}
+
+	var req commentRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "invalid_request", "Invalid JSON")
+		return
+	}
+
+	if err := utils.ValidateStruct(req); err != nil {
+		utils.WriteValidationError(w, err)
+		return
+	}
+
+	comment := models.Comment{
+		TaskID:   task.ID,
+		AuthorID: user.UserID,
+		Body:     req.Body,
+	}
+
+	if err := h.commentDAO.Create(r.Context(), &comment); err != nil {
+		h.logger.Error("Failed to create comment", zap.Error(err))
+		utils.WriteError(w, http.StatusInternalServerError, "database_error", "Failed to create comment")
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusCreated, comment)
+}
+
+func (h *CommentHandler) GetByID(w http.ResponseWriter, r *http.Request) {
+	_, task, ok := loadTask(w, r, h.access, services.PermissionRead)
+	if !ok {
+		return
+	}
+
+	comment, ok := h.loadComment(w, r, task)
+	if !ok {
+		return
+	}
+
+	utils.WriteSuccess(w, comment)
+}
+
+func (h *CommentHandler) Update(w http.ResponseWriter, r *http.Request) {
+	user, task, ok := loadTask(w, r, h.access, services.PermissionComment)
+	if !ok {
+		return
+	}
+
+	comment, ok := h.loadComment(w, r, task)
+	if !ok {
+		return
+	}
+
+	if comment.AuthorID != user.UserID {
+		utils.WriteError(w, http.StatusForbidden, "forbidden", "Only the author can edit a comment")
+		return
+	}
+
+	var req commentRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "invalid_request", "Invalid JSON")
+		return
+	}
+
+	if err := utils.ValidateStruct(req); err != nil {
+		utils.WriteValidationError(w, err)
+		return
+	}
+
+	if req.Body != comment.Body {
+		if err := h.commentDAO.Update(r.Context(), comment, req.Body); err != nil {
+			if errors.Is(err, mongo.ErrNoDocuments) {
+				utils.WriteError(w, http.StatusConflict, "conflict", "Comment was modified concurrently")
+				return
+			}
+			h.logger.Error("Failed to update comment", zap.Error(err))
+			utils.WriteError(w, http.StatusInternalServerError, "database_error", "Failed to update comment")
+			return
+		}
+	}
+
+	updatedComment, _ := h.commentDAO.GetByID(r.Context(), task.ID, comment.ID)
+	utils.WriteSuccess(w, updatedComment)
+}
+
+func (h *CommentHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	user, task, ok := loadTask(w, r, h.access, services.PermissionComment)
+	if !ok {
+		return
+	}
+
+	comment, ok := h.loadComment(w, r, task)
+	if !ok {
+		return
+	}
+
+	if comment.AuthorID != user.UserID && user.Role != string(models.RoleAdmin) {
+		utils.WriteError(w, http.StatusForbidden, "forbidden", "Cannot delete comment written by another user")
+		return
+	}
+
+	if err := h.commentDAO.Delete(r.Context(), comment.ID); err != nil {
+		h.logger.Error("Failed to delete comment", zap.Error(err))
+		utils.WriteError(w, http.StatusInternalServerError, "database_error", "Failed to delete comment")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *CommentHandler) List(w http.ResponseWriter, r *http.Request) {
+	_, task, ok := loadTask(w, r, h.access, services.PermissionRead)
+	if !ok {
+		return
+	}
+
+	limit, offset := parsePagination(r, 20, 100)
+
+	comments, err := h.commentDAO.ListByTask(r.Context(), task.ID, limit, offset)
+	if err != nil {
+		h.logger.Error("Failed to list comments", zap.Error(err))
+		utils.WriteError(w, http.StatusInternalServerError, "database_error", "Failed to list comments")
+		return
+	}
+
+	utils.WriteSuccess(w, comments)
+}
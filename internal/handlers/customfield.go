@@ -0,0 +1,255 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/grewalsk/task-api/internal/middleware"
+	"github.com/grewalsk/task-api/internal/models"
+	"github.com/grewalsk/task-api/internal/utils"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.uber.org/zap"
+)
+
+type CustomFieldHandler struct {
+	customFieldDAO *models.CustomFieldDAO
+	logger         *zap.Logger
+}
+
+func NewCustomFieldHandler(customFieldDAO *models.CustomFieldDAO, logger *zap.Logger) *CustomFieldHandler {
+	return &CustomFieldHandler{
+		customFieldDAO: customFieldDAO,
+		logger:         logger,
+	}
+}
+
+type customFieldUpdateRequest struct {
+	Name     *string  `json:"name" validate:"omitempty,min=1,max=100"`
+	Options  []string `json:"options" validate:"omitempty,max=100,dive,min=1,max=100"`
+	Required *bool    `json:"required"`
+}
+
+func requireAdmin(w http.ResponseWriter, r *http.Request) bool {
+	user, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		utils.WriteError(w, http.StatusUnauthorized, "unauthorized", "User not found in context")
+		return false
+	}
+
+	if user.Role != string(models.RoleAdmin) {
+		utils.WriteError(w, http.StatusForbidden, "forbidden", "Admin role required")
+		return false
+	}
+
+	return true
+}
+
+func validateOptions(fieldType models.CustomFieldType, options []string) string {
+	hasOptions := fieldType == models.CustomFieldSelect || fieldType == models.CustomFieldMultiSelect
+	if hasOptions && len(options) == 0 {
+		return "options are required for select fields"
+	}
+	if !hasOptions && len(options) > 0 {
+		return "options are only allowed for select fields"
+	}
+	return ""
+}
+
+func (h *CustomFieldHandler) Create(w http.ResponseWriter, r *http.Request) {
+	if !requireAdmin(w, r) {
+		return
+	}
+
+	var def models.CustomFieldDefinition
+	if err := json.NewDecoder(r.Body).Decode(&def); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "invalid_request", "Invalid JSON")
+		return
+	}
+
+	if err := utils.ValidateStruct(def); err != nil {
+		utils.WriteValidationError(w, err)
+		return
+	}
+
+	if !models.ValidCustomFieldKey(def.Key) {
+		utils.WriteError(w, http.StatusBadRequest, "validation_error", "key must start with a lowercase letter and contain only lowercase letters, digits and underscores")
+		return
+	}
+
+	if msg := validateOptions(def.Type, def.Options); msg != "" {
+		utils.WriteError(w, http.StatusBadRequest, "validation_error", msg)
+		return
+	}
+
+	inUse, err := h.customFieldDAO.KeyInUse(r.Context(), def.Key, def.ProjectID)
+	if err != nil {
+		h.logger.Error("Failed to check custom field key", zap.Error(err))
+		utils.WriteError(w, http.StatusInternalServerError, "database_error", "Failed to create custom field")
+		return
+	}
+	if inUse {
+		utils.WriteError(w, http.StatusConflict, "conflict", "A custom field with this key already exists")
+		return
+	}
+
+	if err := h.customFieldDAO.Create(r.Context(), &def); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			utils.WriteError(w, http.StatusConflict, "conflict", "A custom field with this key already exists")
+			return
+		}
+		h.logger.Error("Failed to create custom field", zap.Error(err))
+		utils.WriteError(w, http.StatusInternalServerError, "database_error", "Failed to create custom field")
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusCreated, def)
+}
+
+func (h *CustomFieldHandler) List(w http.ResponseWriter, r *http.Request) {
+	var projectID *primitive.ObjectID
+	if projectStr := r.URL.Query().Get("project_id"); projectStr != "" {
+		id, err := primitive.ObjectIDFromHex(projectStr)
+		if err != nil {
+			utils.WriteError(w, http.StatusBadRequest, "invalid_id", "Invalid project ID")
+			return
+		}
+		projectID = &id
+	}
+
+	defs, err := h.customFieldDAO.List(r.Context(), projectID)
+	if err != nil {
+		h.logger.Error("Failed to list custom fields", zap.Error(err))
+		utils.WriteError(w, http.StatusInternalServerError, "database_error", "Failed to list custom fields")
+		return
+	}
+
+	utils.WriteSuccess(w, defs)
+}
+
+func (h *CustomFieldHandler) GetByID(w http.ResponseWriter, r *http.Request) {
+	id, err := primitive.ObjectIDFromHex(chi.URLParam(r, "id"))
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "invalid_id", "Invalid custom field ID")
+		return
+	}
+
+	def, err := h.customFieldDAO.GetByID(r.Context(), id)
+	if err != nil {
+		utils.WriteError(w, http.StatusNotFound, "not_found", "Custom field not found")
+		return
+	}
+
+	utils.WriteSuccess(w, def)
+}
+
+// Update changes the name, options or required flag. Key and type are
+// immutable because existing task values depend on them.
+func (h *CustomFieldHandler) Update(w http.ResponseWriter, r *http.Request) {
+	if !requireAdmin(w, r) {
+		return
+	}
+
+	id, err := primitive.ObjectIDFromHex(chi.URLParam(r, "id"))
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "invalid_id", "Invalid custom field ID")
+		return
+	}
+
+	def, err := h.customFieldDAO.GetByID(r.Context(), id)
+	if err != nil {
+		utils.WriteError(w, http.StatusNotFound, "not_found", "Custom field not found")
+		return
+	}
+
+	var req customFieldUpdateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "invalid_request", "Invalid JSON")
+		return
+	}
+
+	if err := utils.ValidateStruct(req); err != nil {
+		utils.WriteValidationError(w, err)
+		return
+	}
+
+	updateDoc := bson.M{}
+	if req.Name != nil {
+		updateDoc["name"] = *req.Name
+	}
+	if req.Options != nil {
+		if msg := validateOptions(def.Type, req.Options); msg != "" {
+			utils.WriteError(w, http.StatusBadRequest, "validation_error", msg)
+			return
+		}
+		var removed []string
+		for _, option := range def.Options {
+			if !slices.Contains(req.Options, option) {
+				removed = append(removed, option)
+			}
+		}
+		used, err := h.customFieldDAO.OptionsInUse(r.Context(), def, removed)
+		if err != nil {
+			h.logger.Error("Failed to check custom field options", zap.Error(err))
+			utils.WriteError(w, http.StatusInternalServerError, "database_error", "Failed to update custom field")
+			return
+		}
+		if len(used) > 0 {
+			utils.WriteError(w, http.StatusConflict, "option_in_use", "Options still used by tasks cannot be removed: "+strings.Join(used, ", "))
+			return
+		}
+		updateDoc["options"] = req.Options
+	}
+	if req.Required != nil {
+		updateDoc["required"] = *req.Required
+	}
+
+	if len(updateDoc) == 0 {
+		utils.WriteError(w, http.StatusBadRequest, "no_updates", "No valid fields to update")
+		return
+	}
+
+	if err := h.customFieldDAO.Update(r.Context(), id, updateDoc); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			utils.WriteError(w, http.StatusNotFound, "not_found", "Custom field not found")
+			return
+		}
+		h.logger.Error("Failed to update custom field", zap.Error(err))
+		utils.WriteError(w, http.StatusInternalServerError, "database_error", "Failed to update custom field")
+		return
+	}
+
+	updated, _ := h.customFieldDAO.GetByID(r.Context(), id)
+	utils.WriteSuccess(w, updated)
+}
+
+func (h *CustomFieldHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	if !requireAdmin(w, r) {
+		return
+	}
+
+	id, err := primitive.ObjectIDFromHex(chi.URLParam(r, "id"))
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "invalid_id", "Invalid custom field ID")
+		return
+	}
+
+	def, err := h.customFieldDAO.GetByID(r.Context(), id)
+	if err != nil {
+		utils.WriteError(w, http.StatusNotFound, "not_found", "Custom field not found")
+		return
+	}
+
+	if err := h.customFieldDAO.Delete(r.Context(), def); err != nil {
+		h.logger.Error("Failed to delete custom field", zap.Error(err))
+		utils.WriteError(w, http.StatusInternalServerError, "database_error", "Failed to delete custom field")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,165 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"reflect"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/grewalsk/task-api/internal/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// taskFieldPaths maps the JSON name of each task field to its document path.
+var taskFieldPaths = jsonFieldPaths(reflect.TypeOf(models.Task{}))
+
+var expandableTaskRelations = map[string]bool{
+	"owner":     true,
+	"assignees": true,
+}
+
+func jsonFieldPaths(t reflect.Type) map[string]string {
+	paths := make(map[string]string, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		path, _, _ := strings.Cut(field.Tag.Get("bson"), ",")
+		if name == "" || name == "-" || path == "" || path == "-" {
+			continue
+		}
+		paths[name] = path
+	}
+	return paths
+}
+
+// taskView selects how tasks are returned: Fields limits them to the named
+// JSON fields, all when empty, and Expand embeds related resources.
+type taskView struct {
+	Fields []string
+	Expand map[string]bool
+}
+
+// parseTaskView reads the comma-separated fields and expand parameters.
+func parseTaskView(q url.Values) (*taskView, error) {
+	view := &taskView{Expand: map[string]bool{}}
+
+	if param := q.Get("fields"); param != "" {
+		seen := map[string]bool{}
+		for _, name := range strings.Split(param, ",") {
+			name = strings.TrimSpace(name)
+			if _, ok := taskFieldPaths[name]; !ok {
+				return nil, fmt.Errorf("unknown field %q", name)
+			}
+			if !seen[name] {
+				seen[name] = true
+				view.Fields = append(view.Fields, name)
+			}
+		}
+	}
+
+	if param := q.Get("expand"); param != "" {
+		for _, name := range strings.Split(param, ",") {
+			name = strings.TrimSpace(name)
+			if !expandableTaskRelations[name] {
+				return nil, fmt.Errorf("cannot expand %q", name)
+			}
+			view.Expand[name] = true
+		}
+	}
+
+	return view, nil
+}
+
+// full reports whether tasks are returned as they are.
+func (v *taskView) full() bool {
+	return len(v.Fields) == 0 && len(v.Expand) == 0
+}
+
+// key identifies the view independently of the order its fields and
+// expansions were given in.
+func (v *taskView) key() string {
+	fields := slices.Clone(v.Fields)
+	sort.Strings(fields)
+	expand := make([]string, 0, len(v.Expand))
+	for name := range v.Expand {
+		expand = append(expand, name)
+	}
+	sort.Strings(expand)
+	return "fields=" + strings.Join(fields, ",") + ";expand=" + strings.Join(expand, ",")
+}
+
+// paths returns the document fields to load: those requested, those the
+// expansions refer to and extra. It returns nil when every field is needed.
+func (v *taskView) paths(extra ...string) []string {
+	if len(v.Fields) == 0 {
+		return nil
+	}
+
+	paths := make([]string, 0, len(v.Fields)+len(extra)+2)
+	for _, name := range v.Fields {
+		paths = append(paths, taskFieldPaths[name])
+	}
+	if v.Expand["owner"] {
+		paths = append(paths, "owner_id")
+	}
+	if v.Expand["assignees"] {
+		paths = append(paths, "assignee_ids")
+	}
+	return append(paths, extra...)
+}
+
+// renderTasks shapes tasks for a view, loading the users to embed with a
+// single query.
+func (h *TaskHandler) renderTasks(ctx context.Context, view *taskView, tasks []*models.Task) ([]map[string]interface{}, error) {
+	var ids []primitive.ObjectID
+	for _, task := range tasks {
+		if view.Expand["owner"] {
+			ids = append(ids, task.OwnerID)
+		}
+		if view.Expand["assignees"] {
+			ids = append(ids, task.AssigneeIDs...)
+		}
+	}
+	users, err := h.userDAO.Summaries(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	rendered := make([]map[string]interface{}, len(tasks))
+	for i, task := range tasks {
+		doc, err := taskDocument(task)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(view.Fields) > 0 {
+			selected := map[string]interface{}{"id": doc["id"]}
+			for _, name := range view.Fields {
+				if value, ok := doc[name]; ok {
+					selected[name] = value
+				}
+			}
+			doc = selected
+		}
+
+		if view.Expand["owner"] {
+			doc["owner"] = users[task.OwnerID]
+		}
+		if view.Expand["assignees"] {
+			assignees := []*models.UserSummary{}
+			for _, id := range task.AssigneeIDs {
+				if user, ok := users[id]; ok {
+					assignees = append(assignees, user)
+				}
+			}
+			doc["assignees"] = assignees
+		}
+
+		rendered[i] = doc
+	}
+
+	return rendered, nil
+}
@@ -0,0 +1,203 @@
+package handlers
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/grewalsk/task-api/internal/models"
+	"github.com/grewalsk/task-api/internal/query"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// maxSortKeys bounds how many keys a task listing can be sorted by.
+const maxSortKeys = 5
+
+var sortableTaskFields = map[string]bool{
+	"created_at":   true,
+	"updated_at":   true,
+	"started_at":   true,
+	"completed_at": true,
+	"title":        true,
+	"status":       true,
+}
+
+// taskFilterFields are the core task fields a filter expression can use.
+var taskFilterFields = map[string]query.Field{
+	"status":                     {Path: "status", Parse: parseStatusLiteral},
+	"title":                      {Path: "title", Parse: parseStringLiteral, Text: true},
+	"description":                {Path: "description", Parse: parseStringLiteral, Text: true},
+	"labels":                     {Path: "labels", Parse: parseStringLiteral, Text: true},
+	"owner_id":                   {Path: "owner_id", Parse: parseIDLiteral},
+	"assignee_ids":               {Path: "assignee_ids", Parse: parseIDLiteral},
+	"project_id":                 {Path: "project_id", Parse: parseIDLiteral},
+	"parent_id":                  {Path: "parent_id", Parse: parseIDLiteral},
+	"sprint_id":                  {Path: "sprint_id", Parse: parseIDLiteral},
+	"milestone_id":               {Path: "milestone_id", Parse: parseIDLiteral},
+	"original_estimate_minutes":  {Path: "original_estimate_minutes", Parse: parseIntLiteral, Ordered: true},
+	"remaining_estimate_minutes": {Path: "remaining_estimate_minutes", Parse: parseIntLiteral, Ordered: true},
+	"created_at":                 {Path: "created_at", Parse: parseTimeLiteral, Ordered: true},
+	"updated_at":                 {Path: "updated_at", Parse: parseTimeLiteral, Ordered: true},
+	"started_at":                 {Path: "started_at", Parse: parseTimeLiteral, Ordered: true},
+	"completed_at":               {Path: "completed_at", Parse: parseTimeLiteral, Ordered: true},
+	"snoozed_until":              {Path: "snoozed_until", Parse: parseTimeLiteral, Ordered: true},
+}
+
+// customFieldLookup finds a custom field definition by key.
+type customFieldLookup func(key string) (*models.CustomFieldDefinition, error)
+
+// taskFieldResolver resolves the fields of a filter expression: the core
+// fields above and cf.<key> for custom fields.
+func taskFieldResolver(lookup customFieldLookup) query.Resolver {
+	return func(name string) (*query.Field, error) {
+		key, ok := strings.CutPrefix(name, "cf.")
+		if !ok {
+			field, ok := taskFilterFields[name]
+			if !ok {
+				return nil, fmt.Errorf("unsupported field %q", name)
+			}
+			return &field, nil
+		}
+
+		def, err := lookup(key)
+		if err != nil {
+			return nil, err
+		}
+		return &query.Field{
+			Path:    "custom_fields." + key,
+			Parse:   func(s string) (interface{}, error) { return customFieldValue(def, s) },
+			Ordered: def.Type == models.CustomFieldNumber || def.Type == models.CustomFieldDate,
+			Text:    def.Type == models.CustomFieldText,
+		}, nil
+	}
+}
+
+// customFieldValue converts a query string value for a custom field. A single
+// multi-select option matches tasks whose list contains it.
+func customFieldValue(def *models.CustomFieldDefinition, s string) (interface{}, error) {
+	value, err := def.Normalize(s)
+	if err != nil {
+		return nil, err
+	}
+	if selected, ok := value.([]string); ok && len(selected) == 1 {
+		return selected[0], nil
+	}
+	return value, nil
+}
+
+// parseSort reads a comma-separated list of sort keys, each a core field or
+// cf.<key> prefixed with - for descending order.
+func parseSort(param string, lookup customFieldLookup) ([]models.SortKey, error) {
+	parts := strings.Split(param, ",")
+	if len(parts) > maxSortKeys {
+		return nil, fmt.Errorf("cannot sort by more than %d keys", maxSortKeys)
+	}
+
+	keys := make([]models.SortKey, 0, len(parts))
+	seen := make(map[string]bool, len(parts))
+	for _, part := range parts {
+		part = strings.TrimSpace(part)
+		field := strings.TrimPrefix(part, "-")
+
+		var path string
+		if key, ok := strings.CutPrefix(field, "cf."); ok {
+			if _, err := lookup(key); err != nil {
+				return nil, err
+			}
+			path = "custom_fields." + key
+		} else if sortableTaskFields[field] {
+			path = field
+		} else {
+			return nil, fmt.Errorf("cannot sort by %q", field)
+		}
+
+		if seen[path] {
+			return nil, fmt.Errorf("%q appears more than once in sort", field)
+		}
+		seen[path] = true
+		keys = append(keys, models.SortKey{Field: path, Desc: strings.HasPrefix(part, "-")})
+	}
+
+	return keys, nil
+}
+
+// formatSort is the canonical form of a sort, recorded in cursors.
+func formatSort(keys []models.SortKey) string {
+	parts := make([]string, len(keys))
+	for i, key := range keys {
+		parts[i] = key.Field
+		if key.Desc {
+			parts[i] = "-" + key.Field
+		}
+	}
+	return strings.Join(parts, ",")
+}
+
+// parseStatuses reads the status parameter, which may be repeated or hold a
+// comma-separated list.
+func parseStatuses(values []string) ([]models.TaskStatus, error) {
+	var statuses []models.TaskStatus
+	for _, value := range values {
+		for _, s := range strings.Split(value, ",") {
+			status, err := parseStatusLiteral(strings.TrimSpace(s))
+			if err != nil {
+				return nil, fmt.Errorf("status %s", err)
+			}
+			statuses = append(statuses, status.(models.TaskStatus))
+		}
+	}
+	return statuses, nil
+}
+
+// parseTimeParam reads an optional date or timestamp parameter.
+func parseTimeParam(q url.Values, param string) (*time.Time, error) {
+	value := q.Get(param)
+	if value == "" {
+		return nil, nil
+	}
+	t, err := parseTimeLiteral(value)
+	if err != nil {
+		return nil, fmt.Errorf("%s %s", param, err)
+	}
+	parsed := t.(time.Time)
+	return &parsed, nil
+}
+
+func parseStatusLiteral(s string) (interface{}, error) {
+	switch status := models.TaskStatus(s); status {
+	case models.StatusOpen, models.StatusInProgress, models.StatusDone:
+		return status, nil
+	}
+	return nil, fmt.Errorf("must be one of open, in_progress, done")
+}
+
+func parseStringLiteral(s string) (interface{}, error) {
+	return s, nil
+}
+
+func parseIDLiteral(s string) (interface{}, error) {
+	id, err := primitive.ObjectIDFromHex(s)
+	if err != nil {
+		return nil, fmt.Errorf("must be an ID")
+	}
+	return id, nil
+}
+
+func parseIntLiteral(s string) (interface{}, error) {
+	n, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("must be an integer")
+	}
+	return n, nil
+}
+
+func parseTimeLiteral(s string) (interface{}, error) {
+	for _, layout := range []string{time.RFC3339, time.DateOnly} {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t.UTC(), nil
+		}
+	}
+	return nil, fmt.Errorf("must be a date in YYYY-MM-DD or RFC 3339 format")
+}
@@ -0,0 +1,206 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/grewalsk/task-api/internal/middleware"
+	"github.com/grewalsk/task-api/internal/models"
+	"github.com/grewalsk/task-api/internal/utils"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.uber.org/zap"
+)
+
+type GroupHandler struct {
+	groupDAO *models.GroupDAO
+	userDAO  *models.UserDAO
+	logger   *zap.Logger
+}
+
+func NewGroupHandler(groupDAO *models.GroupDAO, userDAO *models.UserDAO, logger *zap.Logger) *GroupHandler {
+	return &GroupHandler{
+		groupDAO: groupDAO,
+		userDAO:  userDAO,
+		logger:   logger,
+	}
+}
+
+type groupUpdateRequest struct {
+	Name      *string              `json:"name" validate:"omitempty,min=1,max=100"`
+	MemberIDs []primitive.ObjectID `json:"member_ids" validate:"omitempty,max=500"`
+}
+
+// checkMembers deduplicates member IDs and checks that each refers to a user
+// in the workspace.
+func (h *GroupHandler) checkMembers(w http.ResponseWriter, r *http.Request, ids []primitive.ObjectID) ([]primitive.ObjectID, bool) {
+	seen := make(map[primitive.ObjectID]bool, len(ids))
+	members := make([]primitive.ObjectID, 0, len(ids))
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			members = append(members, id)
+		}
+	}
+
+	if len(members) == 0 {
+		return members, true
+	}
+
+	count, err := h.userDAO.CountByIDs(r.Context(), members)
+	if err != nil {
+		h.logger.Error("Failed to check group members", zap.Error(err))
+		utils.WriteError(w, http.StatusInternalServerError, "database_error", "Failed to check group members")
+		return nil, false
+	}
+	if count != int64(len(members)) {
+		utils.WriteError(w, http.StatusBadRequest, "validation_error", "member_ids must refer to users in this workspace")
+		return nil, false
+	}
+
+	return members, true
+}
+
+func (h *GroupHandler) Create(w http.ResponseWriter, r *http.Request) {
+	if !requireAdmin(w, r) {
+		return
+	}
+	user, _ := middleware.GetUserFromContext(r.Context())
+
+	var group models.Group
+	if err := json.NewDecoder(r.Body).Decode(&group); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "invalid_request", "Invalid JSON")
+		return
+	}
+
+	if err := utils.ValidateStruct(group); err != nil {
+		utils.WriteValidationError(w, err)
+		return
+	}
+
+	members, ok := h.checkMembers(w, r, group.MemberIDs)
+	if !ok {
+		return
+	}
+	group.MemberIDs = members
+	group.CreatedBy = user.UserID
+
+	if err := h.groupDAO.Create(r.Context(), &group); err != nil {
+		h.logger.Error("Failed to create group", zap.Error(err))
+		utils.WriteError(w, http.StatusInternalServerError, "database_error", "Failed to create group")
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusCreated, group)
+}
+
+func (h *GroupHandler) List(w http.ResponseWriter, r *http.Request) {
+	limit, offset := parsePagination(r, 50, 100)
+
+	groups, err := h.groupDAO.List(r.Context(), limit, offset)
+	if err != nil {
+		h.logger.Error("Failed to list groups", zap.Error(err))
+		utils.WriteError(w, http.StatusInternalServerError, "database_error", "Failed to list groups")
+		return
+	}
+
+	utils.WriteSuccess(w, groups)
+}
+
+func (h *GroupHandler) GetByID(w http.ResponseWriter, r *http.Request) {
+	id, err := primitive.ObjectIDFromHex(chi.URLParam(r, "id"))
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "invalid_id", "Invalid group ID")
+		return
+	}
+
+	group, err := h.groupDAO.GetByID(r.Context(), id)
+	if err != nil {
+		utils.WriteError(w, http.StatusNotFound, "not_found", "Group not found")
+		return
+	}
+
+	utils.WriteSuccess(w, group)
+}
+
+// Update renames the group or replaces its member list.
+func (h *GroupHandler) Update(w http.ResponseWriter, r *http.Request) {
+	if !requireAdmin(w, r) {
+		return
+	}
+
+	id, err := primitive.ObjectIDFromHex(chi.URLParam(r, "id"))
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "invalid_id", "Invalid group ID")
+		return
+	}
+
+	var req groupUpdateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "invalid_request", "Invalid JSON")
+		return
+	}
+
+	if err := utils.ValidateStruct(req); err != nil {
+		utils.WriteValidationError(w, err)
+		return
+	}
+
+	updateDoc := bson.M{}
+	if req.Name != nil {
+		updateDoc["name"] = *req.Name
+	}
+	if req.MemberIDs != nil {
+		members, ok := h.checkMembers(w, r, req.MemberIDs)
+		if !ok {
+			return
+		}
+		updateDoc["member_ids"] = members
+	}
+
+	if len(updateDoc) == 0 {
+		utils.WriteError(w, http.StatusBadRequest, "no_updates", "No valid fields to update")
+		return
+	}
+
+	if err := h.groupDAO.Update(r.Context(), id, updateDoc); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			utils.WriteError(w, http.StatusNotFound, "not_found", "Group not found")
+			return
+		}
+		h.logger.Error("Failed to update group", zap.Error(err))
+		utils.WriteError(w, http.StatusInternalServerError, "database_error", "Failed to update group")
+		return
+	}
+
+	updated, _ := h.groupDAO.GetByID(r.Context(), id)
+	utils.WriteSuccess(w, updated)
+}
+
+// Delete removes the group along with every task share granted to it.
+func (h *GroupHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	if !requireAdmin(w, r) {
+		return
+	}
+
+	id, err := primitive.ObjectIDFromHex(chi.URLParam(r, "id"))
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "invalid_id", "Invalid group ID")
+		return
+	}
+
+	if err := h.groupDAO.Delete(r.Context(), id); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			utils.WriteError(w, http.StatusNotFound, "not_found", "Group not found")
+			return
+		}
+		h.logger.Error("Failed to delete group", zap.Error(err))
+		utils.WriteError(w, http.StatusInternalServerError, "database_error", "Failed to delete group")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,102 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/grewalsk/task-api/internal/middleware"
+	"github.com/grewalsk/task-api/internal/services"
+	"github.com/grewalsk/task-api/internal/utils"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+)
+
+const (
+	defaultMetricsWindow = 30 * 24 * time.Hour
+	maxMetricsWindow     = 366 * 24 * time.Hour
+)
+
+type MetricsHandler struct {
+	metricsService *services.MetricsService
+	logger         *zap.Logger
+}
+
+func NewMetricsHandler(metricsService *services.MetricsService, logger *zap.Logger) *MetricsHandler {
+	return &MetricsHandler{
+		metricsService: metricsService,
+		logger:         logger,
+	}
+}
+
+// CycleTime reports lead and cycle time percentiles of tasks completed
+// between from and to, which default to the last 30 days.
+func (h *MetricsHandler) CycleTime(w http.ResponseWriter, r *http.Request) {
+	user, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		utils.WriteError(w, http.StatusUnauthorized, "unauthorized", "User not found in context")
+		return
+	}
+
+	q := r.URL.Query()
+	query := services.CycleTimeQuery{
+		To:      time.Now().UTC(),
+		GroupBy: services.GroupByOwner,
+	}
+
+	if groupBy := q.Get("group_by"); groupBy != "" {
+		query.GroupBy = services.CycleTimeGrouping(groupBy)
+		if !services.ValidGrouping(query.GroupBy) {
+			utils.WriteError(w, http.StatusBadRequest, "invalid_request", "group_by must be owner, assignee or project")
+			return
+		}
+	}
+
+	if toStr := q.Get("to"); toStr != "" {
+		to, err := time.Parse(time.RFC3339, toStr)
+		if err != nil {
+			utils.WriteError(w, http.StatusBadRequest, "invalid_request", "to must be an RFC 3339 timestamp")
+			return
+		}
+		query.To = to
+	}
+	query.From = query.To.Add(-defaultMetricsWindow)
+	if fromStr := q.Get("from"); fromStr != "" {
+		from, err := time.Parse(time.RFC3339, fromStr)
+		if err != nil {
+			utils.WriteError(w, http.StatusBadRequest, "invalid_request", "from must be an RFC 3339 timestamp")
+			return
+		}
+		query.From = from
+	}
+	if !query.From.Before(query.To) || query.To.Sub(query.From) > maxMetricsWindow {
+		utils.WriteError(w, http.StatusBadRequest, "invalid_request", "from must be before to and at most 366 days earlier")
+		return
+	}
+
+	if projectStr := q.Get("project_id"); projectStr != "" {
+		projectID, err := primitive.ObjectIDFromHex(projectStr)
+		if err != nil {
+			utils.WriteError(w, http.StatusBadRequest, "invalid_id", "Invalid project ID")
+			return
+		}
+		query.ProjectID = &projectID
+	}
+
+	if ownerStr := q.Get("owner_id"); ownerStr != "" {
+		ownerID, err := primitive.ObjectIDFromHex(ownerStr)
+		if err != nil {
+			utils.WriteError(w, http.StatusBadRequest, "invalid_id", "Invalid owner ID")
+			return
+		}
+		query.OwnerID = &ownerID
+	}
+
+	report, err := h.metricsService.CycleTime(r.Context(), user, query)
+	if err != nil {
+		h.logger.Error("Failed to compute cycle time", zap.Error(err))
+		utils.WriteError(w, http.StatusInternalServerError, "database_error", "Failed to compute cycle time")
+		return
+	}
+
+	utils.WriteSuccess(w, report)
+}
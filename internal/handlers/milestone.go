@@ -0,0 +1,225 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/grewalsk/task-api/internal/middleware"
+	"github.com/grewalsk/task-api/internal/models"
+	"github.com/grewalsk/task-api/internal/services"
+	"github.com/grewalsk/task-api/internal/utils"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.uber.org/zap"
+)
+
+type MilestoneHandler struct {
+	milestoneDAO    *models.MilestoneDAO
+	access          *services.AccessService
+	burndownService *services.BurndownService
+	logger          *zap.Logger
+}
+
+func NewMilestoneHandler(milestoneDAO *models.MilestoneDAO, access *services.AccessService, burndownService *services.BurndownService, logger *zap.Logger) *MilestoneHandler {
+	return &MilestoneHandler{
+		milestoneDAO:    milestoneDAO,
+		access:          access,
+		burndownService: burndownService,
+		logger:          logger,
+	}
+}
+
+type milestoneUpdateRequest struct {
+	Name        *string    `json:"name" validate:"omitempty,min=1,max=100"`
+	Description *string    `json:"description" validate:"omitempty,max=1000"`
+	DueDate     *time.Time `json:"due_date"`
+}
+
+func (h *MilestoneHandler) loadMilestone(w http.ResponseWriter, r *http.Request, project *models.Project) (*models.Milestone, bool) {
+	id, err := primitive.ObjectIDFromHex(chi.URLParam(r, "milestoneID"))
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "invalid_id", "Invalid milestone ID")
+		return nil, false
+	}
+
+	milestone, err := h.milestoneDAO.GetByID(r.Context(), project.ID, id)
+	if err != nil {
+		utils.WriteError(w, http.StatusNotFound, "not_found", "Milestone not found")
+		return nil, false
+	}
+
+	return milestone, true
+}
+
+func (h *MilestoneHandler) Create(w http.ResponseWriter, r *http.Request) {
+	project, ok := loadManagedProject(w, r, h.access)
+	if !ok {
+		return
+	}
+	user, _ := middleware.GetUserFromContext(r.Context())
+
+	var milestone models.Milestone
+	if err := json.NewDecoder(r.Body).Decode(&milestone); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "invalid_request", "Invalid JSON")
+		return
+	}
+
+	if err := utils.ValidateStruct(milestone); err != nil {
+		utils.WriteValidationError(w, err)
+		return
+	}
+
+	if project.Archived {
+		utils.WriteError(w, http.StatusConflict, "project_archived", "Cannot add milestones to an archived project")
+		return
+	}
+
+	milestone.ProjectID = project.ID
+	milestone.CreatedBy = user.UserID
+
+	if err := h.milestoneDAO.Create(r.Context(), &milestone); err != nil {
+		h.logger.Error("Failed to create milestone", zap.Error(err))
+		utils.WriteError(w, http.StatusInternalServerError, "database_error", "Failed to create milestone")
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusCreated, milestone)
+}
+
+func (h *MilestoneHandler) List(w http.ResponseWriter, r *http.Request) {
+	project, ok := loadURLProject(w, r, h.access)
+	if !ok {
+		return
+	}
+
+	milestones, err := h.milestoneDAO.List(r.Context(), project.ID)
+	if err != nil {
+		h.logger.Error("Failed to list milestones", zap.Error(err))
+		utils.WriteError(w, http.StatusInternalServerError, "database_error", "Failed to list milestones")
+		return
+	}
+
+	utils.WriteSuccess(w, milestones)
+}
+
+func (h *MilestoneHandler) GetByID(w http.ResponseWriter, r *http.Request) {
+	project, ok := loadURLProject(w, r, h.access)
+	if !ok {
+		return
+	}
+
+	milestone, ok := h.loadMilestone(w, r, project)
+	if !ok {
+		return
+	}
+
+	utils.WriteSuccess(w, milestone)
+}
+
+func (h *MilestoneHandler) Update(w http.ResponseWriter, r *http.Request) {
+	project, ok := loadManagedProject(w, r, h.access)
+	if !ok {
+		return
+	}
+
+	milestone, ok := h.loadMilestone(w, r, project)
+	if !ok {
+		return
+	}
+
+	var req milestoneUpdateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "invalid_request", "Invalid JSON")
+		return
+	}
+
+	if err := utils.ValidateStruct(req); err != nil {
+		utils.WriteValidationError(w, err)
+		return
+	}
+
+	updateDoc := bson.M{}
+	if req.Name != nil {
+		updateDoc["name"] = *req.Name
+	}
+	if req.Description != nil {
+		updateDoc["description"] = *req.Description
+	}
+	if req.DueDate != nil {
+		updateDoc["due_date"] = *req.DueDate
+	}
+
+	if len(updateDoc) == 0 {
+		utils.WriteError(w, http.StatusBadRequest, "no_updates", "No valid fields to update")
+		return
+	}
+
+	if err := h.milestoneDAO.Update(r.Context(), milestone.ID, updateDoc); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			utils.WriteError(w, http.StatusNotFound, "not_found", "Milestone not found")
+			return
+		}
+		h.logger.Error("Failed to update milestone", zap.Error(err))
+		utils.WriteError(w, http.StatusInternalServerError, "database_error", "Failed to update milestone")
+		return
+	}
+
+	updated, _ := h.milestoneDAO.GetByID(r.Context(), project.ID, milestone.ID)
+	utils.WriteSuccess(w, updated)
+}
+
+func (h *MilestoneHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	project, ok := loadManagedProject(w, r, h.access)
+	if !ok {
+		return
+	}
+
+	milestone, ok := h.loadMilestone(w, r, project)
+	if !ok {
+		return
+	}
+
+	if err := h.milestoneDAO.Delete(r.Context(), milestone.ID); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			utils.WriteError(w, http.StatusNotFound, "not_found", "Milestone not found")
+			return
+		}
+		h.logger.Error("Failed to delete milestone", zap.Error(err))
+		utils.WriteError(w, http.StatusInternalServerError, "database_error", "Failed to delete milestone")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Burndown returns the daily burndown and burnup series of the milestone,
+// from its creation to its due date.
+func (h *MilestoneHandler) Burndown(w http.ResponseWriter, r *http.Request) {
+	project, ok := loadURLProject(w, r, h.access)
+	if !ok {
+		return
+	}
+
+	milestone, ok := h.loadMilestone(w, r, project)
+	if !ok {
+		return
+	}
+
+	loc, ok := parseTimezone(w, r)
+	if !ok {
+		return
+	}
+
+	burndown, err := h.burndownService.Milestone(r.Context(), milestone, loc)
+	if err != nil {
+		h.logger.Error("Failed to compute burndown", zap.Error(err))
+		utils.WriteError(w, http.StatusInternalServerError, "database_error", "Failed to compute burndown")
+		return
+	}
+
+	utils.WriteSuccess(w, burndown)
+}
@@ -0,0 +1,316 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/grewalsk/task-api/internal/models"
+	"github.com/grewalsk/task-api/internal/openapi"
+	"github.com/grewalsk/task-api/internal/patch"
+	"github.com/grewalsk/task-api/internal/services"
+	"github.com/grewalsk/task-api/internal/utils"
+)
+
+// docsPage renders the document with Swagger UI.
+const docsPage = `<!DOCTYPE html>
+<html lang="en">
+<head>
+  <meta charset="utf-8">
+  <title>Task API</title>
+  <link rel="stylesheet" href="https://unpkg.com/swagger-ui-dist@5/swagger-ui.css">
+</head>
+<body>
+  <div id="docs"></div>
+  <script src="https://unpkg.com/swagger-ui-dist@5/swagger-ui-bundle.js"></script>
+  <script>SwaggerUIBundle({ url: "/openapi.json", dom_id: "#docs" });</script>
+</body>
+</html>
+`
+
+type OpenAPIHandler struct {
+	spec *openapi.Spec
+}
+
+func NewOpenAPIHandler(spec *openapi.Spec) *OpenAPIHandler {
+	return &OpenAPIHandler{spec: spec}
+}
+
+func (h *OpenAPIHandler) Spec(w http.ResponseWriter, r *http.Request) {
+	utils.WriteJSON(w, http.StatusOK, h.spec.Document())
+}
+
+func (h *OpenAPIHandler) Docs(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Write([]byte(docsPage))
+}
+
+var (
+	paginationParams = []*openapi.Parameter{
+		openapi.Query("limit", 0, "Maximum number of items, 10 by default"),
+		openapi.Query("offset", 0, "Number of items to skip"),
+	}
+
+	taskListParams = append([]*openapi.Parameter{
+		openapi.Query("cursor", "", "Continues a listing from a Link header URL"),
+		openapi.Query("include_total", false, "Report the number of matching tasks in X-Total-Count"),
+		openapi.Query("status", "", "Comma-separated statuses"),
+		openapi.Query("owner", "", "Owner ID"),
+		openapi.Query("project_id", "", "Project ID"),
+		openapi.Query("sprint_id", "", "Sprint ID"),
+		openapi.Query("milestone_id", "", "Milestone ID"),
+		openapi.Query("search", "", "Text to search for in titles and descriptions"),
+		openapi.Query("include_snoozed", false, "Include snoozed tasks"),
+		openapi.Query("has_description", false, "Only tasks with, or without, a description"),
+		openapi.Query("created_after", "", "Date or RFC 3339 timestamp, inclusive"),
+		openapi.Query("created_before", "", "Date or RFC 3339 timestamp"),
+		openapi.Query("updated_after", "", "Date or RFC 3339 timestamp, inclusive"),
+		openapi.Query("updated_before", "", "Date or RFC 3339 timestamp"),
+		openapi.Query("filter", "", "Filter expression; see the README"),
+		openapi.Query("sort", "", "Up to five comma-separated fields, each prefixed with - for descending order"),
+		openapi.Query("fields", "", "Comma-separated task fields to return"),
+		openapi.Query("expand", "", "Comma-separated relations to embed: owner, assignees"),
+	}, paginationParams...)
+
+	taskReadParams = []*openapi.Parameter{
+		openapi.Query("fields", "", "Comma-separated task fields to return"),
+		openapi.Query("expand", "", "Comma-separated relations to embed: owner, assignees"),
+		openapi.Header("If-None-Match", "ETag of a cached copy"),
+	}
+
+	ifMatch = []*openapi.Parameter{
+		openapi.Header("If-Match", "ETag of the version being changed"),
+	}
+
+	timeRangeParams = []*openapi.Parameter{
+		openapi.Query("user_id", "", "User whose time to report, the caller by default"),
+		openapi.Query("from", "", "First day, YYYY-MM-DD"),
+		openapi.Query("to", "", "Last day, YYYY-MM-DD"),
+		openapi.Query("tz", "", "IANA timezone of the days, UTC by default"),
+	}
+
+	burndownParams = []*openapi.Parameter{
+		openapi.Query("tz", "", "IANA timezone of the days, UTC by default"),
+	}
+)
+
+type attachmentForm struct {
+	File openapi.File `json:"file"`
+}
+
+// Endpoints documents every handler served by the router, keyed as
+// openapi.NewSpec expects.
+var Endpoints = map[string]openapi.Endpoint{
+	"AuthHandler.Login": {
+		Summary:  "Log in",
+		Public:   true,
+		Request:  models.LoginRequest{},
+		Response: models.LoginResponse{},
+	},
+	"ProblemType": {
+		Summary:  "Describe an error code",
+		Public:   true,
+		Params:   []*openapi.Parameter{openapi.Path("code", &openapi.Schema{Type: "string"}, "Error code")},
+		Response: map[string]string{},
+	},
+	"HealthHandler.Check": {
+		Summary:  "Check health",
+		Public:   true,
+		Response: map[string]string{},
+		Bare:     true,
+	},
+	"OpenAPIHandler.Spec": {
+		Summary:  "Get this document",
+		Public:   true,
+		Response: map[string]interface{}{},
+		Bare:     true,
+	},
+	"OpenAPIHandler.Docs": {
+		Summary:      "Browse this document",
+		Public:       true,
+		Response:     "",
+		ResponseType: "text/html",
+		Bare:         true,
+	},
+
+	"WorkspaceHandler.Current": {Summary: "Get the current workspace", Response: models.Workspace{}},
+	"WorkspaceHandler.Create": {
+		Summary:  "Provision a workspace",
+		Request:  provisionWorkspaceRequest{},
+		Status:   http.StatusCreated,
+		Response: provisionWorkspaceResponse{},
+	},
+
+	"UserHandler.Create":  {Summary: "Create a user", Request: createUserRequest{}, Status: http.StatusCreated, Response: models.User{}},
+	"UserHandler.List":    {Summary: "List users", Params: paginationParams, Response: []*models.User{}},
+	"UserHandler.GetByID": {Summary: "Get a user", Response: models.User{}},
+
+	"GroupHandler.Create":  {Summary: "Create a group", Request: models.Group{}, Status: http.StatusCreated, Response: models.Group{}},
+	"GroupHandler.List":    {Summary: "List groups", Params: paginationParams, Response: []*models.Group{}},
+	"GroupHandler.GetByID": {Summary: "Get a group", Response: models.Group{}},
+	"GroupHandler.Update":  {Summary: "Update a group", Request: groupUpdateRequest{}, Response: models.Group{}},
+	"GroupHandler.Delete":  {Summary: "Delete a group"},
+
+	"ViewHandler.Create":  {Summary: "Save a view", Request: models.SavedView{}, Status: http.StatusCreated, Response: models.SavedView{}},
+	"ViewHandler.List":    {Summary: "List views", Response: []*models.SavedView{}},
+	"ViewHandler.Default": {Summary: "Get the default view", Response: models.SavedView{}},
+	"ViewHandler.GetByID": {Summary: "Get a view", Response: models.SavedView{}},
+	"ViewHandler.Update":  {Summary: "Update a view", Request: viewUpdateRequest{}, Response: models.SavedView{}},
+	"ViewHandler.Delete":  {Summary: "Delete a view"},
+	"ViewHandler.Tasks":   {Summary: "List the tasks of a view", Params: taskListParams, Response: []*models.Task{}},
+
+	"CustomFieldHandler.Create": {
+		Summary:  "Define a custom field",
+		Request:  models.CustomFieldDefinition{},
+		Status:   http.StatusCreated,
+		Response: models.CustomFieldDefinition{},
+	},
+	"CustomFieldHandler.List": {
+		Summary:  "List custom fields",
+		Params:   []*openapi.Parameter{openapi.Query("project_id", "", "Include the fields of a project")},
+		Response: []*models.CustomFieldDefinition{},
+	},
+	"CustomFieldHandler.GetByID": {Summary: "Get a custom field", Response: models.CustomFieldDefinition{}},
+	"CustomFieldHandler.Update":  {Summary: "Update a custom field", Request: customFieldUpdateRequest{}, Response: models.CustomFieldDefinition{}},
+	"CustomFieldHandler.Delete":  {Summary: "Delete a custom field"},
+
+	"ProjectHandler.Create": {Summary: "Create a project", Request: models.Project{}, Status: http.StatusCreated, Response: models.Project{}},
+	"ProjectHandler.List": {
+		Summary:  "List projects",
+		Params:   append([]*openapi.Parameter{openapi.Query("archived", false, "Include archived projects")}, paginationParams...),
+		Response: []*models.Project{},
+	},
+	"ProjectHandler.GetByID":      {Summary: "Get a project", Response: models.Project{}},
+	"ProjectHandler.Update":       {Summary: "Update a project", Request: projectUpdateRequest{}, Response: models.Project{}},
+	"ProjectHandler.Delete":       {Summary: "Delete an empty project"},
+	"ProjectHandler.PutMember":    {Summary: "Add or update a project member", Request: models.ProjectMember{}, Response: models.Project{}},
+	"ProjectHandler.RemoveMember": {Summary: "Remove a project member"},
+
+	"SprintHandler.Create": {Summary: "Create a sprint", Request: models.Sprint{}, Status: http.StatusCreated, Response: models.Sprint{}},
+	"SprintHandler.List": {
+		Summary:  "List sprints",
+		Params:   []*openapi.Parameter{openapi.Query("state", "", "planned, active or closed")},
+		Response: []*models.Sprint{},
+	},
+	"SprintHandler.GetByID":  {Summary: "Get a sprint", Response: models.Sprint{}},
+	"SprintHandler.Update":   {Summary: "Update a sprint", Request: sprintUpdateRequest{}, Response: models.Sprint{}},
+	"SprintHandler.Delete":   {Summary: "Delete a sprint"},
+	"SprintHandler.Start":    {Summary: "Start a sprint", Response: models.Sprint{}},
+	"SprintHandler.Close":    {Summary: "Close a sprint", Request: sprintCloseRequest{}, OptionalBody: true, Response: models.Sprint{}},
+	"SprintHandler.Burndown": {Summary: "Get a sprint burndown", Params: burndownParams, Response: services.Burndown{}},
+
+	"MilestoneHandler.Create":   {Summary: "Create a milestone", Request: models.Milestone{}, Status: http.StatusCreated, Response: models.Milestone{}},
+	"MilestoneHandler.List":     {Summary: "List milestones", Response: []*models.Milestone{}},
+	"MilestoneHandler.GetByID":  {Summary: "Get a milestone", Response: models.Milestone{}},
+	"MilestoneHandler.Update":   {Summary: "Update a milestone", Request: milestoneUpdateRequest{}, Response: models.Milestone{}},
+	"MilestoneHandler.Delete":   {Summary: "Delete a milestone"},
+	"MilestoneHandler.Burndown": {Summary: "Get a milestone burnup", Params: burndownParams, Response: services.Burndown{}},
+
+	"TemplateHandler.Create": {Summary: "Create a template", Request: models.Template{}, Status: http.StatusCreated, Response: models.Template{}},
+	"TemplateHandler.List":   {Summary: "List templates", Params: paginationParams, Response: []*models.Template{}},
+	"TemplateHandler.GetByID": {
+		Summary:  "Get a template",
+		Params:   []*openapi.Parameter{openapi.Query("version", 0, "Version to get, the latest by default")},
+		Response: models.Template{},
+	},
+	"TemplateHandler.Update":   {Summary: "Publish a new template version", Request: models.Template{}, Response: models.Template{}},
+	"TemplateHandler.Delete":   {Summary: "Delete a template"},
+	"TemplateHandler.Versions": {Summary: "List template versions", Response: []*models.Template{}},
+	"TemplateHandler.Instantiate": {
+		Summary:      "Create a task from a template",
+		Request:      instantiateRequest{},
+		OptionalBody: true,
+		Status:       http.StatusCreated,
+		Response:     taskTreeResponse{},
+	},
+
+	"TaskHandler.Create": {Summary: "Create a task", Request: taskCreateRequest{}, Status: http.StatusCreated, Response: models.Task{}},
+	"TaskHandler.List":   {Summary: "List tasks", Params: taskListParams, Response: []*models.Task{}},
+	"TaskHandler.GetByID": {
+		Summary:  "Get a task",
+		Params:   taskReadParams,
+		Response: models.Task{},
+	},
+	"TaskHandler.Update": {
+		Summary: "Patch a task",
+		Params:  ifMatch,
+		Requests: map[string]interface{}{
+			"application/json":             map[string]interface{}{},
+			"application/merge-patch+json": map[string]interface{}{},
+			"application/json-patch+json":  []patch.Operation{},
+		},
+		Response: models.Task{},
+	},
+	"TaskHandler.Delete":   {Summary: "Move a task to the trash", Params: ifMatch},
+	"TaskHandler.Restore":  {Summary: "Restore a task from the trash", Response: models.Task{}},
+	"TaskHandler.Trash":    {Summary: "List deleted tasks", Params: paginationParams, Response: []*models.Task{}},
+	"TaskHandler.Purge":    {Summary: "Delete a task permanently"},
+	"TaskHandler.Snooze":   {Summary: "Snooze a task", Request: snoozeRequest{}, Response: models.Task{}},
+	"TaskHandler.Unsnooze": {Summary: "Wake a snoozed task", Response: models.Task{}},
+
+	"BulkHandler.Run": {Summary: "Change many tasks at once", Request: services.BulkRequest{}, Response: services.BulkResult{}},
+	"CloneHandler.Clone": {
+		Summary:      "Clone a task",
+		Request:      services.CloneOptions{},
+		OptionalBody: true,
+		Status:       http.StatusCreated,
+		Response:     taskTreeResponse{},
+	},
+	"ActivityHandler.List": {Summary: "List task activity", Params: paginationParams, Response: []*models.Activity{}},
+
+	"ShareHandler.List":  {Summary: "List task shares", Response: []models.TaskShare{}},
+	"ShareHandler.Grant": {Summary: "Share a task", Request: models.TaskShare{}, Response: []models.TaskShare{}},
+	"ShareHandler.Revoke": {
+		Summary: "Stop sharing a task",
+		Params:  []*openapi.Parameter{openapi.Path("principalType", openapi.Enum("user", "group"), "Kind of principal")},
+	},
+
+	"WorklogHandler.StartTimer":   {Summary: "Start a timer on a task", Status: http.StatusCreated, Response: models.Worklog{}},
+	"WorklogHandler.StopTimer":    {Summary: "Stop the timer on a task", Response: models.Worklog{}},
+	"WorklogHandler.CurrentTimer": {Summary: "Get the running timer", Response: models.Worklog{}},
+	"WorklogHandler.Create":       {Summary: "Log time on a task", Request: worklogRequest{}, Status: http.StatusCreated, Response: models.Worklog{}},
+	"WorklogHandler.List":         {Summary: "List task worklogs", Params: paginationParams, Response: []*models.Worklog{}},
+	"WorklogHandler.Delete":       {Summary: "Delete a worklog"},
+	"WorklogHandler.TaskTotals":   {Summary: "Total the time logged on a task", Response: taskTotalsResponse{}},
+	"WorklogHandler.UserTotals":   {Summary: "Total a user's time by task", Params: timeRangeParams, Response: userTotalsResponse{}},
+	"WorklogHandler.Timesheet":    {Summary: "Get a user's timesheet", Params: timeRangeParams, Response: timesheetResponse{}},
+
+	"MetricsHandler.CycleTime": {
+		Summary: "Report lead and cycle times",
+		Params: []*openapi.Parameter{
+			openapi.Query("from", "", "Start of the period"),
+			openapi.Query("to", "", "End of the period"),
+			openapi.Query("group_by", "", "owner, assignee or project"),
+			openapi.Query("project_id", "", "Project ID"),
+			openapi.Query("owner_id", "", "Owner ID"),
+		},
+		Response: services.CycleTimeReport{},
+	},
+
+	"CommentHandler.Create":  {Summary: "Comment on a task", Request: commentRequest{}, Status: http.StatusCreated, Response: models.Comment{}},
+	"CommentHandler.List":    {Summary: "List task comments", Params: paginationParams, Response: []*models.Comment{}},
+	"CommentHandler.GetByID": {Summary: "Get a comment", Response: models.Comment{}},
+	"CommentHandler.Update":  {Summary: "Edit a comment", Request: commentRequest{}, Response: models.Comment{}},
+	"CommentHandler.Delete":  {Summary: "Delete a comment"},
+
+	"AttachmentHandler.Upload": {
+		Summary: "Attach a file to a task",
+		Params: []*openapi.Parameter{
+			openapi.Query("filename", "", "Name of a raw upload"),
+			openapi.Header("X-Checksum-Sha256", "Hex SHA-256 the content must match"),
+		},
+		Requests: map[string]interface{}{
+			"multipart/form-data":      attachmentForm{},
+			"application/octet-stream": openapi.File{},
+		},
+		Status:   http.StatusCreated,
+		Response: models.Attachment{},
+	},
+	"AttachmentHandler.List":    {Summary: "List task attachments", Response: []*models.Attachment{}},
+	"AttachmentHandler.GetByID": {Summary: "Get attachment metadata", Response: models.Attachment{}},
+	"AttachmentHandler.Download": {
+		Summary:      "Download an attachment",
+		Response:     openapi.File{},
+		ResponseType: "application/octet-stream",
+		Bare:         true,
+	},
+	"AttachmentHandler.Delete": {Summary: "Delete an attachment"},
+}
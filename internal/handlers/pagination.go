@@ -0,0 +1,26 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+)
+
+// parsePagination reads limit and offset query parameters, ignoring values
+// that are malformed or out of range.
+func parsePagination(r *http.Request, defaultLimit, maxLimit int64) (limit, offset int64) {
+	limit = defaultLimit
+
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		if l, err := strconv.ParseInt(limitStr, 10, 64); err == nil && l > 0 && l <= maxLimit {
+			limit = l
+		}
+	}
+
+	if offsetStr := r.URL.Query().Get("offset"); offsetStr != "" {
+		if o, err := strconv.ParseInt(offsetStr, 10, 64); err == nil && o >= 0 {
+			offset = o
+		}
+	}
+
+	return limit, offset
+}
@@ -0,0 +1,262 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"mime"
+	"net/http"
+	"reflect"
+	"sort"
+
+	"github.com/grewalsk/task-api/internal/models"
+	"github.com/grewalsk/task-api/internal/patch"
+	"github.com/grewalsk/task-api/internal/utils"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+)
+
+const (
+	mergePatchType = "application/merge-patch+json"
+	jsonPatchType  = "application/json-patch+json"
+)
+
+// patchableTaskFields are the task fields, by JSON name, that PATCH may
+// change.
+var patchableTaskFields = map[string]bool{
+	"title":                      true,
+	"description":                true,
+	"status":                     true,
+	"original_estimate_minutes":  true,
+	"remaining_estimate_minutes": true,
+	"custom_fields":              true,
+	"sprint_id":                  true,
+	"milestone_id":               true,
+}
+
+// patchTask applies the request body to the JSON form of task, as a JSON
+// Patch or a JSON Merge Patch depending on its content type, and validates
+// the result with the rules used on creation. It returns the $set document
+// for the fields the patch changed and the fields it removed, writing the
+// error response if the patch or its result is invalid.
+func (h *TaskHandler) patchTask(w http.ResponseWriter, r *http.Request, task *models.Task) (bson.M, []string, bool) {
+	mediaType := "application/json"
+	if contentType := r.Header.Get("Content-Type"); contentType != "" {
+		parsed, _, err := mime.ParseMediaType(contentType)
+		if err != nil {
+			utils.WriteError(w, http.StatusUnsupportedMediaType, "unsupported_media_type", "Invalid Content-Type")
+			return nil, nil, false
+		}
+		mediaType = parsed
+	}
+
+	original, err := taskDocument(task)
+	if err != nil {
+		h.logger.Error("Failed to encode task", zap.Error(err))
+		utils.WriteError(w, http.StatusInternalServerError, "internal_error", "Failed to update task")
+		return nil, nil, false
+	}
+	doc, _ := taskDocument(task)
+
+	var patched interface{}
+	switch mediaType {
+	case jsonPatchType:
+		var ops []patch.Operation
+		if err := json.NewDecoder(r.Body).Decode(&ops); err != nil {
+			utils.WriteError(w, http.StatusBadRequest, "invalid_request", "Invalid JSON Patch")
+			return nil, nil, false
+		}
+		patched, err = patch.Apply(doc, ops)
+		if errors.Is(err, patch.ErrTestFailed) {
+			utils.WriteError(w, http.StatusConflict, "patch_test_failed", err.Error())
+			return nil, nil, false
+		}
+		if err != nil {
+			utils.WriteError(w, http.StatusBadRequest, "invalid_patch", err.Error())
+			return nil, nil, false
+		}
+
+	case mergePatchType, "application/json":
+		var p interface{}
+		if err := json.NewDecoder(r.Body).Decode(&p); err != nil {
+			utils.WriteError(w, http.StatusBadRequest, "invalid_request", "Invalid JSON")
+			return nil, nil, false
+		}
+		if _, ok := p.(map[string]interface{}); !ok {
+			utils.WriteError(w, http.StatusBadRequest, "invalid_patch", "Merge patch must be a JSON object")
+			return nil, nil, false
+		}
+		patched = patch.Merge(doc, p)
+
+	default:
+		utils.WriteError(w, http.StatusUnsupportedMediaType, "unsupported_media_type", "PATCH accepts application/json, "+mergePatchType+" or "+jsonPatchType)
+		return nil, nil, false
+	}
+
+	after, ok := patched.(map[string]interface{})
+	if !ok {
+		utils.WriteError(w, http.StatusBadRequest, "validation_error", "task must be a JSON object")
+		return nil, nil, false
+	}
+
+	keys := make(map[string]bool, len(original)+len(after))
+	for key := range original {
+		keys[key] = true
+	}
+	for key := range after {
+		keys[key] = true
+	}
+	var changed []string
+	for key := range keys {
+		if !reflect.DeepEqual(original[key], after[key]) {
+			changed = append(changed, key)
+		}
+	}
+	sort.Strings(changed)
+	for _, key := range changed {
+		if !patchableTaskFields[key] {
+			utils.WriteError(w, http.StatusBadRequest, "validation_error", key+" cannot be updated")
+			return nil, nil, false
+		}
+	}
+
+	body, err := json.Marshal(after)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "validation_error", "Invalid task")
+		return nil, nil, false
+	}
+	var result models.Task
+	if err := json.Unmarshal(body, &result); err != nil {
+		var typeErr *json.UnmarshalTypeError
+		if errors.As(err, &typeErr) {
+			utils.WriteError(w, http.StatusBadRequest, "validation_error", typeErr.Field+" must be "+jsonTypeName(typeErr.Type))
+			return nil, nil, false
+		}
+		utils.WriteError(w, http.StatusBadRequest, "validation_error", "Invalid task: "+err.Error())
+		return nil, nil, false
+	}
+
+	if err := utils.ValidateStruct(result); err != nil {
+		utils.WriteValidationError(w, err)
+		return nil, nil, false
+	}
+
+	values := map[string]interface{}{
+		"title":                      result.Title,
+		"description":                result.Description,
+		"status":                     result.Status,
+		"original_estimate_minutes":  result.OriginalEstimateMinutes,
+		"remaining_estimate_minutes": result.RemainingEstimateMinutes,
+	}
+	if result.SprintID != nil {
+		values["sprint_id"] = result.SprintID
+	}
+	if result.MilestoneID != nil {
+		values["milestone_id"] = result.MilestoneID
+	}
+	set := bson.M{}
+	var unset []string
+	for _, key := range changed {
+		if key == "custom_fields" {
+			fields, ok := h.patchCustomFields(w, r, task, original[key], after[key])
+			if !ok {
+				return nil, nil, false
+			}
+			set[key] = fields
+			continue
+		}
+		// Members a merge patch sets to null are removed, not stored as null.
+		value, ok := values[key]
+		if !ok {
+			unset = append(unset, key)
+			continue
+		}
+		set[key] = value
+	}
+
+	for _, key := range changed {
+		if key != "sprint_id" && key != "milestone_id" {
+			continue
+		}
+		planned := *task
+		planned.SprintID = result.SprintID
+		planned.MilestoneID = result.MilestoneID
+		if !h.checkPlanning(w, r, &planned) {
+			return nil, nil, false
+		}
+		break
+	}
+
+	return set, unset, true
+}
+
+// patchCustomFields checks the custom field values that differ between
+// before and after against their definitions and merges them over the
+// task's stored values.
+func (h *TaskHandler) patchCustomFields(w http.ResponseWriter, r *http.Request, task *models.Task, before, after interface{}) (map[string]interface{}, bool) {
+	oldValues, _ := before.(map[string]interface{})
+	newValues, _ := after.(map[string]interface{})
+
+	changes := map[string]interface{}{}
+	for key, value := range newValues {
+		if !reflect.DeepEqual(oldValues[key], value) {
+			changes[key] = value
+		}
+	}
+	for key := range oldValues {
+		if _, ok := newValues[key]; !ok {
+			changes[key] = nil
+		}
+	}
+
+	defs, err := h.customFieldDAO.ListApplicable(r.Context(), task.ProjectID)
+	if err != nil {
+		h.logger.Error("Failed to load custom fields", zap.Error(err))
+		utils.WriteError(w, http.StatusInternalServerError, "database_error", "Failed to update task")
+		return nil, false
+	}
+
+	merged, err := models.NormalizeCustomFields(defs, changes, task.CustomFields)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "validation_error", err.Error())
+		return nil, false
+	}
+	return merged, true
+}
+
+// taskDocument returns the JSON form of task as decoded JSON values.
+func taskDocument(task *models.Task) (map[string]interface{}, error) {
+	body, err := json.Marshal(task)
+	if err != nil {
+		return nil, err
+	}
+	var doc map[string]interface{}
+	if err := json.Unmarshal(body, &doc); err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
+
+// jsonTypeName describes the JSON type a Go type is decoded from.
+func jsonTypeName(t reflect.Type) string {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t == reflect.TypeOf(primitive.ObjectID{}) {
+		return "an ID"
+	}
+	switch t.Kind() {
+	case reflect.String:
+		return "a string"
+	case reflect.Bool:
+		return "a boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "an integer"
+	case reflect.Float32, reflect.Float64:
+		return "a number"
+	case reflect.Slice, reflect.Array:
+		return "an array"
+	}
+	return "an object"
+}
@@ -0,0 +1,25 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/grewalsk/task-api/internal/utils"
+)
+
+// ProblemType describes an error code, so that the type URI of every
+// problem response resolves.
+func ProblemType(w http.ResponseWriter, r *http.Request) {
+	code := chi.URLParam(r, "code")
+	title, ok := utils.ErrorTitle(code)
+	if !ok {
+		utils.WriteError(w, http.StatusNotFound, "not_found", "Unknown error code")
+		return
+	}
+
+	utils.WriteSuccess(w, map[string]string{
+		"type":  utils.ProblemTypeBase + code,
+		"code":  code,
+		"title": title,
+	})
+}
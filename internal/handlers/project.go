@@ -0,0 +1,347 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/grewalsk/task-api/internal/middleware"
+	"github.com/grewalsk/task-api/internal/models"
+	"github.com/grewalsk/task-api/internal/services"
+	"github.com/grewalsk/task-api/internal/utils"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.uber.org/zap"
+)
+
+type ProjectHandler struct {
+	projectDAO *models.ProjectDAO
+	userDAO    *models.UserDAO
+	access     *services.AccessService
+	logger     *zap.Logger
+}
+
+func NewProjectHandler(projectDAO *models.ProjectDAO, userDAO *models.UserDAO, access *services.AccessService, logger *zap.Logger) *ProjectHandler {
+	return &ProjectHandler{
+		projectDAO: projectDAO,
+		userDAO:    userDAO,
+		access:     access,
+		logger:     logger,
+	}
+}
+
+type projectUpdateRequest struct {
+	Name        *string `json:"name" validate:"omitempty,min=1,max=100"`
+	Description *string `json:"description" validate:"omitempty,max=1000"`
+	Archived    *bool   `json:"archived"`
+}
+
+// urlProjectID reads the {projectID} URL parameter of project-scoped task
+// routes. It returns nil on routes without one.
+func urlProjectID(w http.ResponseWriter, r *http.Request) (*primitive.ObjectID, bool) {
+	idStr := chi.URLParam(r, "projectID")
+	if idStr == "" {
+		return nil, true
+	}
+
+	id, err := primitive.ObjectIDFromHex(idStr)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "invalid_id", "Invalid project ID")
+		return nil, false
+	}
+
+	return &id, true
+}
+
+// loadProject resolves a project the current user is a member of, writing the
+// error response on failure. Non-members get 404 so project IDs do not leak.
+func loadProject(w http.ResponseWriter, r *http.Request, access *services.AccessService, id primitive.ObjectID) (*models.Project, bool) {
+	user, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		utils.WriteError(w, http.StatusUnauthorized, "unauthorized", "User not found in context")
+		return nil, false
+	}
+
+	project, err := access.Project(r.Context(), user, id)
+	if err != nil {
+		utils.WriteError(w, http.StatusNotFound, "not_found", "Project not found")
+		return nil, false
+	}
+
+	return project, true
+}
+
+func loadProjectForNewTask(w http.ResponseWriter, r *http.Request, access *services.AccessService, id primitive.ObjectID) (*models.Project, bool) {
+	user, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		utils.WriteError(w, http.StatusUnauthorized, "unauthorized", "User not found in context")
+		return nil, false
+	}
+
+	project, err := access.ProjectForNewTask(r.Context(), user, id)
+	switch {
+	case errors.Is(err, services.ErrProjectArchived):
+		utils.WriteError(w, http.StatusConflict, "project_archived", "Cannot add tasks to an archived project")
+		return nil, false
+	case err != nil:
+		utils.WriteError(w, http.StatusNotFound, "not_found", "Project not found")
+		return nil, false
+	}
+
+	return project, true
+}
+
+// loadManagedProject resolves the {projectID} URL parameter and requires the
+// user to be a project owner or an admin.
+func loadManagedProject(w http.ResponseWriter, r *http.Request, access *services.AccessService) (*models.Project, bool) {
+	project, ok := loadURLProject(w, r, access)
+	if !ok {
+		return nil, false
+	}
+
+	user, _ := middleware.GetUserFromContext(r.Context())
+	if !access.CanManageProject(user, project) {
+		utils.WriteError(w, http.StatusForbidden, "forbidden", "Only project owners can manage the project")
+		return nil, false
+	}
+
+	return project, true
+}
+
+func loadURLProject(w http.ResponseWriter, r *http.Request, access *services.AccessService) (*models.Project, bool) {
+	id, ok := urlProjectID(w, r)
+	if !ok {
+		return nil, false
+	}
+
+	return loadProject(w, r, access, *id)
+}
+
+func (h *ProjectHandler) Create(w http.ResponseWriter, r *http.Request) {
+	user, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		utils.WriteError(w, http.StatusUnauthorized, "unauthorized", "User not found in context")
+		return
+	}
+
+	var project models.Project
+	if err := json.NewDecoder(r.Body).Decode(&project); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "invalid_request", "Invalid JSON")
+		return
+	}
+
+	project.Key = strings.ToUpper(project.Key)
+	if err := utils.ValidateStruct(project); err != nil {
+		utils.WriteValidationError(w, err)
+		return
+	}
+
+	if !models.ValidProjectKey(project.Key) {
+		utils.WriteError(w, http.StatusBadRequest, "validation_error", "key must start with a letter and contain only letters and digits")
+		return
+	}
+
+	project.CreatedBy = user.UserID
+	project.Members = []models.ProjectMember{{UserID: user.UserID, Role: models.ProjectRoleOwner}}
+
+	if err := h.projectDAO.Create(r.Context(), &project); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			utils.WriteError(w, http.StatusConflict, "conflict", "A project with this key already exists")
+			return
+		}
+		h.logger.Error("Failed to create project", zap.Error(err))
+		utils.WriteError(w, http.StatusInternalServerError, "database_error", "Failed to create project")
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusCreated, project)
+}
+
+// List returns the projects the user belongs to, or every project for
+// admins. Archived projects are included with ?archived=true.
+func (h *ProjectHandler) List(w http.ResponseWriter, r *http.Request) {
+	user, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		utils.WriteError(w, http.StatusUnauthorized, "unauthorized", "User not found in context")
+		return
+	}
+
+	limit, offset := parsePagination(r, 50, 100)
+	includeArchived := r.URL.Query().Get("archived") == "true"
+
+	var memberID *primitive.ObjectID
+	if user.Role != string(models.RoleAdmin) {
+		memberID = &user.UserID
+	}
+
+	projects, err := h.projectDAO.List(r.Context(), memberID, includeArchived, limit, offset)
+	if err != nil {
+		h.logger.Error("Failed to list projects", zap.Error(err))
+		utils.WriteError(w, http.StatusInternalServerError, "database_error", "Failed to list projects")
+		return
+	}
+
+	utils.WriteSuccess(w, projects)
+}
+
+func (h *ProjectHandler) GetByID(w http.ResponseWriter, r *http.Request) {
+	project, ok := loadURLProject(w, r, h.access)
+	if !ok {
+		return
+	}
+
+	utils.WriteSuccess(w, project)
+}
+
+func (h *ProjectHandler) Update(w http.ResponseWriter, r *http.Request) {
+	project, ok := loadManagedProject(w, r, h.access)
+	if !ok {
+		return
+	}
+
+	var req projectUpdateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "invalid_request", "Invalid JSON")
+		return
+	}
+
+	if err := utils.ValidateStruct(req); err != nil {
+		utils.WriteValidationError(w, err)
+		return
+	}
+
+	updateDoc := bson.M{}
+	if req.Name != nil {
+		updateDoc["name"] = *req.Name
+	}
+	if req.Description != nil {
+		updateDoc["description"] = *req.Description
+	}
+
+	if len(updateDoc) == 0 && req.Archived == nil {
+		utils.WriteError(w, http.StatusBadRequest, "no_updates", "No valid fields to update")
+		return
+	}
+
+	if len(updateDoc) > 0 {
+		if err := h.projectDAO.Update(r.Context(), project.ID, updateDoc); err != nil {
+			h.writeUpdateError(w, err)
+			return
+		}
+	}
+
+	if req.Archived != nil && *req.Archived != project.Archived {
+		if err := h.projectDAO.SetArchived(r.Context(), project.ID, *req.Archived); err != nil {
+			h.writeUpdateError(w, err)
+			return
+		}
+	}
+
+	updated, _ := h.projectDAO.GetByID(r.Context(), project.ID)
+	utils.WriteSuccess(w, updated)
+}
+
+func (h *ProjectHandler) writeUpdateError(w http.ResponseWriter, err error) {
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		utils.WriteError(w, http.StatusNotFound, "not_found", "Project not found")
+		return
+	}
+	h.logger.Error("Failed to update project", zap.Error(err))
+	utils.WriteError(w, http.StatusInternalServerError, "database_error", "Failed to update project")
+}
+
+// Delete removes an empty project. Projects that still hold tasks must be
+// archived instead.
+func (h *ProjectHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	project, ok := loadManagedProject(w, r, h.access)
+	if !ok {
+		return
+	}
+
+	deleted, err := h.projectDAO.Delete(r.Context(), project.ID)
+	if err != nil {
+		h.logger.Error("Failed to delete project", zap.Error(err))
+		utils.WriteError(w, http.StatusInternalServerError, "database_error", "Failed to delete project")
+		return
+	}
+	if !deleted {
+		utils.WriteError(w, http.StatusConflict, "project_not_empty", "Project still contains tasks; archive it instead")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// PutMember adds a user to the project or changes their role.
+func (h *ProjectHandler) PutMember(w http.ResponseWriter, r *http.Request) {
+	project, ok := loadManagedProject(w, r, h.access)
+	if !ok {
+		return
+	}
+
+	var member models.ProjectMember
+	if err := json.NewDecoder(r.Body).Decode(&member); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "invalid_request", "Invalid JSON")
+		return
+	}
+
+	if member.Role == "" {
+		member.Role = models.ProjectRoleMember
+	}
+
+	if err := utils.ValidateStruct(member); err != nil {
+		utils.WriteValidationError(w, err)
+		return
+	}
+
+	if _, err := h.userDAO.GetByID(r.Context(), member.UserID); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "validation_error", "user_id does not refer to a user in this workspace")
+		return
+	}
+
+	if member.Role != models.ProjectRoleOwner && project.LastOwner(member.UserID) {
+		utils.WriteError(w, http.StatusConflict, "last_owner", "A project needs at least one owner")
+		return
+	}
+
+	if err := h.projectDAO.PutMember(r.Context(), project.ID, member); err != nil {
+		h.writeUpdateError(w, err)
+		return
+	}
+
+	updated, _ := h.projectDAO.GetByID(r.Context(), project.ID)
+	utils.WriteSuccess(w, updated)
+}
+
+func (h *ProjectHandler) RemoveMember(w http.ResponseWriter, r *http.Request) {
+	project, ok := loadManagedProject(w, r, h.access)
+	if !ok {
+		return
+	}
+
+	userID, err := primitive.ObjectIDFromHex(chi.URLParam(r, "userID"))
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "invalid_id", "Invalid user ID")
+		return
+	}
+
+	if project.LastOwner(userID) {
+		utils.WriteError(w, http.StatusConflict, "last_owner", "A project needs at least one owner")
+		return
+	}
+
+	if err := h.projectDAO.RemoveMember(r.Context(), project.ID, userID); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			utils.WriteError(w, http.StatusNotFound, "not_found", "Member not found")
+			return
+		}
+		h.logger.Error("Failed to remove project member", zap.Error(err))
+		utils.WriteError(w, http.StatusInternalServerError, "database_error", "Failed to remove project member")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
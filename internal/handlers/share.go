@@ -0,0 +1,137 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/grewalsk/task-api/internal/models"
+	"github.com/grewalsk/task-api/internal/services"
+	"github.com/grewalsk/task-api/internal/utils"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.uber.org/zap"
+)
+
+type ShareHandler struct {
+	taskDAO  *models.TaskDAO
+	userDAO  *models.UserDAO
+	groupDAO *models.GroupDAO
+	access   *services.AccessService
+	logger   *zap.Logger
+}
+
+func NewShareHandler(taskDAO *models.TaskDAO, userDAO *models.UserDAO, groupDAO *models.GroupDAO, access *services.AccessService, logger *zap.Logger) *ShareHandler {
+	return &ShareHandler{
+		taskDAO:  taskDAO,
+		userDAO:  userDAO,
+		groupDAO: groupDAO,
+		access:   access,
+		logger:   logger,
+	}
+}
+
+func (h *ShareHandler) List(w http.ResponseWriter, r *http.Request) {
+	_, task, ok := loadTask(w, r, h.access, services.PermissionRead)
+	if !ok {
+		return
+	}
+
+	shares := task.Shares
+	if shares == nil {
+		shares = []models.TaskShare{}
+	}
+
+	utils.WriteSuccess(w, shares)
+}
+
+// Grant shares the task with a user or group, replacing any existing grant
+// for the same principal.
+func (h *ShareHandler) Grant(w http.ResponseWriter, r *http.Request) {
+	user, task, ok := loadTask(w, r, h.access, services.PermissionManage)
+	if !ok {
+		return
+	}
+
+	var share models.TaskShare
+	if err := json.NewDecoder(r.Body).Decode(&share); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "invalid_request", "Invalid JSON")
+		return
+	}
+
+	if err := utils.ValidateStruct(share); err != nil {
+		utils.WriteValidationError(w, err)
+		return
+	}
+
+	var err error
+	if share.PrincipalType == models.SharePrincipalGroup {
+		_, err = h.groupDAO.GetByID(r.Context(), share.PrincipalID)
+	} else {
+		_, err = h.userDAO.GetByID(r.Context(), share.PrincipalID)
+	}
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "validation_error", "principal_id does not refer to a "+string(share.PrincipalType)+" in this workspace")
+		return
+	}
+
+	share.GrantedBy = user.UserID
+	share.GrantedAt = time.Now()
+
+	shares := models.WithShare(task.Shares, share)
+	if h.saveShares(w, r, task, shares, user.UserID) {
+		utils.WriteSuccess(w, shares)
+	}
+}
+
+func (h *ShareHandler) Revoke(w http.ResponseWriter, r *http.Request) {
+	user, task, ok := loadTask(w, r, h.access, services.PermissionManage)
+	if !ok {
+		return
+	}
+
+	principalType := models.SharePrincipal(chi.URLParam(r, "principalType"))
+	if principalType != models.SharePrincipalUser && principalType != models.SharePrincipalGroup {
+		utils.WriteError(w, http.StatusBadRequest, "invalid_request", "principal type must be user or group")
+		return
+	}
+
+	principalID, err := primitive.ObjectIDFromHex(chi.URLParam(r, "principalID"))
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "invalid_id", "Invalid principal ID")
+		return
+	}
+
+	shares := models.WithoutShare(task.Shares, principalType, principalID)
+	if len(shares) == len(task.Shares) {
+		utils.WriteError(w, http.StatusNotFound, "not_found", "Share not found")
+		return
+	}
+
+	if h.saveShares(w, r, task, shares, user.UserID) {
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func (h *ShareHandler) saveShares(w http.ResponseWriter, r *http.Request, task *models.Task, shares []models.TaskShare, actorID primitive.ObjectID) bool {
+	// The new list was derived from the loaded shares, so it must not
+	// overwrite a concurrent change.
+	if err := h.taskDAO.Update(r.Context(), task.ID, task.Version, bson.M{"shares": shares}, nil, actorID); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			utils.WriteError(w, http.StatusNotFound, "not_found", "Task not found")
+			return false
+		}
+		if errors.Is(err, models.ErrVersionMismatch) {
+			utils.WriteError(w, http.StatusConflict, "conflict", "Task was modified concurrently; retry the request")
+			return false
+		}
+		h.logger.Error("Failed to update task shares", zap.Error(err))
+		utils.WriteError(w, http.StatusInternalServerError, "database_error", "Failed to update task shares")
+		return false
+	}
+
+	return true
+}
@@ -0,0 +1,351 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/grewalsk/task-api/internal/middleware"
+	"github.com/grewalsk/task-api/internal/models"
+	"github.com/grewalsk/task-api/internal/services"
+	"github.com/grewalsk/task-api/internal/utils"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.uber.org/zap"
+)
+
+type SprintHandler struct {
+	sprintDAO       *models.SprintDAO
+	access          *services.AccessService
+	burndownService *services.BurndownService
+	logger          *zap.Logger
+}
+
+func NewSprintHandler(sprintDAO *models.SprintDAO, access *services.AccessService, burndownService *services.BurndownService, logger *zap.Logger) *SprintHandler {
+	return &SprintHandler{
+		sprintDAO:       sprintDAO,
+		access:          access,
+		burndownService: burndownService,
+		logger:          logger,
+	}
+}
+
+type sprintUpdateRequest struct {
+	Name      *string    `json:"name" validate:"omitempty,min=1,max=100"`
+	Goal      *string    `json:"goal" validate:"omitempty,max=1000"`
+	StartDate *time.Time `json:"start_date"`
+	EndDate   *time.Time `json:"end_date"`
+}
+
+type sprintCloseRequest struct {
+	NextSprintID *primitive.ObjectID `json:"next_sprint_id"`
+}
+
+func (h *SprintHandler) loadSprint(w http.ResponseWriter, r *http.Request, project *models.Project) (*models.Sprint, bool) {
+	id, err := primitive.ObjectIDFromHex(chi.URLParam(r, "sprintID"))
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "invalid_id", "Invalid sprint ID")
+		return nil, false
+	}
+
+	sprint, err := h.sprintDAO.GetByID(r.Context(), project.ID, id)
+	if err != nil {
+		utils.WriteError(w, http.StatusNotFound, "not_found", "Sprint not found")
+		return nil, false
+	}
+
+	return sprint, true
+}
+
+func (h *SprintHandler) Create(w http.ResponseWriter, r *http.Request) {
+	project, ok := loadManagedProject(w, r, h.access)
+	if !ok {
+		return
+	}
+	user, _ := middleware.GetUserFromContext(r.Context())
+
+	var sprint models.Sprint
+	if err := json.NewDecoder(r.Body).Decode(&sprint); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "invalid_request", "Invalid JSON")
+		return
+	}
+
+	if err := utils.ValidateStruct(sprint); err != nil {
+		utils.WriteValidationError(w, err)
+		return
+	}
+
+	if project.Archived {
+		utils.WriteError(w, http.StatusConflict, "project_archived", "Cannot add sprints to an archived project")
+		return
+	}
+
+	sprint.ProjectID = project.ID
+	sprint.CreatedBy = user.UserID
+
+	if err := h.sprintDAO.Create(r.Context(), &sprint); err != nil {
+		h.logger.Error("Failed to create sprint", zap.Error(err))
+		utils.WriteError(w, http.StatusInternalServerError, "database_error", "Failed to create sprint")
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusCreated, sprint)
+}
+
+// List returns the project's sprints, optionally filtered with ?state=.
+func (h *SprintHandler) List(w http.ResponseWriter, r *http.Request) {
+	project, ok := loadURLProject(w, r, h.access)
+	if !ok {
+		return
+	}
+
+	state := models.SprintState(r.URL.Query().Get("state"))
+	switch state {
+	case "", models.SprintPlanned, models.SprintActive, models.SprintClosed:
+	default:
+		utils.WriteError(w, http.StatusBadRequest, "invalid_filter", "state must be planned, active or closed")
+		return
+	}
+
+	sprints, err := h.sprintDAO.List(r.Context(), project.ID, state)
+	if err != nil {
+		h.logger.Error("Failed to list sprints", zap.Error(err))
+		utils.WriteError(w, http.StatusInternalServerError, "database_error", "Failed to list sprints")
+		return
+	}
+
+	utils.WriteSuccess(w, sprints)
+}
+
+func (h *SprintHandler) GetByID(w http.ResponseWriter, r *http.Request) {
+	project, ok := loadURLProject(w, r, h.access)
+	if !ok {
+		return
+	}
+
+	sprint, ok := h.loadSprint(w, r, project)
+	if !ok {
+		return
+	}
+
+	utils.WriteSuccess(w, sprint)
+}
+
+func (h *SprintHandler) Update(w http.ResponseWriter, r *http.Request) {
+	project, ok := loadManagedProject(w, r, h.access)
+	if !ok {
+		return
+	}
+
+	sprint, ok := h.loadSprint(w, r, project)
+	if !ok {
+		return
+	}
+
+	var req sprintUpdateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "invalid_request", "Invalid JSON")
+		return
+	}
+
+	if err := utils.ValidateStruct(req); err != nil {
+		utils.WriteValidationError(w, err)
+		return
+	}
+
+	updateDoc := bson.M{}
+	if req.Name != nil {
+		updateDoc["name"] = *req.Name
+	}
+	if req.Goal != nil {
+		updateDoc["goal"] = *req.Goal
+	}
+	start, end := sprint.StartDate, sprint.EndDate
+	if req.StartDate != nil {
+		start = *req.StartDate
+		updateDoc["start_date"] = start
+	}
+	if req.EndDate != nil {
+		end = *req.EndDate
+		updateDoc["end_date"] = end
+	}
+
+	if len(updateDoc) == 0 {
+		utils.WriteError(w, http.StatusBadRequest, "no_updates", "No valid fields to update")
+		return
+	}
+
+	if !end.After(start) {
+		utils.WriteError(w, http.StatusBadRequest, "validation_error", "end_date must be after start_date")
+		return
+	}
+
+	if err := h.sprintDAO.Update(r.Context(), sprint.ID, updateDoc); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			utils.WriteError(w, http.StatusConflict, "sprint_closed", "Closed sprints cannot be changed")
+			return
+		}
+		h.logger.Error("Failed to update sprint", zap.Error(err))
+		utils.WriteError(w, http.StatusInternalServerError, "database_error", "Failed to update sprint")
+		return
+	}
+
+	updated, _ := h.sprintDAO.GetByID(r.Context(), project.ID, sprint.ID)
+	utils.WriteSuccess(w, updated)
+}
+
+// Delete removes a sprint that is not active. Its tasks return to the
+// project backlog.
+func (h *SprintHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	project, ok := loadManagedProject(w, r, h.access)
+	if !ok {
+		return
+	}
+
+	sprint, ok := h.loadSprint(w, r, project)
+	if !ok {
+		return
+	}
+
+	if err := h.sprintDAO.Delete(r.Context(), sprint.ID); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			utils.WriteError(w, http.StatusConflict, "sprint_active", "Close the sprint before deleting it")
+			return
+		}
+		h.logger.Error("Failed to delete sprint", zap.Error(err))
+		utils.WriteError(w, http.StatusInternalServerError, "database_error", "Failed to delete sprint")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *SprintHandler) Start(w http.ResponseWriter, r *http.Request) {
+	project, ok := loadManagedProject(w, r, h.access)
+	if !ok {
+		return
+	}
+
+	sprint, ok := h.loadSprint(w, r, project)
+	if !ok {
+		return
+	}
+
+	if err := h.sprintDAO.Start(r.Context(), sprint.ID); err != nil {
+		switch {
+		case mongo.IsDuplicateKeyError(err):
+			utils.WriteError(w, http.StatusConflict, "sprint_active", "The project already has an active sprint")
+		case errors.Is(err, mongo.ErrNoDocuments):
+			utils.WriteError(w, http.StatusConflict, "invalid_state", "Only planned sprints can be started")
+		default:
+			h.logger.Error("Failed to start sprint", zap.Error(err))
+			utils.WriteError(w, http.StatusInternalServerError, "database_error", "Failed to start sprint")
+		}
+		return
+	}
+
+	updated, _ := h.sprintDAO.GetByID(r.Context(), project.ID, sprint.ID)
+	utils.WriteSuccess(w, updated)
+}
+
+// Close closes the active sprint and moves its unfinished tasks to
+// next_sprint_id, or by default to the next planned sprint. Without a next
+// sprint they return to the project backlog.
+func (h *SprintHandler) Close(w http.ResponseWriter, r *http.Request) {
+	project, ok := loadManagedProject(w, r, h.access)
+	if !ok {
+		return
+	}
+	user, _ := middleware.GetUserFromContext(r.Context())
+
+	sprint, ok := h.loadSprint(w, r, project)
+	if !ok {
+		return
+	}
+
+	var req sprintCloseRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		utils.WriteError(w, http.StatusBadRequest, "invalid_request", "Invalid JSON")
+		return
+	}
+
+	if sprint.State != models.SprintActive {
+		utils.WriteError(w, http.StatusConflict, "invalid_state", "Only active sprints can be closed")
+		return
+	}
+
+	var next *primitive.ObjectID
+	if req.NextSprintID != nil {
+		target, err := h.sprintDAO.GetByID(r.Context(), project.ID, *req.NextSprintID)
+		if err != nil || target.State != models.SprintPlanned {
+			utils.WriteError(w, http.StatusBadRequest, "validation_error", "next_sprint_id must refer to a planned sprint in this project")
+			return
+		}
+		next = &target.ID
+	} else {
+		target, err := h.sprintDAO.NextPlanned(r.Context(), project.ID, sprint.StartDate)
+		switch {
+		case err == nil:
+			next = &target.ID
+		case !errors.Is(err, mongo.ErrNoDocuments):
+			h.logger.Error("Failed to find next sprint", zap.Error(err))
+			utils.WriteError(w, http.StatusInternalServerError, "database_error", "Failed to close sprint")
+			return
+		}
+	}
+
+	if _, err := h.sprintDAO.Close(r.Context(), sprint, next, user.UserID); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			utils.WriteError(w, http.StatusConflict, "invalid_state", "Only active sprints can be closed")
+			return
+		}
+		h.logger.Error("Failed to close sprint", zap.Error(err))
+		utils.WriteError(w, http.StatusInternalServerError, "database_error", "Failed to close sprint")
+		return
+	}
+
+	updated, _ := h.sprintDAO.GetByID(r.Context(), project.ID, sprint.ID)
+	utils.WriteSuccess(w, updated)
+}
+
+// Burndown returns the daily burndown and burnup series of the sprint. Days
+// are computed in the timezone given by ?tz=, UTC by default.
+func (h *SprintHandler) Burndown(w http.ResponseWriter, r *http.Request) {
+	project, ok := loadURLProject(w, r, h.access)
+	if !ok {
+		return
+	}
+
+	sprint, ok := h.loadSprint(w, r, project)
+	if !ok {
+		return
+	}
+
+	loc, ok := parseTimezone(w, r)
+	if !ok {
+		return
+	}
+
+	burndown, err := h.burndownService.Sprint(r.Context(), sprint, loc)
+	if err != nil {
+		h.logger.Error("Failed to compute burndown", zap.Error(err))
+		utils.WriteError(w, http.StatusInternalServerError, "database_error", "Failed to compute burndown")
+		return
+	}
+
+	utils.WriteSuccess(w, burndown)
+}
+
+func parseTimezone(w http.ResponseWriter, r *http.Request) (*time.Location, bool) {
+	tz := r.URL.Query().Get("tz")
+	loc, err := time.LoadLocation(tz)
+	if err != nil || tz == "Local" {
+		utils.WriteError(w, http.StatusBadRequest, "invalid_request", "Invalid timezone")
+		return nil, false
+	}
+	return loc, true
+}
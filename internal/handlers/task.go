@@ -1,31 +1,91 @@
 package handlers
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/grewalsk/task-api/internal/middleware"
 	"github.com/grewalsk/task-api/internal/models"
+	"github.com/grewalsk/task-api/internal/query"
+	"github.com/grewalsk/task-api/internal/services"
 	"github.com/grewalsk/task-api/internal/utils"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
 	"go.uber.org/zap"
 )
 
 type TaskHandler struct {
-	taskDAO *models.TaskDAO
-	logger  *zap.Logger
+	taskDAO           *models.TaskDAO
+	userDAO           *models.UserDAO
+	access            *services.AccessService
+	customFieldDAO    *models.CustomFieldDAO
+	sprintDAO         *models.SprintDAO
+	milestoneDAO      *models.MilestoneDAO
+	recurrenceService *services.RecurrenceService
+	purgeService      *services.PurgeService
+	requireIfMatch    bool
+	cursors           *utils.CursorSigner
+	logger            *zap.Logger
 }
 
-func NewTaskHandler(taskDAO *models.TaskDAO, logger *zap.Logger) *TaskHandler {
+func NewTaskHandler(taskDAO *models.TaskDAO, userDAO *models.UserDAO, access *services.AccessService, customFieldDAO *models.CustomFieldDAO, sprintDAO *models.SprintDAO, milestoneDAO *models.MilestoneDAO, recurrenceService *services.RecurrenceService, purgeService *services.PurgeService, requireIfMatch bool, cursors *utils.CursorSigner, logger *zap.Logger) *TaskHandler {
 	return &TaskHandler{
-		taskDAO: taskDAO,
-		logger:  logger,
+		taskDAO:           taskDAO,
+		userDAO:           userDAO,
+		access:            access,
+		customFieldDAO:    customFieldDAO,
+		sprintDAO:         sprintDAO,
+		milestoneDAO:      milestoneDAO,
+		recurrenceService: recurrenceService,
+		purgeService:      purgeService,
+		requireIfMatch:    requireIfMatch,
+		cursors:           cursors,
+		logger:            logger,
 	}
 }
 
+const (
+	defaultTaskPageSize = 10
+	maxTaskPageSize     = 100
+)
+
+// taskCursor is the signed payload of a task listing cursor. The sort it was
+// issued for is recorded so that it cannot be replayed against another one.
+type taskCursor struct {
+	Sort   string             `bson:"s"`
+	Values []interface{}      `bson:"v"`
+	ID     primitive.ObjectID `bson:"i"`
+	Before bool               `bson:"b,omitempty"`
+}
+
+// taskCreateRequest holds the task fields a client may set on creation. The
+// rest are maintained by the server or by dedicated endpoints.
+type taskCreateRequest struct {
+	Title                    string                 `json:"title" validate:"required,min=1,max=200"`
+	Description              string                 `json:"description" validate:"max=1000"`
+	Status                   models.TaskStatus      `json:"status" validate:"omitempty,oneof=open in_progress done" openapi:"default=open"`
+	ProjectID                *primitive.ObjectID    `json:"project_id"`
+	ParentID                 *primitive.ObjectID    `json:"parent_id"`
+	SprintID                 *primitive.ObjectID    `json:"sprint_id"`
+	MilestoneID              *primitive.ObjectID    `json:"milestone_id"`
+	AssigneeIDs              []primitive.ObjectID   `json:"assignee_ids" validate:"max=20"`
+	Labels                   []string               `json:"labels" validate:"max=20,dive,min=1,max=50"`
+	Checklist                []models.ChecklistItem `json:"checklist" validate:"max=100,dive"`
+	Recurrence               *models.Recurrence     `json:"recurrence"`
+	OriginalEstimateMinutes  *int64                 `json:"original_estimate_minutes" validate:"omitempty,min=0"`
+	RemainingEstimateMinutes *int64                 `json:"remaining_estimate_minutes" validate:"omitempty,min=0"`
+	CustomFields             map[string]interface{} `json:"custom_fields"`
+}
+
 func (h *TaskHandler) Create(w http.ResponseWriter, r *http.Request) {
 	user, ok := middleware.GetUserFromContext(r.Context())
 	if !ok {
@@ -33,156 +93,349 @@ func (h *TaskHandler) Create(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	var task models.Task
-	if err := json.NewDecoder(r.Body).Decode(&task); err != nil {
+	var req taskCreateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		utils.WriteError(w, http.StatusBadRequest, "invalid_request", "Invalid JSON")
 		return
 	}
 
-	task.OwnerID = user.UserID
+	task := models.Task{
+		Title:                    req.Title,
+		Description:              req.Description,
+		Status:                   req.Status,
+		OwnerID:                  user.UserID,
+		ProjectID:                req.ProjectID,
+		ParentID:                 req.ParentID,
+		SprintID:                 req.SprintID,
+		MilestoneID:              req.MilestoneID,
+		AssigneeIDs:              req.AssigneeIDs,
+		Labels:                   req.Labels,
+		Checklist:                req.Checklist,
+		Recurrence:               req.Recurrence,
+		OriginalEstimateMinutes:  req.OriginalEstimateMinutes,
+		RemainingEstimateMinutes: req.RemainingEstimateMinutes,
+		CustomFields:             req.CustomFields,
+	}
 	if task.Status == "" {
 		task.Status = models.StatusOpen
 	}
 
-	if err := utils.ValidateStruct(task); err != nil {
-		utils.WriteError(w, http.StatusBadRequest, "validation_error", utils.FormatValidationError(err))
+	projectID, ok := urlProjectID(w, r)
+	if !ok {
 		return
 	}
+	if projectID != nil {
+		task.ProjectID = projectID
+	}
 
-	if err := h.taskDAO.Create(r.Context(), &task); err != nil {
-		h.logger.Error("Failed to create task", zap.Error(err))
-		utils.WriteError(w, http.StatusInternalServerError, "database_error", "Failed to create task")
+	if err := utils.ValidateStruct(task); err != nil {
+		utils.WriteValidationError(w, err)
 		return
 	}
 
-	utils.WriteJSON(w, http.StatusCreated, task)
-}
+	if task.ParentID != nil {
+		_, err := h.access.Task(r.Context(), user, *task.ParentID, services.PermissionRead, "_id")
+		switch {
+		case errors.Is(err, services.ErrForbidden), errors.Is(err, mongo.ErrNoDocuments):
+			utils.WriteError(w, http.StatusBadRequest, "validation_error", "parent_id must refer to a task you can read")
+			return
+		case err != nil:
+			h.logger.Error("Failed to load parent task", zap.Error(err))
+			utils.WriteError(w, http.StatusInternalServerError, "database_error", "Failed to create task")
+			return
+		}
+	}
 
-func (h *TaskHandler) GetByID(w http.ResponseWriter, r *http.Request) {
-	idStr := chi.URLParam(r, "id")
-	id, err := primitive.ObjectIDFromHex(idStr)
+	if len(task.AssigneeIDs) > 0 {
+		task.AssigneeIDs = uniqueObjectIDs(task.AssigneeIDs)
+		count, err := h.userDAO.CountByIDs(r.Context(), task.AssigneeIDs)
+		if err != nil {
+			h.logger.Error("Failed to check assignees", zap.Error(err))
+			utils.WriteError(w, http.StatusInternalServerError, "database_error", "Failed to create task")
+			return
+		}
+		if count != int64(len(task.AssigneeIDs)) {
+			utils.WriteError(w, http.StatusBadRequest, "validation_error", services.ErrInvalidAssignees.Error())
+			return
+		}
+	}
+
+	if task.ProjectID != nil {
+		if _, ok := loadProjectForNewTask(w, r, h.access, *task.ProjectID); !ok {
+			return
+		}
+	}
+
+	if !h.checkPlanning(w, r, &task) {
+		return
+	}
+
+	defs, err := h.customFieldDAO.ListApplicable(r.Context(), task.ProjectID)
 	if err != nil {
-		utils.WriteError(w, http.StatusBadRequest, "invalid_id", "Invalid task ID")
+		h.logger.Error("Failed to load custom fields", zap.Error(err))
+		utils.WriteError(w, http.StatusInternalServerError, "database_error", "Failed to create task")
 		return
 	}
 
-	task, err := h.taskDAO.GetByID(r.Context(), id)
+	task.CustomFields, err = models.NormalizeCustomFields(defs, task.CustomFields, nil)
 	if err != nil {
-		utils.WriteError(w, http.StatusNotFound, "not_found", "Task not found")
+		utils.WriteError(w, http.StatusBadRequest, "validation_error", err.Error())
 		return
 	}
 
-	utils.WriteSuccess(w, task)
+	if task.Recurrence != nil {
+		if err := h.recurrenceService.Prepare(&task); err != nil {
+			utils.WriteError(w, http.StatusBadRequest, "validation_error", err.Error())
+			return
+		}
+	}
+
+	if err := h.taskDAO.Create(r.Context(), &task, user.UserID); err != nil {
+		h.logger.Error("Failed to create task", zap.Error(err))
+		utils.WriteError(w, http.StatusInternalServerError, "database_error", "Failed to create task")
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusCreated, task)
 }
 
-func (h *TaskHandler) Update(w http.ResponseWriter, r *http.Request) {
-	idStr := chi.URLParam(r, "id")
-	id, err := primitive.ObjectIDFromHex(idStr)
+func (h *TaskHandler) GetByID(w http.ResponseWriter, r *http.Request) {
+	view, err := parseTaskView(r.URL.Query())
 	if err != nil {
-		utils.WriteError(w, http.StatusBadRequest, "invalid_id", "Invalid task ID")
+		utils.WriteError(w, http.StatusBadRequest, "invalid_request", err.Error())
 		return
 	}
 
-	user, ok := middleware.GetUserFromContext(r.Context())
+	_, task, ok := loadTask(w, r, h.access, services.PermissionRead, view.paths("version")...)
 	if !ok {
-		utils.WriteError(w, http.StatusUnauthorized, "unauthorized", "User not found in context")
 		return
 	}
 
-	task, err := h.taskDAO.GetByID(r.Context(), id)
-	if err != nil {
-		utils.WriteError(w, http.StatusNotFound, "not_found", "Task not found")
+	etag := viewETag(task, view)
+	w.Header().Set("ETag", etag)
+	if matchesETag(r.Header.Get("If-None-Match"), etag) {
+		w.WriteHeader(http.StatusNotModified)
 		return
 	}
 
-	if task.OwnerID != user.UserID && user.Role != string(models.RoleAdmin) {
-		utils.WriteError(w, http.StatusForbidden, "forbidden", "Cannot update task owned by another user")
+	if view.full() {
+		utils.WriteSuccess(w, task)
 		return
 	}
 
-	var updates map[string]interface{}
-	if err := json.NewDecoder(r.Body).Decode(&updates); err != nil {
-		utils.WriteError(w, http.StatusBadRequest, "invalid_request", "Invalid JSON")
+	rendered, err := h.renderTasks(r.Context(), view, []*models.Task{task})
+	if err != nil {
+		h.logger.Error("Failed to render task", zap.Error(err))
+		utils.WriteError(w, http.StatusInternalServerError, "database_error", "Failed to get task")
 		return
 	}
+	utils.WriteSuccess(w, rendered[0])
+}
 
-	allowedFields := map[string]bool{
-		"title":       true,
-		"description": true,
-		"status":      true,
+// Update applies a JSON Merge Patch, or a JSON Patch when sent as
+// application/json-patch+json, to a task. Only the fields that change are
+// written.
+func (h *TaskHandler) Update(w http.ResponseWriter, r *http.Request) {
+	user, task, ok := loadTask(w, r, h.access, services.PermissionEdit)
+	if !ok {
+		return
 	}
+	id := task.ID
 
-	updateDoc := bson.M{}
-	for key, value := range updates {
-		if allowedFields[key] {
-			updateDoc[key] = value
-		}
+	version, ok := h.checkIfMatch(w, r, task)
+	if !ok {
+		return
 	}
 
-	if len(updateDoc) == 0 {
-		utils.WriteError(w, http.StatusBadRequest, "no_updates", "No valid fields to update")
+	updateDoc, unset, ok := h.patchTask(w, r, task)
+	if !ok {
+		return
+	}
+	if len(updateDoc) == 0 && len(unset) == 0 {
+		w.Header().Set("ETag", taskETag(task))
+		utils.WriteSuccess(w, task)
 		return
 	}
 
-	if err := h.taskDAO.Update(r.Context(), id, updateDoc); err != nil {
+	if err := h.taskDAO.Update(r.Context(), id, version, updateDoc, unset, user.UserID); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			utils.WriteError(w, http.StatusNotFound, "not_found", "Task not found")
+			return
+		}
+		if errors.Is(err, models.ErrVersionMismatch) {
+			h.preconditionFailed(w, r, id)
+			return
+		}
 		h.logger.Error("Failed to update task", zap.Error(err))
 		utils.WriteError(w, http.StatusInternalServerError, "database_error", "Failed to update task")
 		return
 	}
 
 	updatedTask, _ := h.taskDAO.GetByID(r.Context(), id)
+
+	if task.Status != models.StatusDone && updatedTask != nil && updatedTask.Status == models.StatusDone {
+		if err := h.recurrenceService.SpawnNext(r.Context(), updatedTask); err != nil {
+			h.logger.Error("Failed to generate next recurring task", zap.Error(err))
+		}
+	}
+
+	if updatedTask != nil {
+		w.Header().Set("ETag", taskETag(updatedTask))
+	}
 	utils.WriteSuccess(w, updatedTask)
 }
 
 func (h *TaskHandler) Delete(w http.ResponseWriter, r *http.Request) {
-	idStr := chi.URLParam(r, "id")
-	id, err := primitive.ObjectIDFromHex(idStr)
-	if err != nil {
-		utils.WriteError(w, http.StatusBadRequest, "invalid_id", "Invalid task ID")
+	user, task, ok := loadTask(w, r, h.access, services.PermissionEdit)
+	if !ok {
+		return
+	}
+
+	version, ok := h.checkIfMatch(w, r, task)
+	if !ok {
+		return
+	}
+
+	if err := h.taskDAO.Delete(r.Context(), task.ID, version, user.UserID); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			utils.WriteError(w, http.StatusNotFound, "not_found", "Task not found")
+			return
+		}
+		if errors.Is(err, models.ErrVersionMismatch) {
+			h.preconditionFailed(w, r, task.ID)
+			return
+		}
+		h.logger.Error("Failed to delete task", zap.Error(err))
+		utils.WriteError(w, http.StatusInternalServerError, "database_error", "Failed to delete task")
 		return
 	}
 
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *TaskHandler) List(w http.ResponseWriter, r *http.Request) {
 	user, ok := middleware.GetUserFromContext(r.Context())
 	if !ok {
 		utils.WriteError(w, http.StatusUnauthorized, "unauthorized", "User not found in context")
 		return
 	}
 
-	task, err := h.taskDAO.GetByID(r.Context(), id)
+	filter, ok := h.listFilter(w, r)
+	if !ok {
+		return
+	}
+
+	view, err := parseTaskView(r.URL.Query())
 	if err != nil {
-		utils.WriteError(w, http.StatusNotFound, "not_found", "Task not found")
+		utils.WriteError(w, http.StatusBadRequest, "invalid_request", err.Error())
 		return
 	}
+	// Sort values are needed to build the page links.
+	var sortPaths []string
+	for _, key := range filter.Sort {
+		sortPaths = append(sortPaths, key.Field)
+	}
+	filter.Fields = view.paths(sortPaths...)
 
-	if task.OwnerID != user.UserID && user.Role != string(models.RoleAdmin) {
-		utils.WriteError(w, http.StatusForbidden, "forbidden", "Cannot delete task owned by another user")
+	scope, err := h.access.TaskScope(r.Context(), user)
+	if err != nil {
+		h.logger.Error("Failed to load task scope", zap.Error(err))
+		utils.WriteError(w, http.StatusInternalServerError, "database_error", "Failed to list tasks")
 		return
 	}
+	filter.Scope = scope
+
+	if r.URL.Query().Get("include_total") == "true" {
+		total, err := h.taskDAO.Count(r.Context(), *filter)
+		if err != nil {
+			h.logger.Error("Failed to count tasks", zap.Error(err))
+			utils.WriteError(w, http.StatusInternalServerError, "database_error", "Failed to list tasks")
+			return
+		}
+		w.Header().Set("X-Total-Count", strconv.FormatInt(total, 10))
+	}
 
-	if err := h.taskDAO.Delete(r.Context(), id); err != nil {
-		h.logger.Error("Failed to delete task", zap.Error(err))
-		utils.WriteError(w, http.StatusInternalServerError, "database_error", "Failed to delete task")
+	// One extra task tells whether another page follows in the direction
+	// of travel.
+	limit := filter.Limit
+	filter.Limit++
+	tasks, err := h.taskDAO.List(r.Context(), *filter)
+	if err != nil {
+		h.logger.Error("Failed to list tasks", zap.Error(err))
+		utils.WriteError(w, http.StatusInternalServerError, "database_error", "Failed to list tasks")
 		return
 	}
 
-	w.WriteHeader(http.StatusNoContent)
-}
+	backwards := filter.Cursor != nil && filter.Cursor.Before
+	more := int64(len(tasks)) > limit
+	if more && backwards {
+		tasks = tasks[1:]
+	} else if more {
+		tasks = tasks[:limit]
+	}
 
-func (h *TaskHandler) List(w http.ResponseWriter, r *http.Request) {
-	user, ok := middleware.GetUserFromContext(r.Context())
-	if !ok {
-		utils.WriteError(w, http.StatusUnauthorized, "unauthorized", "User not found in context")
+	if len(tasks) > 0 {
+		var links []string
+		if more || backwards {
+			links = append(links, h.pageLink(r, filter, tasks[len(tasks)-1], false, "next"))
+		}
+		if (more && backwards) || (!backwards && (filter.Cursor != nil || filter.Offset > 0)) {
+			links = append(links, h.pageLink(r, filter, tasks[0], true, "prev"))
+		}
+		if len(links) > 0 {
+			w.Header().Set("Link", strings.Join(links, ", "))
+		}
+	}
+
+	if view.full() {
+		utils.WriteSuccess(w, tasks)
 		return
 	}
 
+	rendered, err := h.renderTasks(r.Context(), view, tasks)
+	if err != nil {
+		h.logger.Error("Failed to render tasks", zap.Error(err))
+		utils.WriteError(w, http.StatusInternalServerError, "database_error", "Failed to list tasks")
+		return
+	}
+	utils.WriteSuccess(w, rendered)
+}
+
+// pageLink formats a Link header entry for the page after, or before, task.
+// The link keeps the request's parameters and replaces its position.
+func (h *TaskHandler) pageLink(r *http.Request, filter *models.TaskFilter, task *models.Task, before bool, rel string) string {
+	values := make([]interface{}, len(filter.Sort))
+	for i, key := range filter.Sort {
+		values[i] = task.SortValue(key.Field)
+	}
+	token, err := h.cursors.Encode(taskCursor{
+		Sort:   formatSort(filter.Sort),
+		Values: values,
+		ID:     task.ID,
+		Before: before,
+	})
+	if err != nil {
+		h.logger.Error("Failed to encode cursor", zap.Error(err))
+		return ""
+	}
+
+	query := r.URL.Query()
+	query.Del("offset")
+	query.Set("cursor", token)
+	return fmt.Sprintf(`<%s?%s>; rel="%s"`, r.URL.Path, query.Encode(), rel)
+}
+
+// listFilter builds the task filter described by the query parameters of a
+// listing request, writing the error response if they are invalid.
+func (h *TaskHandler) listFilter(w http.ResponseWriter, r *http.Request) (*models.TaskFilter, bool) {
 	filter := models.TaskFilter{
-		Limit:  10,
+		Limit:  defaultTaskPageSize,
 		Offset: 0,
 	}
 
 	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
 		if limit, err := strconv.ParseInt(limitStr, 10, 64); err == nil && limit > 0 {
-			filter.Limit = limit
+			filter.Limit = min(limit, maxTaskPageSize)
 		}
 	}
 
@@ -192,10 +445,12 @@ func (h *TaskHandler) List(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	if statusStr := r.URL.Query().Get("status"); statusStr != "" {
-		status := models.TaskStatus(statusStr)
-		filter.Status = &status
+	statuses, err := parseStatuses(r.URL.Query()["status"])
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "invalid_filter", err.Error())
+		return nil, false
 	}
+	filter.Statuses = statuses
 
 	if ownerStr := r.URL.Query().Get("owner"); ownerStr != "" {
 		if ownerID, err := primitive.ObjectIDFromHex(ownerStr); err == nil {
@@ -203,20 +458,500 @@ func (h *TaskHandler) List(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	if projectStr := r.URL.Query().Get("project_id"); projectStr != "" {
+		projectID, err := primitive.ObjectIDFromHex(projectStr)
+		if err != nil {
+			utils.WriteError(w, http.StatusBadRequest, "invalid_id", "Invalid project ID")
+			return nil, false
+		}
+		filter.ProjectID = &projectID
+	}
+
+	if sprintStr := r.URL.Query().Get("sprint_id"); sprintStr != "" {
+		sprintID, err := primitive.ObjectIDFromHex(sprintStr)
+		if err != nil {
+			utils.WriteError(w, http.StatusBadRequest, "invalid_id", "Invalid sprint ID")
+			return nil, false
+		}
+		filter.SprintID = &sprintID
+	}
+
+	if milestoneStr := r.URL.Query().Get("milestone_id"); milestoneStr != "" {
+		milestoneID, err := primitive.ObjectIDFromHex(milestoneStr)
+		if err != nil {
+			utils.WriteError(w, http.StatusBadRequest, "invalid_id", "Invalid milestone ID")
+			return nil, false
+		}
+		filter.MilestoneID = &milestoneID
+	}
+
+	projectID, ok := urlProjectID(w, r)
+	if !ok {
+		return nil, false
+	}
+	if projectID != nil {
+		if _, ok := loadProject(w, r, h.access, *projectID); !ok {
+			return nil, false
+		}
+		filter.ProjectID = projectID
+	}
+
 	if search := r.URL.Query().Get("search"); search != "" {
 		filter.Search = search
 	}
 
-	if user.Role != string(models.RoleAdmin) {
-		filter.OwnerID = &user.UserID
+	filter.HideSnoozed = r.URL.Query().Get("include_snoozed") != "true"
+
+	if hasDescription := r.URL.Query().Get("has_description"); hasDescription != "" {
+		value, err := strconv.ParseBool(hasDescription)
+		if err != nil {
+			utils.WriteError(w, http.StatusBadRequest, "invalid_filter", "has_description must be true or false")
+			return nil, false
+		}
+		filter.HasDescription = &value
+	}
+
+	ranges := []struct {
+		param  string
+		target **time.Time
+	}{
+		{"created_after", &filter.CreatedAfter},
+		{"created_before", &filter.CreatedBefore},
+		{"updated_after", &filter.UpdatedAfter},
+		{"updated_before", &filter.UpdatedBefore},
+	}
+	for _, rng := range ranges {
+		t, err := parseTimeParam(r.URL.Query(), rng.param)
+		if err != nil {
+			utils.WriteError(w, http.StatusBadRequest, "invalid_filter", err.Error())
+			return nil, false
+		}
+		*rng.target = t
 	}
 
-	tasks, err := h.taskDAO.List(r.Context(), filter)
+	if err := h.applyFieldFilters(r, &filter); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "invalid_filter", err.Error())
+		return nil, false
+	}
+	if len(filter.Sort) == 0 {
+		filter.Sort = []models.SortKey{{Field: "created_at", Desc: true}}
+	}
+
+	if token := r.URL.Query().Get("cursor"); token != "" {
+		if filter.Offset > 0 {
+			utils.WriteError(w, http.StatusBadRequest, "invalid_request", "cursor and offset cannot be combined")
+			return nil, false
+		}
+
+		var c taskCursor
+		if err := h.cursors.Decode(token, &c); err != nil {
+			utils.WriteError(w, http.StatusBadRequest, "invalid_cursor", "Invalid cursor")
+			return nil, false
+		}
+		if c.Sort != formatSort(filter.Sort) || len(c.Values) != len(filter.Sort) {
+			utils.WriteError(w, http.StatusBadRequest, "invalid_cursor", "Cursor was issued for a different sort order")
+			return nil, false
+		}
+		filter.Cursor = &models.TaskCursor{Values: c.Values, ID: c.ID, Before: c.Before}
+	}
+
+	return &filter, true
+}
+
+func (h *TaskHandler) Trash(w http.ResponseWriter, r *http.Request) {
+	user, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		utils.WriteError(w, http.StatusUnauthorized, "unauthorized", "User not found in context")
+		return
+	}
+
+	limit, offset := parsePagination(r, 10, 100)
+	filter := models.TaskFilter{
+		Limit:  limit,
+		Offset: offset,
+	}
+
+	scope, err := h.access.TaskScope(r.Context(), user)
 	if err != nil {
-		h.logger.Error("Failed to list tasks", zap.Error(err))
-		utils.WriteError(w, http.StatusInternalServerError, "database_error", "Failed to list tasks")
+		h.logger.Error("Failed to load task scope", zap.Error(err))
+		utils.WriteError(w, http.StatusInternalServerError, "database_error", "Failed to list deleted tasks")
+		return
+	}
+	filter.Scope = scope
+
+	tasks, err := h.taskDAO.ListDeleted(r.Context(), filter)
+	if err != nil {
+		h.logger.Error("Failed to list deleted tasks", zap.Error(err))
+		utils.WriteError(w, http.StatusInternalServerError, "database_error", "Failed to list deleted tasks")
 		return
 	}
 
 	utils.WriteSuccess(w, tasks)
-}
\ No newline at end of file
+}
+
+func (h *TaskHandler) Restore(w http.ResponseWriter, r *http.Request) {
+	idStr := chi.URLParam(r, "id")
+	id, err := primitive.ObjectIDFromHex(idStr)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "invalid_id", "Invalid task ID")
+		return
+	}
+
+	user, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		utils.WriteError(w, http.StatusUnauthorized, "unauthorized", "User not found in context")
+		return
+	}
+
+	task, err := h.taskDAO.GetDeletedByID(r.Context(), id)
+	if err != nil {
+		utils.WriteError(w, http.StatusNotFound, "not_found", "Deleted task not found")
+		return
+	}
+
+	allowed, err := h.access.CanAccessTask(r.Context(), user, task, services.PermissionEdit)
+	if err != nil {
+		h.logger.Error("Failed to check task access", zap.Error(err))
+		utils.WriteError(w, http.StatusInternalServerError, "database_error", "Failed to restore task")
+		return
+	}
+	if !allowed {
+		utils.WriteError(w, http.StatusForbidden, "forbidden", "Cannot restore task owned by another user")
+		return
+	}
+
+	if err := h.taskDAO.Restore(r.Context(), id, user.UserID); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			utils.WriteError(w, http.StatusNotFound, "not_found", "Deleted task not found")
+			return
+		}
+		h.logger.Error("Failed to restore task", zap.Error(err))
+		utils.WriteError(w, http.StatusInternalServerError, "database_error", "Failed to restore task")
+		return
+	}
+
+	restoredTask, _ := h.taskDAO.GetByID(r.Context(), id)
+	utils.WriteSuccess(w, restoredTask)
+}
+
+type snoozeRequest struct {
+	Until time.Time `json:"until" validate:"required"`
+}
+
+// Snooze hides a task from default listings until the given time, when a
+// background job resurfaces it.
+func (h *TaskHandler) Snooze(w http.ResponseWriter, r *http.Request) {
+	user, task, ok := loadTask(w, r, h.access, services.PermissionEdit)
+	if !ok {
+		return
+	}
+
+	var req snoozeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "invalid_request", "Invalid JSON")
+		return
+	}
+
+	if err := utils.ValidateStruct(req); err != nil {
+		utils.WriteValidationError(w, err)
+		return
+	}
+
+	if !req.Until.After(time.Now()) {
+		utils.WriteError(w, http.StatusBadRequest, "validation_error", "until must be in the future")
+		return
+	}
+
+	if task.Status == models.StatusDone {
+		utils.WriteError(w, http.StatusConflict, "task_done", "Completed tasks cannot be snoozed")
+		return
+	}
+
+	if err := h.taskDAO.Update(r.Context(), task.ID, 0, bson.M{"snoozed_until": req.Until}, nil, user.UserID); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			utils.WriteError(w, http.StatusNotFound, "not_found", "Task not found")
+			return
+		}
+		h.logger.Error("Failed to snooze task", zap.Error(err))
+		utils.WriteError(w, http.StatusInternalServerError, "database_error", "Failed to snooze task")
+		return
+	}
+
+	snoozedTask, _ := h.taskDAO.GetByID(r.Context(), task.ID)
+	utils.WriteSuccess(w, snoozedTask)
+}
+
+// Unsnooze returns a snoozed task to default listings right away.
+func (h *TaskHandler) Unsnooze(w http.ResponseWriter, r *http.Request) {
+	user, task, ok := loadTask(w, r, h.access, services.PermissionEdit)
+	if !ok {
+		return
+	}
+
+	if _, err := h.taskDAO.Unsnooze(r.Context(), task.ID, nil, user.UserID); err != nil {
+		h.logger.Error("Failed to unsnooze task", zap.Error(err))
+		utils.WriteError(w, http.StatusInternalServerError, "database_error", "Failed to unsnooze task")
+		return
+	}
+
+	unsnoozedTask, _ := h.taskDAO.GetByID(r.Context(), task.ID)
+	utils.WriteSuccess(w, unsnoozedTask)
+}
+
+// Purge permanently deletes a task from the trash. Admin only.
+func (h *TaskHandler) Purge(w http.ResponseWriter, r *http.Request) {
+	idStr := chi.URLParam(r, "id")
+	id, err := primitive.ObjectIDFromHex(idStr)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "invalid_id", "Invalid task ID")
+		return
+	}
+
+	user, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		utils.WriteError(w, http.StatusUnauthorized, "unauthorized", "User not found in context")
+		return
+	}
+
+	if user.Role != string(models.RoleAdmin) {
+		utils.WriteError(w, http.StatusForbidden, "forbidden", "Only admins can permanently delete tasks")
+		return
+	}
+
+	if _, err := h.taskDAO.GetDeletedByID(r.Context(), id); err != nil {
+		utils.WriteError(w, http.StatusNotFound, "not_found", "Deleted task not found")
+		return
+	}
+
+	if err := h.purgeService.Purge(r.Context(), id); err != nil {
+		h.logger.Error("Failed to purge task", zap.Error(err))
+		utils.WriteError(w, http.StatusInternalServerError, "database_error", "Failed to purge task")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// applyFieldFilters reads cf.<key>=value filters, the filter expression and
+// the sort parameter, looking up custom fields as they are referenced.
+func (h *TaskHandler) applyFieldFilters(r *http.Request, filter *models.TaskFilter) error {
+	var defs map[string]*models.CustomFieldDefinition
+	lookup := func(key string) (*models.CustomFieldDefinition, error) {
+		if defs == nil {
+			list, err := h.customFieldDAO.ListApplicable(r.Context(), filter.ProjectID)
+			if err != nil {
+				return nil, err
+			}
+			defs = make(map[string]*models.CustomFieldDefinition, len(list))
+			for _, def := range list {
+				defs[def.Key] = def
+			}
+		}
+		def, ok := defs[key]
+		if !ok {
+			return nil, fmt.Errorf("unknown custom field %q", key)
+		}
+		return def, nil
+	}
+
+	q := r.URL.Query()
+	for param, values := range q {
+		key, ok := strings.CutPrefix(param, "cf.")
+		if !ok {
+			continue
+		}
+		def, err := lookup(key)
+		if err != nil {
+			return err
+		}
+		value, err := customFieldValue(def, values[0])
+		if err != nil {
+			return fmt.Errorf("cf.%s %s", key, err)
+		}
+		if filter.CustomFields == nil {
+			filter.CustomFields = map[string]interface{}{}
+		}
+		filter.CustomFields[key] = value
+	}
+
+	if expr := q.Get("filter"); expr != "" {
+		expression, err := query.Parse(expr, taskFieldResolver(lookup))
+		if err != nil {
+			return err
+		}
+		filter.Expression = expression
+	}
+
+	if sortParam := q.Get("sort"); sortParam != "" {
+		keys, err := parseSort(sortParam, lookup)
+		if err != nil {
+			return err
+		}
+		filter.Sort = keys
+	}
+
+	return nil
+}
+
+// checkPlanning verifies that the task's sprint and milestone belong to its
+// project and that the sprint is not closed.
+func (h *TaskHandler) checkPlanning(w http.ResponseWriter, r *http.Request, task *models.Task) bool {
+	if task.SprintID == nil && task.MilestoneID == nil {
+		return true
+	}
+	if task.ProjectID == nil {
+		utils.WriteError(w, http.StatusBadRequest, "validation_error", "Only project tasks can be planned into sprints and milestones")
+		return false
+	}
+
+	if task.SprintID != nil {
+		sprint, err := h.sprintDAO.GetByID(r.Context(), *task.ProjectID, *task.SprintID)
+		if err != nil {
+			utils.WriteError(w, http.StatusBadRequest, "validation_error", "sprint_id does not refer to a sprint in the task's project")
+			return false
+		}
+		if sprint.State == models.SprintClosed {
+			utils.WriteError(w, http.StatusConflict, "sprint_closed", "Cannot add tasks to a closed sprint")
+			return false
+		}
+	}
+
+	if task.MilestoneID != nil {
+		if _, err := h.milestoneDAO.GetByID(r.Context(), *task.ProjectID, *task.MilestoneID); err != nil {
+			utils.WriteError(w, http.StatusBadRequest, "validation_error", "milestone_id does not refer to a milestone in the task's project")
+			return false
+		}
+	}
+
+	return true
+}
+
+// taskETag is the entity tag of a task's current representation.
+func taskETag(task *models.Task) string {
+	return `"` + strconv.FormatInt(task.Version, 10) + `"`
+}
+
+// viewETag is the entity tag of a task as returned for a view. Sparse and
+// expanded representations get a weak tag of their own, since embedded users
+// can change without the task's version changing.
+func viewETag(task *models.Task, view *taskView) string {
+	if view.full() {
+		return taskETag(task)
+	}
+	sum := sha256.Sum256([]byte(view.key()))
+	return `W/"` + strconv.FormatInt(task.Version, 10) + "-" + hex.EncodeToString(sum[:4]) + `"`
+}
+
+// matchesETag reports whether a comma-separated If-None-Match header value
+// names etag, using the weak comparison RFC 9110 prescribes for it.
+func matchesETag(header, etag string) bool {
+	current := strings.TrimPrefix(etag, "W/")
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+		if tag == "*" || tag == current {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesVersion reports whether a comma-separated If-Match header value
+// names the task's current version. Tags of any view of that version match,
+// since they all stand for the same stored task.
+func matchesVersion(header string, task *models.Task) bool {
+	current := strconv.FormatInt(task.Version, 10)
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+		if tag == "*" {
+			return true
+		}
+		tag = strings.Trim(tag, `"`)
+		tag, _, _ = strings.Cut(tag, "-")
+		if tag == current {
+			return true
+		}
+	}
+	return false
+}
+
+// checkIfMatch evaluates the If-Match precondition against the loaded task
+// and returns the version a conditional write must expect, or 0 when the
+// request carries no precondition.
+func (h *TaskHandler) checkIfMatch(w http.ResponseWriter, r *http.Request, task *models.Task) (int64, bool) {
+	header := r.Header.Get("If-Match")
+	if header == "" {
+		if h.requireIfMatch {
+			utils.WriteError(w, http.StatusPreconditionRequired, "precondition_required", "If-Match header is required")
+			return 0, false
+		}
+		return 0, true
+	}
+
+	if !matchesVersion(header, task) {
+		writePreconditionFailed(w, task)
+		return 0, false
+	}
+	if strings.TrimSpace(header) == "*" {
+		return 0, true
+	}
+	return task.Version, true
+}
+
+// preconditionFailed answers a conditional write that lost a race with the
+// task's current representation.
+func (h *TaskHandler) preconditionFailed(w http.ResponseWriter, r *http.Request, id primitive.ObjectID) {
+	current, err := h.taskDAO.GetByID(r.Context(), id)
+	if err != nil {
+		utils.WriteError(w, http.StatusNotFound, "not_found", "Task not found")
+		return
+	}
+	writePreconditionFailed(w, current)
+}
+
+func writePreconditionFailed(w http.ResponseWriter, current *models.Task) {
+	w.Header().Set("ETag", taskETag(current))
+	p := utils.NewProblem(http.StatusPreconditionFailed, "precondition_failed", "Task has been modified; retry with the current version")
+	p.Data = current
+	utils.WriteProblem(w, p)
+}
+
+func uniqueObjectIDs(ids []primitive.ObjectID) []primitive.ObjectID {
+	seen := make(map[primitive.ObjectID]bool, len(ids))
+	result := make([]primitive.ObjectID, 0, len(ids))
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			result = append(result, id)
+		}
+	}
+	return result
+}
+
+// loadTask resolves the task named by the {id} URL parameter and checks that
+// the user holds the required permission, writing the error response on
+// failure. Fields, if given, limit the document fields loaded.
+func loadTask(w http.ResponseWriter, r *http.Request, access *services.AccessService, required services.Permission, fields ...string) (*middleware.Claims, *models.Task, bool) {
+	user, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		utils.WriteError(w, http.StatusUnauthorized, "unauthorized", "User not found in context")
+		return nil, nil, false
+	}
+
+	taskID, err := primitive.ObjectIDFromHex(chi.URLParam(r, "id"))
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "invalid_id", "Invalid task ID")
+		return nil, nil, false
+	}
+
+	task, err := access.Task(r.Context(), user, taskID, required, fields...)
+	switch {
+	case errors.Is(err, services.ErrForbidden):
+		utils.WriteError(w, http.StatusForbidden, "forbidden", "Insufficient permission on task")
+		return nil, nil, false
+	case err != nil:
+		utils.WriteError(w, http.StatusNotFound, "not_found", "Task not found")
+		return nil, nil, false
+	}
+
+	return user, task, true
+}
@@ -0,0 +1,338 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/grewalsk/task-api/internal/middleware"
+	"github.com/grewalsk/task-api/internal/models"
+	"github.com/grewalsk/task-api/internal/services"
+	"github.com/grewalsk/task-api/internal/utils"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.uber.org/zap"
+)
+
+type TemplateHandler struct {
+	templateDAO     *models.TemplateDAO
+	access          *services.AccessService
+	customFieldDAO  *models.CustomFieldDAO
+	templateService *services.TemplateService
+	logger          *zap.Logger
+}
+
+func NewTemplateHandler(templateDAO *models.TemplateDAO, access *services.AccessService, customFieldDAO *models.CustomFieldDAO, templateService *services.TemplateService, logger *zap.Logger) *TemplateHandler {
+	return &TemplateHandler{
+		templateDAO:     templateDAO,
+		access:          access,
+		customFieldDAO:  customFieldDAO,
+		templateService: templateService,
+		logger:          logger,
+	}
+}
+
+type instantiateRequest struct {
+	Version      int                    `json:"version" validate:"min=0"`
+	ProjectID    *primitive.ObjectID    `json:"project_id"`
+	Variables    map[string]string      `json:"variables"`
+	Timezone     string                 `json:"timezone"`
+	CustomFields map[string]interface{} `json:"custom_fields"`
+}
+
+func canReadTemplate(user *middleware.Claims, tmpl *models.Template) bool {
+	return tmpl.Shared || canEditTemplate(user, tmpl)
+}
+
+func canEditTemplate(user *middleware.Claims, tmpl *models.Template) bool {
+	return tmpl.OwnerID == user.UserID || user.Role == string(models.RoleAdmin)
+}
+
+// loadTemplate resolves the latest version of the template named by the {id}
+// URL parameter, writing the error response on failure.
+func (h *TemplateHandler) loadTemplate(w http.ResponseWriter, r *http.Request) (*middleware.Claims, *models.Template, bool) {
+	user, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		utils.WriteError(w, http.StatusUnauthorized, "unauthorized", "User not found in context")
+		return nil, nil, false
+	}
+
+	templateID, err := primitive.ObjectIDFromHex(chi.URLParam(r, "id"))
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "invalid_id", "Invalid template ID")
+		return nil, nil, false
+	}
+
+	tmpl, err := h.templateDAO.Get(r.Context(), templateID, 0)
+	if err != nil || !canReadTemplate(user, tmpl) {
+		utils.WriteError(w, http.StatusNotFound, "not_found", "Template not found")
+		return nil, nil, false
+	}
+
+	return user, tmpl, true
+}
+
+func decodeTemplate(w http.ResponseWriter, r *http.Request) (*models.Template, bool) {
+	var tmpl models.Template
+	if err := json.NewDecoder(r.Body).Decode(&tmpl); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "invalid_request", "Invalid JSON")
+		return nil, false
+	}
+
+	if err := utils.ValidateStruct(tmpl); err != nil {
+		utils.WriteValidationError(w, err)
+		return nil, false
+	}
+
+	return &tmpl, true
+}
+
+func (h *TemplateHandler) Create(w http.ResponseWriter, r *http.Request) {
+	user, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		utils.WriteError(w, http.StatusUnauthorized, "unauthorized", "User not found in context")
+		return
+	}
+
+	tmpl, ok := decodeTemplate(w, r)
+	if !ok {
+		return
+	}
+
+	tmpl.OwnerID = user.UserID
+	tmpl.CreatedBy = user.UserID
+
+	if err := h.templateDAO.Create(r.Context(), tmpl); err != nil {
+		h.logger.Error("Failed to create template", zap.Error(err))
+		utils.WriteError(w, http.StatusInternalServerError, "database_error", "Failed to create template")
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusCreated, tmpl)
+}
+
+func (h *TemplateHandler) List(w http.ResponseWriter, r *http.Request) {
+	user, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		utils.WriteError(w, http.StatusUnauthorized, "unauthorized", "User not found in context")
+		return
+	}
+
+	limit, offset := parsePagination(r, 50, 100)
+
+	var ownerID *primitive.ObjectID
+	if user.Role != string(models.RoleAdmin) {
+		ownerID = &user.UserID
+	}
+
+	templates, err := h.templateDAO.ListLatest(r.Context(), ownerID, limit, offset)
+	if err != nil {
+		h.logger.Error("Failed to list templates", zap.Error(err))
+		utils.WriteError(w, http.StatusInternalServerError, "database_error", "Failed to list templates")
+		return
+	}
+
+	utils.WriteSuccess(w, templates)
+}
+
+// GetByID returns the latest version, or the one named by the version query
+// parameter.
+func (h *TemplateHandler) GetByID(w http.ResponseWriter, r *http.Request) {
+	_, tmpl, ok := h.loadTemplate(w, r)
+	if !ok {
+		return
+	}
+
+	if versionStr := r.URL.Query().Get("version"); versionStr != "" {
+		version, err := strconv.Atoi(versionStr)
+		if err != nil || version < 1 {
+			utils.WriteError(w, http.StatusBadRequest, "invalid_request", "Invalid template version")
+			return
+		}
+
+		tmpl, err = h.templateDAO.Get(r.Context(), tmpl.TemplateID, version)
+		if err != nil {
+			utils.WriteError(w, http.StatusNotFound, "not_found", "Template version not found")
+			return
+		}
+	}
+
+	utils.WriteSuccess(w, tmpl)
+}
+
+func (h *TemplateHandler) Versions(w http.ResponseWriter, r *http.Request) {
+	_, tmpl, ok := h.loadTemplate(w, r)
+	if !ok {
+		return
+	}
+
+	versions, err := h.templateDAO.ListVersions(r.Context(), tmpl.TemplateID)
+	if err != nil {
+		h.logger.Error("Failed to list template versions", zap.Error(err))
+		utils.WriteError(w, http.StatusInternalServerError, "database_error", "Failed to list template versions")
+		return
+	}
+
+	utils.WriteSuccess(w, versions)
+}
+
+// Update replaces the template contents by publishing a new version. Tasks
+// created from earlier versions are unaffected.
+func (h *TemplateHandler) Update(w http.ResponseWriter, r *http.Request) {
+	user, current, ok := h.loadTemplate(w, r)
+	if !ok {
+		return
+	}
+
+	if !canEditTemplate(user, current) {
+		utils.WriteError(w, http.StatusForbidden, "forbidden", "Cannot update template owned by another user")
+		return
+	}
+
+	tmpl, ok := decodeTemplate(w, r)
+	if !ok {
+		return
+	}
+
+	tmpl.CreatedBy = user.UserID
+
+	if err := h.templateDAO.CreateVersion(r.Context(), current, tmpl); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			utils.WriteError(w, http.StatusConflict, "conflict", "Template was modified concurrently")
+			return
+		}
+		h.logger.Error("Failed to update template", zap.Error(err))
+		utils.WriteError(w, http.StatusInternalServerError, "database_error", "Failed to update template")
+		return
+	}
+
+	utils.WriteSuccess(w, tmpl)
+}
+
+func (h *TemplateHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	user, tmpl, ok := h.loadTemplate(w, r)
+	if !ok {
+		return
+	}
+
+	if !canEditTemplate(user, tmpl) {
+		utils.WriteError(w, http.StatusForbidden, "forbidden", "Cannot delete template owned by another user")
+		return
+	}
+
+	if err := h.templateDAO.Delete(r.Context(), tmpl.TemplateID); err != nil {
+		h.logger.Error("Failed to delete template", zap.Error(err))
+		utils.WriteError(w, http.StatusInternalServerError, "database_error", "Failed to delete template")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Instantiate creates a task, and its subtasks, from the latest or the
+// requested version of a template.
+func (h *TemplateHandler) Instantiate(w http.ResponseWriter, r *http.Request) {
+	user, tmpl, ok := h.loadTemplate(w, r)
+	if !ok {
+		return
+	}
+
+	var req instantiateRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			utils.WriteError(w, http.StatusBadRequest, "invalid_request", "Invalid JSON")
+			return
+		}
+	}
+
+	if err := utils.ValidateStruct(req); err != nil {
+		utils.WriteValidationError(w, err)
+		return
+	}
+
+	if req.Version > 0 && req.Version != tmpl.Version {
+		var err error
+		tmpl, err = h.templateDAO.Get(r.Context(), tmpl.TemplateID, req.Version)
+		if err != nil {
+			utils.WriteError(w, http.StatusNotFound, "not_found", "Template version not found")
+			return
+		}
+	}
+
+	if req.ProjectID != nil {
+		if _, ok := loadProjectForNewTask(w, r, h.access, *req.ProjectID); !ok {
+			return
+		}
+	}
+
+	loc := time.UTC
+	if req.Timezone != "" {
+		var err error
+		loc, err = time.LoadLocation(req.Timezone)
+		if err != nil {
+			utils.WriteError(w, http.StatusBadRequest, "validation_error", "Invalid timezone")
+			return
+		}
+	}
+
+	tasks, err := h.templateService.Render(tmpl, services.InstantiateInput{
+		OwnerID:   user.UserID,
+		ProjectID: req.ProjectID,
+		Variables: req.Variables,
+		Location:  loc,
+	})
+	if err != nil {
+		if errors.Is(err, services.ErrMissingVariables) {
+			utils.WriteError(w, http.StatusBadRequest, "validation_error", err.Error())
+			return
+		}
+		h.logger.Error("Failed to render template", zap.Error(err))
+		utils.WriteError(w, http.StatusInternalServerError, "internal_error", "Failed to render template")
+		return
+	}
+
+	for _, task := range tasks {
+		if err := utils.ValidateStruct(task); err != nil {
+			utils.WriteValidationError(w, err)
+			return
+		}
+	}
+
+	defs, err := h.customFieldDAO.ListApplicable(r.Context(), req.ProjectID)
+	if err != nil {
+		h.logger.Error("Failed to load custom fields", zap.Error(err))
+		utils.WriteError(w, http.StatusInternalServerError, "database_error", "Failed to create task")
+		return
+	}
+
+	tasks[0].CustomFields, err = models.NormalizeCustomFields(defs, req.CustomFields, nil)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "validation_error", err.Error())
+		return
+	}
+	// Subtasks take the parent's values, which already satisfy every
+	// required field.
+	for _, task := range tasks[1:] {
+		if len(tasks[0].CustomFields) == 0 {
+			break
+		}
+		task.CustomFields = make(map[string]interface{}, len(tasks[0].CustomFields))
+		for key, value := range tasks[0].CustomFields {
+			task.CustomFields[key] = value
+		}
+	}
+
+	if err := h.templateService.Create(r.Context(), tasks, user.UserID); err != nil {
+		h.logger.Error("Failed to create tasks from template", zap.Error(err))
+		utils.WriteError(w, http.StatusInternalServerError, "database_error", "Failed to create task")
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusCreated, taskTreeResponse{
+		Task:     tasks[0],
+		Subtasks: tasks[1:],
+	})
+}
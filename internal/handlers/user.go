@@ -0,0 +1,102 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/grewalsk/task-api/internal/models"
+	"github.com/grewalsk/task-api/internal/services"
+	"github.com/grewalsk/task-api/internal/utils"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.uber.org/zap"
+)
+
+type UserHandler struct {
+	userDAO     *models.UserDAO
+	authService *services.AuthService
+	logger      *zap.Logger
+}
+
+func NewUserHandler(userDAO *models.UserDAO, authService *services.AuthService, logger *zap.Logger) *UserHandler {
+	return &UserHandler{
+		userDAO:     userDAO,
+		authService: authService,
+		logger:      logger,
+	}
+}
+
+type createUserRequest struct {
+	Email    string          `json:"email" validate:"required,email"`
+	Password string          `json:"password" validate:"required,min=6"`
+	Role     models.UserRole `json:"role" validate:"omitempty,oneof=user admin"`
+}
+
+// Create adds a user to the caller's workspace. Admin only.
+func (h *UserHandler) Create(w http.ResponseWriter, r *http.Request) {
+	if !requireAdmin(w, r) {
+		return
+	}
+
+	var req createUserRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "invalid_request", "Invalid JSON")
+		return
+	}
+
+	if err := utils.ValidateStruct(req); err != nil {
+		utils.WriteValidationError(w, err)
+		return
+	}
+
+	user := &models.User{
+		Email:    req.Email,
+		Password: h.authService.HashPassword(req.Password),
+		Role:     req.Role,
+	}
+	if user.Role == "" {
+		user.Role = models.RoleUser
+	}
+
+	if err := h.userDAO.Create(r.Context(), user); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			utils.WriteError(w, http.StatusConflict, "conflict", "A user with this email already exists")
+			return
+		}
+		h.logger.Error("Failed to create user", zap.Error(err))
+		utils.WriteError(w, http.StatusInternalServerError, "database_error", "Failed to create user")
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusCreated, user)
+}
+
+func (h *UserHandler) List(w http.ResponseWriter, r *http.Request) {
+	limit, offset := parsePagination(r, 50, 100)
+
+	users, err := h.userDAO.List(r.Context(), limit, offset)
+	if err != nil {
+		h.logger.Error("Failed to list users", zap.Error(err))
+		utils.WriteError(w, http.StatusInternalServerError, "database_error", "Failed to list users")
+		return
+	}
+
+	utils.WriteSuccess(w, users)
+}
+
+func (h *UserHandler) GetByID(w http.ResponseWriter, r *http.Request) {
+	id, err := primitive.ObjectIDFromHex(chi.URLParam(r, "id"))
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "invalid_id", "Invalid user ID")
+		return
+	}
+
+	user, err := h.userDAO.GetByID(r.Context(), id)
+	if err != nil {
+		utils.WriteError(w, http.StatusNotFound, "not_found", "User not found")
+		return
+	}
+
+	utils.WriteSuccess(w, user)
+}
@@ -0,0 +1,391 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/grewalsk/task-api/internal/middleware"
+	"github.com/grewalsk/task-api/internal/models"
+	"github.com/grewalsk/task-api/internal/services"
+	"github.com/grewalsk/task-api/internal/utils"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.uber.org/zap"
+)
+
+// viewFilterParams are the task list query parameters a view may fix, in
+// addition to cf.<key> custom field filters.
+var viewFilterParams = map[string]bool{
+	"status":          true,
+	"owner":           true,
+	"project_id":      true,
+	"sprint_id":       true,
+	"milestone_id":    true,
+	"search":          true,
+	"include_snoozed": true,
+	"has_description": true,
+	"created_after":   true,
+	"created_before":  true,
+	"updated_after":   true,
+	"updated_before":  true,
+	"filter":          true,
+}
+
+var groupableTaskFields = map[string]bool{
+	"status":       true,
+	"owner_id":     true,
+	"project_id":   true,
+	"sprint_id":    true,
+	"milestone_id": true,
+	"assignee_ids": true,
+	"labels":       true,
+}
+
+type ViewHandler struct {
+	viewDAO     *models.ViewDAO
+	projectDAO  *models.ProjectDAO
+	taskHandler *TaskHandler
+	access      *services.AccessService
+	logger      *zap.Logger
+}
+
+func NewViewHandler(viewDAO *models.ViewDAO, projectDAO *models.ProjectDAO, taskHandler *TaskHandler, access *services.AccessService, logger *zap.Logger) *ViewHandler {
+	return &ViewHandler{
+		viewDAO:     viewDAO,
+		projectDAO:  projectDAO,
+		taskHandler: taskHandler,
+		access:      access,
+		logger:      logger,
+	}
+}
+
+type viewUpdateRequest struct {
+	Name    *string            `json:"name" validate:"omitempty,min=1,max=100"`
+	Filters *map[string]string `json:"filters" validate:"omitempty,max=30,dive,max=2000"`
+	Sort    *string            `json:"sort" validate:"omitempty,max=100"`
+	Columns *[]string          `json:"columns" validate:"omitempty,max=50,dive,min=1,max=100"`
+	GroupBy *string            `json:"group_by" validate:"omitempty,max=100"`
+	Default *bool              `json:"default"`
+}
+
+// loadView resolves the view named by the {id} URL parameter. Views are
+// visible to their owner and, when shared, to members of the project.
+func (h *ViewHandler) loadView(w http.ResponseWriter, r *http.Request) (*middleware.Claims, *models.SavedView, bool) {
+	user, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		utils.WriteError(w, http.StatusUnauthorized, "unauthorized", "User not found in context")
+		return nil, nil, false
+	}
+
+	id, err := primitive.ObjectIDFromHex(chi.URLParam(r, "id"))
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "invalid_id", "Invalid view ID")
+		return nil, nil, false
+	}
+
+	view, err := h.viewDAO.GetByID(r.Context(), id)
+	if err != nil {
+		utils.WriteError(w, http.StatusNotFound, "not_found", "View not found")
+		return nil, nil, false
+	}
+
+	if view.OwnerID != user.UserID {
+		if view.ProjectID == nil {
+			utils.WriteError(w, http.StatusNotFound, "not_found", "View not found")
+			return nil, nil, false
+		}
+		if _, err := h.access.Project(r.Context(), user, *view.ProjectID); err != nil {
+			utils.WriteError(w, http.StatusNotFound, "not_found", "View not found")
+			return nil, nil, false
+		}
+	}
+
+	return user, view, true
+}
+
+// loadOwnView is loadView restricted to views the user owns.
+func (h *ViewHandler) loadOwnView(w http.ResponseWriter, r *http.Request) (*models.SavedView, bool) {
+	user, view, ok := h.loadView(w, r)
+	if !ok {
+		return nil, false
+	}
+
+	if view.OwnerID != user.UserID {
+		utils.WriteError(w, http.StatusForbidden, "forbidden", "Only the owner can change a view")
+		return nil, false
+	}
+
+	return view, true
+}
+
+// checkView validates the view's filters and sort by building the task
+// filter they describe, and its grouping against the known fields.
+func (h *ViewHandler) checkView(w http.ResponseWriter, r *http.Request, view *models.SavedView) bool {
+	for key := range view.Filters {
+		if !viewFilterParams[key] && !strings.HasPrefix(key, "cf.") {
+			utils.WriteError(w, http.StatusBadRequest, "invalid_filter", "unsupported filter "+key)
+			return false
+		}
+	}
+
+	if view.GroupBy != "" && !groupableTaskFields[view.GroupBy] && !strings.HasPrefix(view.GroupBy, "cf.") {
+		utils.WriteError(w, http.StatusBadRequest, "validation_error", "cannot group by "+view.GroupBy)
+		return false
+	}
+
+	_, ok := h.taskHandler.listFilter(w, withQuery(r, viewQuery(view, nil)))
+	return ok
+}
+
+// checkProject verifies that the user may share a view with the project.
+func (h *ViewHandler) checkProject(w http.ResponseWriter, r *http.Request, user *middleware.Claims, projectID primitive.ObjectID) bool {
+	if _, err := h.access.Project(r.Context(), user, projectID); err != nil {
+		if errors.Is(err, services.ErrForbidden) {
+			utils.WriteError(w, http.StatusForbidden, "forbidden", "Views can only be shared with your own projects")
+			return false
+		}
+		utils.WriteError(w, http.StatusBadRequest, "validation_error", "project_id does not refer to a project")
+		return false
+	}
+	return true
+}
+
+func (h *ViewHandler) Create(w http.ResponseWriter, r *http.Request) {
+	user, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		utils.WriteError(w, http.StatusUnauthorized, "unauthorized", "User not found in context")
+		return
+	}
+
+	var view models.SavedView
+	if err := json.NewDecoder(r.Body).Decode(&view); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "invalid_request", "Invalid JSON")
+		return
+	}
+
+	if err := utils.ValidateStruct(view); err != nil {
+		utils.WriteValidationError(w, err)
+		return
+	}
+
+	if !h.checkView(w, r, &view) {
+		return
+	}
+	if view.ProjectID != nil && !h.checkProject(w, r, user, *view.ProjectID) {
+		return
+	}
+
+	view.OwnerID = user.UserID
+	if err := h.viewDAO.Create(r.Context(), &view); err != nil {
+		h.logger.Error("Failed to create view", zap.Error(err))
+		utils.WriteError(w, http.StatusInternalServerError, "database_error", "Failed to create view")
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusCreated, view)
+}
+
+// List returns the user's own views and those shared with their projects.
+func (h *ViewHandler) List(w http.ResponseWriter, r *http.Request) {
+	user, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		utils.WriteError(w, http.StatusUnauthorized, "unauthorized", "User not found in context")
+		return
+	}
+
+	projectIDs, err := h.projectDAO.ListIDsForMember(r.Context(), user.UserID)
+	if err != nil {
+		h.logger.Error("Failed to list projects", zap.Error(err))
+		utils.WriteError(w, http.StatusInternalServerError, "database_error", "Failed to list views")
+		return
+	}
+
+	views, err := h.viewDAO.ListVisible(r.Context(), user.UserID, projectIDs)
+	if err != nil {
+		h.logger.Error("Failed to list views", zap.Error(err))
+		utils.WriteError(w, http.StatusInternalServerError, "database_error", "Failed to list views")
+		return
+	}
+
+	utils.WriteSuccess(w, views)
+}
+
+func (h *ViewHandler) GetByID(w http.ResponseWriter, r *http.Request) {
+	_, view, ok := h.loadView(w, r)
+	if !ok {
+		return
+	}
+
+	utils.WriteSuccess(w, view)
+}
+
+// Default returns the user's default view.
+func (h *ViewHandler) Default(w http.ResponseWriter, r *http.Request) {
+	user, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		utils.WriteError(w, http.StatusUnauthorized, "unauthorized", "User not found in context")
+		return
+	}
+
+	view, err := h.viewDAO.GetDefault(r.Context(), user.UserID)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			utils.WriteError(w, http.StatusNotFound, "not_found", "No default view")
+			return
+		}
+		h.logger.Error("Failed to get default view", zap.Error(err))
+		utils.WriteError(w, http.StatusInternalServerError, "database_error", "Failed to get default view")
+		return
+	}
+
+	utils.WriteSuccess(w, view)
+}
+
+func (h *ViewHandler) Update(w http.ResponseWriter, r *http.Request) {
+	view, ok := h.loadOwnView(w, r)
+	if !ok {
+		return
+	}
+	user, _ := middleware.GetUserFromContext(r.Context())
+
+	// project_id is read separately because null, which unshares the view,
+	// must be told apart from an absent field.
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "invalid_request", "Invalid JSON")
+		return
+	}
+	var req viewUpdateRequest
+	var raw map[string]json.RawMessage
+	if json.Unmarshal(body, &req) != nil || json.Unmarshal(body, &raw) != nil {
+		utils.WriteError(w, http.StatusBadRequest, "invalid_request", "Invalid JSON")
+		return
+	}
+
+	if err := utils.ValidateStruct(req); err != nil {
+		utils.WriteValidationError(w, err)
+		return
+	}
+
+	set := bson.M{}
+	var unset []string
+	if req.Name != nil {
+		view.Name = *req.Name
+		set["name"] = view.Name
+	}
+	if req.Filters != nil {
+		view.Filters = *req.Filters
+		set["filters"] = view.Filters
+	}
+	if req.Sort != nil {
+		view.Sort = *req.Sort
+		set["sort"] = view.Sort
+	}
+	if req.Columns != nil {
+		view.Columns = *req.Columns
+		set["columns"] = view.Columns
+	}
+	if req.GroupBy != nil {
+		view.GroupBy = *req.GroupBy
+		set["group_by"] = view.GroupBy
+	}
+	if req.Default != nil {
+		set["default"] = *req.Default
+	}
+	if value, ok := raw["project_id"]; ok {
+		var projectID *primitive.ObjectID
+		if err := json.Unmarshal(value, &projectID); err != nil {
+			utils.WriteError(w, http.StatusBadRequest, "validation_error", "project_id must be an ID or null")
+			return
+		}
+		if projectID == nil {
+			unset = append(unset, "project_id")
+		} else {
+			if !h.checkProject(w, r, user, *projectID) {
+				return
+			}
+			set["project_id"] = *projectID
+		}
+	}
+
+	if len(set) == 0 && len(unset) == 0 {
+		utils.WriteError(w, http.StatusBadRequest, "no_updates", "No valid fields to update")
+		return
+	}
+
+	if !h.checkView(w, r, view) {
+		return
+	}
+
+	if err := h.viewDAO.Update(r.Context(), view, set, unset); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			utils.WriteError(w, http.StatusNotFound, "not_found", "View not found")
+			return
+		}
+		h.logger.Error("Failed to update view", zap.Error(err))
+		utils.WriteError(w, http.StatusInternalServerError, "database_error", "Failed to update view")
+		return
+	}
+
+	updated, _ := h.viewDAO.GetByID(r.Context(), view.ID)
+	utils.WriteSuccess(w, updated)
+}
+
+func (h *ViewHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	view, ok := h.loadOwnView(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.viewDAO.Delete(r.Context(), view.ID); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			utils.WriteError(w, http.StatusNotFound, "not_found", "View not found")
+			return
+		}
+		h.logger.Error("Failed to delete view", zap.Error(err))
+		utils.WriteError(w, http.StatusInternalServerError, "database_error", "Failed to delete view")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Tasks lists the tasks matching the view, within the tasks the requesting
+// user can see. Other query parameters, such as limit and offset, are passed
+// through to the task listing; the view's own filters and sort take
+// precedence.
+func (h *ViewHandler) Tasks(w http.ResponseWriter, r *http.Request) {
+	_, view, ok := h.loadView(w, r)
+	if !ok {
+		return
+	}
+
+	h.taskHandler.List(w, withQuery(r, viewQuery(view, r.URL.Query())))
+}
+
+// viewQuery overlays the view's filters and sort on base.
+func viewQuery(view *models.SavedView, base url.Values) url.Values {
+	query := url.Values{}
+	for key, values := range base {
+		query[key] = values
+	}
+	for key, value := range view.Filters {
+		query.Set(key, value)
+	}
+	if view.Sort != "" {
+		query.Set("sort", view.Sort)
+	}
+	return query
+}
+
+func withQuery(r *http.Request, query url.Values) *http.Request {
+	clone := r.Clone(r.Context())
+	clone.URL.RawQuery = query.Encode()
+	return clone
+}
@@ -0,0 +1,383 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/grewalsk/task-api/internal/middleware"
+	"github.com/grewalsk/task-api/internal/models"
+	"github.com/grewalsk/task-api/internal/services"
+	"github.com/grewalsk/task-api/internal/utils"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.uber.org/zap"
+)
+
+const maxTimesheetDays = 92
+
+type WorklogHandler struct {
+	worklogDAO *models.WorklogDAO
+	access     *services.AccessService
+	logger     *zap.Logger
+}
+
+func NewWorklogHandler(worklogDAO *models.WorklogDAO, access *services.AccessService, logger *zap.Logger) *WorklogHandler {
+	return &WorklogHandler{
+		worklogDAO: worklogDAO,
+		access:     access,
+		logger:     logger,
+	}
+}
+
+type worklogRequest struct {
+	StartedAt       time.Time  `json:"started_at" validate:"required"`
+	EndedAt         *time.Time `json:"ended_at"`
+	DurationSeconds int64      `json:"duration_seconds" validate:"min=0,max=86400"`
+	Note            string     `json:"note" validate:"max=1000"`
+}
+
+type taskTotalsResponse struct {
+	TaskID       primitive.ObjectID     `json:"task_id"`
+	TotalSeconds int64                  `json:"total_seconds"`
+	ByUser       []*models.WorklogTotal `json:"by_user"`
+}
+
+type userTotalsResponse struct {
+	UserID       primitive.ObjectID     `json:"user_id"`
+	From         string                 `json:"from"`
+	To           string                 `json:"to"`
+	TotalSeconds int64                  `json:"total_seconds"`
+	ByTask       []*models.WorklogTotal `json:"by_task"`
+}
+
+type timesheetDay struct {
+	Date  string                   `json:"date"`
+	Hours float64                  `json:"hours"`
+	Tasks []*timesheetTaskResponse `json:"tasks"`
+}
+
+type timesheetTaskResponse struct {
+	TaskID primitive.ObjectID `json:"task_id"`
+	Hours  float64            `json:"hours"`
+}
+
+type timesheetResponse struct {
+	UserID     primitive.ObjectID `json:"user_id"`
+	From       string             `json:"from"`
+	To         string             `json:"to"`
+	Timezone   string             `json:"timezone"`
+	TotalHours float64            `json:"total_hours"`
+	Days       []*timesheetDay    `json:"days"`
+}
+
+func (h *WorklogHandler) StartTimer(w http.ResponseWriter, r *http.Request) {
+	user, task, ok := loadTask(w, r, h.access, services.PermissionEdit)
+	if !ok {
+		return
+	}
+
+	worklog, err := h.worklogDAO.StartTimer(r.Context(), task.ID, user.UserID)
+	if err != nil {
+		if errors.Is(err, models.ErrTimerRunning) {
+			utils.WriteError(w, http.StatusConflict, "timer_running", "Stop the running timer before starting another")
+			return
+		}
+		h.logger.Error("Failed to start timer", zap.Error(err))
+		utils.WriteError(w, http.StatusInternalServerError, "database_error", "Failed to start timer")
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusCreated, worklog)
+}
+
+func (h *WorklogHandler) StopTimer(w http.ResponseWriter, r *http.Request) {
+	user, task, ok := loadTask(w, r, h.access, services.PermissionRead)
+	if !ok {
+		return
+	}
+
+	running, err := h.worklogDAO.GetRunning(r.Context(), user.UserID)
+	if err != nil || running.TaskID != task.ID {
+		utils.WriteError(w, http.StatusNotFound, "not_found", "No running timer on this task")
+		return
+	}
+
+	worklog, err := h.worklogDAO.StopTimer(r.Context(), running)
+	if err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			utils.WriteError(w, http.StatusNotFound, "not_found", "No running timer on this task")
+			return
+		}
+		h.logger.Error("Failed to stop timer", zap.Error(err))
+		utils.WriteError(w, http.StatusInternalServerError, "database_error", "Failed to stop timer")
+		return
+	}
+
+	utils.WriteSuccess(w, worklog)
+}
+
+func (h *WorklogHandler) CurrentTimer(w http.ResponseWriter, r *http.Request) {
+	user, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		utils.WriteError(w, http.StatusUnauthorized, "unauthorized", "User not found in context")
+		return
+	}
+
+	worklog, err := h.worklogDAO.GetRunning(r.Context(), user.UserID)
+	if err != nil {
+		utils.WriteError(w, http.StatusNotFound, "not_found", "No running timer")
+		return
+	}
+
+	utils.WriteSuccess(w, worklog)
+}
+
+func (h *WorklogHandler) Create(w http.ResponseWriter, r *http.Request) {
+	user, task, ok := loadTask(w, r, h.access, services.PermissionEdit)
+	if !ok {
+		return
+	}
+
+	var req worklogRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "invalid_request", "Invalid JSON")
+		return
+	}
+
+	if err := utils.ValidateStruct(req); err != nil {
+		utils.WriteValidationError(w, err)
+		return
+	}
+
+	if req.EndedAt != nil {
+		if !req.EndedAt.After(req.StartedAt) {
+			utils.WriteError(w, http.StatusBadRequest, "validation_error", "ended_at must be after started_at")
+			return
+		}
+		req.DurationSeconds = int64(req.EndedAt.Sub(req.StartedAt).Seconds())
+	}
+
+	if req.DurationSeconds <= 0 || req.DurationSeconds > 86400 {
+		utils.WriteError(w, http.StatusBadRequest, "validation_error", "duration must be between 1 second and 24 hours")
+		return
+	}
+
+	endedAt := req.StartedAt.Add(time.Duration(req.DurationSeconds) * time.Second)
+	worklog := models.Worklog{
+		TaskID:          task.ID,
+		UserID:          user.UserID,
+		StartedAt:       req.StartedAt,
+		EndedAt:         &endedAt,
+		DurationSeconds: req.DurationSeconds,
+		Note:            req.Note,
+	}
+
+	if err := h.worklogDAO.Create(r.Context(), &worklog); err != nil {
+		h.logger.Error("Failed to create worklog", zap.Error(err))
+		utils.WriteError(w, http.StatusInternalServerError, "database_error", "Failed to create worklog")
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusCreated, worklog)
+}
+
+func (h *WorklogHandler) List(w http.ResponseWriter, r *http.Request) {
+	_, task, ok := loadTask(w, r, h.access, services.PermissionRead)
+	if !ok {
+		return
+	}
+
+	limit, offset := parsePagination(r, 20, 100)
+
+	worklogs, err := h.worklogDAO.ListByTask(r.Context(), task.ID, limit, offset)
+	if err != nil {
+		h.logger.Error("Failed to list worklogs", zap.Error(err))
+		utils.WriteError(w, http.StatusInternalServerError, "database_error", "Failed to list worklogs")
+		return
+	}
+
+	utils.WriteSuccess(w, worklogs)
+}
+
+func (h *WorklogHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	user, task, ok := loadTask(w, r, h.access, services.PermissionEdit)
+	if !ok {
+		return
+	}
+
+	worklogID, err := primitive.ObjectIDFromHex(chi.URLParam(r, "worklogID"))
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "invalid_id", "Invalid worklog ID")
+		return
+	}
+
+	worklog, err := h.worklogDAO.GetByID(r.Context(), task.ID, worklogID)
+	if err != nil {
+		utils.WriteError(w, http.StatusNotFound, "not_found", "Worklog not found")
+		return
+	}
+
+	if worklog.UserID != user.UserID && user.Role != string(models.RoleAdmin) {
+		utils.WriteError(w, http.StatusForbidden, "forbidden", "Cannot delete worklog of another user")
+		return
+	}
+
+	if err := h.worklogDAO.Delete(r.Context(), worklog.ID); err != nil {
+		h.logger.Error("Failed to delete worklog", zap.Error(err))
+		utils.WriteError(w, http.StatusInternalServerError, "database_error", "Failed to delete worklog")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// TaskTotals reports the time logged on a task, per user.
+func (h *WorklogHandler) TaskTotals(w http.ResponseWriter, r *http.Request) {
+	_, task, ok := loadTask(w, r, h.access, services.PermissionRead)
+	if !ok {
+		return
+	}
+
+	totals, err := h.worklogDAO.TotalsByTask(r.Context(), task.ID)
+	if err != nil {
+		h.logger.Error("Failed to total worklogs", zap.Error(err))
+		utils.WriteError(w, http.StatusInternalServerError, "database_error", "Failed to total worklogs")
+		return
+	}
+
+	var seconds int64
+	for _, total := range totals {
+		seconds += total.Seconds
+	}
+
+	utils.WriteSuccess(w, taskTotalsResponse{
+		TaskID:       task.ID,
+		TotalSeconds: seconds,
+		ByUser:       totals,
+	})
+}
+
+// UserTotals reports the time a user logged within a date range, per task.
+func (h *WorklogHandler) UserTotals(w http.ResponseWriter, r *http.Request) {
+	userID, from, to, loc, ok := h.parseRange(w, r)
+	if !ok {
+		return
+	}
+
+	totals, err := h.worklogDAO.TotalsByUser(r.Context(), userID, from, to)
+	if err != nil {
+		h.logger.Error("Failed to total worklogs", zap.Error(err))
+		utils.WriteError(w, http.StatusInternalServerError, "database_error", "Failed to total worklogs")
+		return
+	}
+
+	var seconds int64
+	for _, total := range totals {
+		seconds += total.Seconds
+	}
+
+	utils.WriteSuccess(w, userTotalsResponse{
+		UserID:       userID,
+		From:         from.In(loc).Format(time.DateOnly),
+		To:           to.In(loc).AddDate(0, 0, -1).Format(time.DateOnly),
+		TotalSeconds: seconds,
+		ByTask:       totals,
+	})
+}
+
+func (h *WorklogHandler) Timesheet(w http.ResponseWriter, r *http.Request) {
+	userID, from, to, loc, ok := h.parseRange(w, r)
+	if !ok {
+		return
+	}
+
+	entries, err := h.worklogDAO.Timesheet(r.Context(), userID, from, to, loc)
+	if err != nil {
+		h.logger.Error("Failed to build timesheet", zap.Error(err))
+		utils.WriteError(w, http.StatusInternalServerError, "database_error", "Failed to build timesheet")
+		return
+	}
+
+	resp := timesheetResponse{
+		UserID:   userID,
+		From:     from.In(loc).Format(time.DateOnly),
+		To:       to.In(loc).AddDate(0, 0, -1).Format(time.DateOnly),
+		Timezone: loc.String(),
+		Days:     []*timesheetDay{},
+	}
+
+	var day *timesheetDay
+	var daySeconds, totalSeconds int64
+	for _, entry := range entries {
+		if day == nil || day.Date != entry.Date {
+			day = &timesheetDay{Date: entry.Date}
+			daySeconds = 0
+			resp.Days = append(resp.Days, day)
+		}
+		day.Tasks = append(day.Tasks, &timesheetTaskResponse{TaskID: entry.TaskID, Hours: secondsToHours(entry.Seconds)})
+		daySeconds += entry.Seconds
+		totalSeconds += entry.Seconds
+		day.Hours = secondsToHours(daySeconds)
+	}
+	resp.TotalHours = secondsToHours(totalSeconds)
+
+	utils.WriteSuccess(w, resp)
+}
+
+// parseRange reads user_id, from, to (inclusive YYYY-MM-DD dates) and tz.
+// Users may only query their own time unless they are admins.
+func (h *WorklogHandler) parseRange(w http.ResponseWriter, r *http.Request) (primitive.ObjectID, time.Time, time.Time, *time.Location, bool) {
+	var zero primitive.ObjectID
+	user, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		utils.WriteError(w, http.StatusUnauthorized, "unauthorized", "User not found in context")
+		return zero, time.Time{}, time.Time{}, nil, false
+	}
+
+	query := r.URL.Query()
+	userID := user.UserID
+	if userIDStr := query.Get("user_id"); userIDStr != "" {
+		id, err := primitive.ObjectIDFromHex(userIDStr)
+		if err != nil {
+			utils.WriteError(w, http.StatusBadRequest, "invalid_id", "Invalid user ID")
+			return zero, time.Time{}, time.Time{}, nil, false
+		}
+		if id != user.UserID && user.Role != string(models.RoleAdmin) {
+			utils.WriteError(w, http.StatusForbidden, "forbidden", "Cannot view time logged by another user")
+			return zero, time.Time{}, time.Time{}, nil, false
+		}
+		userID = id
+	}
+
+	loc := time.UTC
+	if tz := query.Get("tz"); tz != "" {
+		var err error
+		if loc, err = time.LoadLocation(tz); err != nil {
+			utils.WriteError(w, http.StatusBadRequest, "invalid_request", "Invalid timezone")
+			return zero, time.Time{}, time.Time{}, nil, false
+		}
+	}
+
+	from, errFrom := time.ParseInLocation(time.DateOnly, query.Get("from"), loc)
+	to, errTo := time.ParseInLocation(time.DateOnly, query.Get("to"), loc)
+	if errFrom != nil || errTo != nil {
+		utils.WriteError(w, http.StatusBadRequest, "invalid_request", "from and to must be dates in YYYY-MM-DD format")
+		return zero, time.Time{}, time.Time{}, nil, false
+	}
+
+	to = to.AddDate(0, 0, 1)
+	if !to.After(from) || to.Sub(from) > maxTimesheetDays*24*time.Hour+time.Hour {
+		utils.WriteError(w, http.StatusBadRequest, "invalid_request", "Date range must cover between 1 and 92 days")
+		return zero, time.Time{}, time.Time{}, nil, false
+	}
+
+	return userID, from, to, loc, true
+}
+
+func secondsToHours(seconds int64) float64 {
+	return math.Round(float64(seconds)/36) / 100
+}
@@ -0,0 +1,108 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/grewalsk/task-api/internal/middleware"
+	"github.com/grewalsk/task-api/internal/models"
+	"github.com/grewalsk/task-api/internal/services"
+	"github.com/grewalsk/task-api/internal/utils"
+	"go.uber.org/zap"
+)
+
+type WorkspaceHandler struct {
+	workspaceService *services.WorkspaceService
+	logger           *zap.Logger
+}
+
+func NewWorkspaceHandler(workspaceService *services.WorkspaceService, logger *zap.Logger) *WorkspaceHandler {
+	return &WorkspaceHandler{
+		workspaceService: workspaceService,
+		logger:           logger,
+	}
+}
+
+type provisionWorkspaceRequest struct {
+	Slug          string `json:"slug" validate:"required,min=2,max=50"`
+	Name          string `json:"name" validate:"required,min=1,max=100"`
+	AdminEmail    string `json:"admin_email" validate:"required,email"`
+	AdminPassword string `json:"admin_password" validate:"required,min=6"`
+}
+
+type provisionWorkspaceResponse struct {
+	Workspace *models.Workspace `json:"workspace"`
+	Admin     *models.User      `json:"admin"`
+}
+
+func (h *WorkspaceHandler) Current(w http.ResponseWriter, r *http.Request) {
+	user, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		utils.WriteError(w, http.StatusUnauthorized, "unauthorized", "User not found in context")
+		return
+	}
+
+	workspace, err := h.workspaceService.Get(r.Context(), user)
+	if err != nil {
+		utils.WriteError(w, http.StatusNotFound, "not_found", "Workspace not found")
+		return
+	}
+
+	utils.WriteSuccess(w, workspace)
+}
+
+// Create provisions a new workspace and its first admin. Only admins of the
+// default workspace may do this.
+func (h *WorkspaceHandler) Create(w http.ResponseWriter, r *http.Request) {
+	user, ok := middleware.GetUserFromContext(r.Context())
+	if !ok {
+		utils.WriteError(w, http.StatusUnauthorized, "unauthorized", "User not found in context")
+		return
+	}
+
+	allowed, err := h.workspaceService.CanProvision(r.Context(), user)
+	if err != nil {
+		h.logger.Error("Failed to check workspace permissions", zap.Error(err))
+		utils.WriteError(w, http.StatusInternalServerError, "database_error", "Failed to create workspace")
+		return
+	}
+	if !allowed {
+		utils.WriteError(w, http.StatusForbidden, "forbidden", "Only admins of the default workspace can create workspaces")
+		return
+	}
+
+	var req provisionWorkspaceRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "invalid_request", "Invalid JSON")
+		return
+	}
+
+	if err := utils.ValidateStruct(req); err != nil {
+		utils.WriteValidationError(w, err)
+		return
+	}
+
+	if !models.ValidWorkspaceSlug(req.Slug) {
+		utils.WriteError(w, http.StatusBadRequest, "validation_error", "slug must contain only lowercase letters, digits and hyphens")
+		return
+	}
+
+	workspace := &models.Workspace{Slug: req.Slug, Name: req.Name}
+	admin := &models.User{Email: req.AdminEmail}
+
+	if err := h.workspaceService.Provision(r.Context(), workspace, admin, req.AdminPassword); err != nil {
+		if errors.Is(err, services.ErrWorkspaceExists) {
+			utils.WriteError(w, http.StatusConflict, "conflict", "A workspace with this slug already exists")
+			return
+		}
+		h.logger.Error("Failed to create workspace", zap.Error(err))
+		utils.WriteError(w, http.StatusInternalServerError, "database_error", "Failed to create workspace")
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusCreated, provisionWorkspaceResponse{
+		Workspace: workspace,
+		Admin:     admin,
+	})
+}
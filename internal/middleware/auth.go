@@ -6,12 +6,15 @@ import (
 	"strings"
 
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/grewalsk/task-api/internal/tenant"
+	"github.com/grewalsk/task-api/internal/utils"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 type Claims struct {
-	UserID primitive.ObjectID `json:"user_id"`
-	Role   string             `json:"role"`
+	UserID   primitive.ObjectID `json:"user_id"`
+	TenantID primitive.ObjectID `json:"tenant_id"`
+	Role     string             `json:"role"`
 	jwt.RegisteredClaims
 }
 
@@ -24,13 +27,13 @@ func JWTAuth(jwtSecret string) func(http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			authHeader := r.Header.Get("Authorization")
 			if authHeader == "" {
-				http.Error(w, `{"error":"missing_authorization","message":"Authorization header required"}`, http.StatusUnauthorized)
+				utils.WriteError(w, http.StatusUnauthorized, "missing_authorization", "Authorization header required")
 				return
 			}
 
 			tokenString := strings.TrimPrefix(authHeader, "Bearer ")
 			if tokenString == authHeader {
-				http.Error(w, `{"error":"invalid_authorization","message":"Bearer token required"}`, http.StatusUnauthorized)
+				utils.WriteError(w, http.StatusUnauthorized, "invalid_authorization", "Bearer token required")
 				return
 			}
 
@@ -39,17 +42,18 @@ func JWTAuth(jwtSecret string) func(http.Handler) http.Handler {
 			})
 
 			if err != nil || !token.Valid {
-				http.Error(w, `{"error":"invalid_token","message":"Invalid or expired token"}`, http.StatusUnauthorized)
+				utils.WriteError(w, http.StatusUnauthorized, "invalid_token", "Invalid or expired token")
 				return
 			}
 
 			claims, ok := token.Claims.(*Claims)
-			if !ok {
-				http.Error(w, `{"error":"invalid_claims","message":"Invalid token claims"}`, http.StatusUnauthorized)
+			if !ok || claims.TenantID.IsZero() {
+				utils.WriteError(w, http.StatusUnauthorized, "invalid_claims", "Invalid token claims")
 				return
 			}
 
 			ctx := context.WithValue(r.Context(), UserContextKey, claims)
+			ctx = tenant.WithID(ctx, claims.TenantID)
 			next.ServeHTTP(w, r.WithContext(ctx))
 		})
 	}
@@ -0,0 +1,107 @@
+package middleware
+
+import (
+	"bytes"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+
+	"github.com/grewalsk/task-api/internal/openapi"
+	"github.com/grewalsk/task-api/internal/utils"
+	"go.uber.org/zap"
+)
+
+// maxValidatedBodyBytes bounds the request bodies buffered for validation;
+// larger ones are passed on unchecked.
+const maxValidatedBodyBytes = 1 << 20
+
+// ValidateContract checks requests and responses against the OpenAPI
+// document, for development and tests. JSON request bodies that do not
+// match their schema are rejected with a validation_error listing each
+// violation. Responses that do not match are logged, as they are server
+// bugs the client cannot act on.
+func ValidateContract(spec *openapi.Spec, logger *zap.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			doc := spec.Document()
+			op, ok := doc.Match(r.Method, r.URL.Path)
+			if !ok {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			// Handlers read bodies without a content type as JSON.
+			contentType := r.Header.Get("Content-Type")
+			if contentType == "" {
+				contentType = "application/json"
+			}
+			if schema, ok := op.RequestSchema(contentType); ok && isJSON(contentType) {
+				body, err := io.ReadAll(io.LimitReader(r.Body, maxValidatedBodyBytes+1))
+				if err != nil {
+					utils.WriteError(w, http.StatusBadRequest, "invalid_request", "Failed to read request body")
+					return
+				}
+				r.Body = io.NopCloser(io.MultiReader(bytes.NewReader(body), r.Body))
+
+				if len(body) > 0 && len(body) <= maxValidatedBodyBytes {
+					if violations := doc.ValidateRequest(schema, body); len(violations) > 0 {
+						writeViolations(w, violations)
+						return
+					}
+				}
+			}
+
+			recorder := &recordingWriter{ResponseWriter: w, status: http.StatusOK}
+			next.ServeHTTP(recorder, r)
+
+			responseType := recorder.Header().Get("Content-Type")
+			if !isJSON(responseType) {
+				return
+			}
+			schema, ok := op.ResponseSchema(recorder.status, responseType)
+			if !ok {
+				logger.Error("Response is not described by the OpenAPI document",
+					zap.String("method", r.Method),
+					zap.String("path", r.URL.Path),
+					zap.String("request_id", GetRequestID(r.Context())),
+					zap.Int("status_code", recorder.status),
+					zap.String("content_type", responseType),
+				)
+				return
+			}
+			if violations := doc.ValidateResponse(schema, recorder.body.Bytes()); len(violations) > 0 {
+				messages := make([]string, len(violations))
+				for i, v := range violations {
+					messages[i] = v.Error()
+				}
+				logger.Error("Response does not match the OpenAPI document",
+					zap.String("method", r.Method),
+					zap.String("path", r.URL.Path),
+					zap.String("request_id", GetRequestID(r.Context())),
+					zap.Int("status_code", recorder.status),
+					zap.Strings("violations", messages),
+				)
+			}
+		})
+	}
+}
+
+func isJSON(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	return err == nil && (mediaType == "application/json" || strings.HasSuffix(mediaType, "+json"))
+}
+
+func writeViolations(w http.ResponseWriter, violations []openapi.Violation) {
+	fieldErrors := make([]utils.FieldError, len(violations))
+	messages := make([]string, len(violations))
+	for i, v := range violations {
+		message := strings.TrimSpace(v.Path + " " + v.Message)
+		fieldErrors[i] = utils.FieldError{Field: v.Path, Rule: v.Rule, Message: message}
+		messages[i] = message
+	}
+
+	p := utils.NewProblem(http.StatusBadRequest, "validation_error", strings.Join(messages, ", "))
+	p.Errors = fieldErrors
+	utils.WriteProblem(w, p)
+}
@@ -0,0 +1,230 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"hash"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/grewalsk/task-api/internal/models"
+	"github.com/grewalsk/task-api/internal/utils"
+	"go.uber.org/zap"
+)
+
+const (
+	IdempotencyKeyHeader = "Idempotency-Key"
+
+	maxIdempotencyKeyLength = 255
+	// maxUnreadBodyBytes bounds how much of a request body its handler left
+	// unread is read to complete the fingerprint.
+	maxUnreadBodyBytes = 1 << 20
+	// idempotencyLock is how long a request holds its key before a retry may
+	// assume it failed and take over. A running request renews it every
+	// idempotencyRenewal.
+	idempotencyLock    = time.Minute
+	idempotencyRenewal = idempotencyLock / 3
+)
+
+// Idempotency makes POST requests that carry an Idempotency-Key header safe
+// to retry. The first request with a key runs and its response is stored
+// for ttl; retries with the same key and payload receive the stored
+// response, marked with Idempotent-Replayed, without running again. Reusing
+// a key with a different payload is rejected, as is a retry that arrives
+// while the first request is still running. Keys are scoped to the user, so
+// this must run after JWTAuth. Server errors are not stored, leaving the
+// key free for a retry. Bodies are hashed as they are read rather than
+// buffered, so uploads of any size can be retried.
+func Idempotency(dao *models.IdempotencyDAO, ttl time.Duration, logger *zap.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := r.Header.Get(IdempotencyKeyHeader)
+			if r.Method != http.MethodPost || key == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			user, ok := GetUserFromContext(r.Context())
+			if !ok {
+				utils.WriteError(w, http.StatusUnauthorized, "unauthorized", "User not found in context")
+				return
+			}
+			if len(key) > maxIdempotencyKeyLength {
+				utils.WriteError(w, http.StatusBadRequest, "invalid_request", "Idempotency-Key must be at most 255 characters")
+				return
+			}
+
+			record, acquired, err := dao.Acquire(r.Context(), &models.IdempotencyRecord{
+				UserID:    user.UserID,
+				Key:       key,
+				ExpiresAt: time.Now().Add(ttl),
+			}, idempotencyLock)
+			if errors.Is(err, models.ErrIdempotencyKeyInUse) {
+				w.Header().Set("Retry-After", "1")
+				utils.WriteError(w, http.StatusConflict, "idempotency_key_in_use", "A request with this Idempotency-Key is still being processed")
+				return
+			}
+			if err != nil {
+				logger.Error("Failed to acquire idempotency key", zap.Error(err))
+				utils.WriteError(w, http.StatusInternalServerError, "database_error", "Failed to process request")
+				return
+			}
+
+			if !acquired {
+				body := newFingerprintReader(r)
+				if _, err := io.Copy(io.Discard, body); err != nil {
+					utils.WriteError(w, http.StatusBadRequest, "invalid_request", "Failed to read request body")
+					return
+				}
+				if record.Fingerprint != body.Sum() {
+					utils.WriteError(w, http.StatusUnprocessableEntity, "idempotency_key_reused", "Idempotency-Key was already used for a different request")
+					return
+				}
+				replay(w, record)
+				return
+			}
+
+			body := newFingerprintReader(r)
+			r.Body = body
+
+			// The outcome is recorded even if the client has gone away, since
+			// that is when it is most likely to retry.
+			ctx := context.WithoutCancel(r.Context())
+			recorder := &recordingWriter{ResponseWriter: w, status: http.StatusOK}
+			completed := false
+			defer func() {
+				if !completed {
+					if err := dao.Release(ctx, record); err != nil {
+						logger.Error("Failed to release idempotency key", zap.Error(err))
+					}
+				}
+			}()
+
+			stop := renewLock(ctx, dao, record, logger)
+			defer stop()
+			next.ServeHTTP(recorder, r)
+			stop()
+
+			if recorder.status >= http.StatusInternalServerError {
+				return
+			}
+			// A handler that answered without reading the whole body, such as
+			// one rejecting an oversized upload, cannot be tied to a complete
+			// request unless the rest is short enough to read now.
+			if !body.done {
+				io.Copy(io.Discard, io.LimitReader(body, maxUnreadBodyBytes))
+			}
+			if !body.done {
+				return
+			}
+			header := recorder.Header().Clone()
+			header.Del(utils.RequestIDHeader)
+			if err := dao.Complete(ctx, record, body.Sum(), recorder.status, header, recorder.body.Bytes()); err != nil {
+				logger.Error("Failed to store idempotent response", zap.Error(err))
+				return
+			}
+			completed = true
+		})
+	}
+}
+
+// renewLock keeps the record locked until the returned function is called,
+// so that a slow request is not taken over by a retry. Calling it again does
+// nothing.
+func renewLock(ctx context.Context, dao *models.IdempotencyDAO, record *models.IdempotencyRecord, logger *zap.Logger) func() {
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		ticker := time.NewTicker(idempotencyRenewal)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				if err := dao.Renew(ctx, record, idempotencyLock); err != nil {
+					logger.Error("Failed to renew idempotency key", zap.Error(err))
+					return
+				}
+			}
+		}
+	}()
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			close(done)
+			<-stopped
+		})
+	}
+}
+
+// fingerprintReader hashes a request body as it is read, together with the
+// method, path and query, to identify the request.
+type fingerprintReader struct {
+	body io.ReadCloser
+	hash hash.Hash
+	done bool
+}
+
+func newFingerprintReader(r *http.Request) *fingerprintReader {
+	h := sha256.New()
+	io.WriteString(h, r.Method+" "+r.URL.RequestURI()+"\n")
+	return &fingerprintReader{body: r.Body, hash: h}
+}
+
+func (f *fingerprintReader) Read(p []byte) (int, error) {
+	n, err := f.body.Read(p)
+	f.hash.Write(p[:n])
+	if err == io.EOF {
+		f.done = true
+	}
+	return n, err
+}
+
+func (f *fingerprintReader) Close() error {
+	return f.body.Close()
+}
+
+// Sum returns the fingerprint of the request, once its body has been read.
+func (f *fingerprintReader) Sum() string {
+	return hex.EncodeToString(f.hash.Sum(nil))
+}
+
+func replay(w http.ResponseWriter, record *models.IdempotencyRecord) {
+	for name, values := range record.Header {
+		w.Header()[name] = values
+	}
+	w.Header().Set("Idempotent-Replayed", "true")
+	w.Header().Set("Content-Length", strconv.Itoa(len(record.Body)))
+	w.WriteHeader(record.Status)
+	w.Write(record.Body)
+}
+
+// recordingWriter passes a response through while keeping a copy of it.
+type recordingWriter struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+	body        bytes.Buffer
+}
+
+func (rw *recordingWriter) WriteHeader(code int) {
+	if !rw.wroteHeader {
+		rw.status = code
+		rw.wroteHeader = true
+	}
+	rw.ResponseWriter.WriteHeader(code)
+}
+
+func (rw *recordingWriter) Write(b []byte) (int, error) {
+	rw.wroteHeader = true
+	rw.body.Write(b)
+	return rw.ResponseWriter.Write(b)
+}
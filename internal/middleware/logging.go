@@ -18,6 +18,7 @@ func Logging(logger *zap.Logger) func(http.Handler) http.Handler {
 			logger.Info("HTTP Request",
 				zap.String("method", r.Method),
 				zap.String("path", r.URL.Path),
+				zap.String("request_id", GetRequestID(r.Context())),
 				zap.String("remote_addr", r.RemoteAddr),
 				zap.Int("status_code", wrapped.statusCode),
 				zap.Duration("duration", time.Since(start)),
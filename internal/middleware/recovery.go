@@ -3,6 +3,7 @@ package middleware
 import (
 	"net/http"
 
+	"github.com/grewalsk/task-api/internal/utils"
 	"go.uber.org/zap"
 )
 
@@ -15,11 +16,10 @@ func Recovery(logger *zap.Logger) func(http.Handler) http.Handler {
 						zap.Any("error", err),
 						zap.String("method", r.Method),
 						zap.String("path", r.URL.Path),
+						zap.String("request_id", GetRequestID(r.Context())),
 					)
 
-					w.Header().Set("Content-Type", "application/json")
-					w.WriteHeader(http.StatusInternalServerError)
-					w.Write([]byte(`{"error":"internal_error","message":"Internal server error"}`))
+					utils.WriteError(w, http.StatusInternalServerError, "internal_error", "Internal server error")
 				}
 			}()
 
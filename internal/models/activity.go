@@ -0,0 +1,152 @@
+package models
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"time"
+
+	"github.com/grewalsk/task-api/internal/tenant"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type ActivityAction string
+
+const (
+	ActivityCreated  ActivityAction = "created"
+	ActivityUpdated  ActivityAction = "updated"
+	ActivityDeleted  ActivityAction = "deleted"
+	ActivityRestored ActivityAction = "restored"
+)
+
+// Activity is an immutable audit entry for a task. ActorID is nil for
+// changes made by the server itself, such as generated recurring tasks.
+type Activity struct {
+	ID        primitive.ObjectID  `json:"id" bson:"_id,omitempty"`
+	TaskID    primitive.ObjectID  `json:"task_id" bson:"task_id"`
+	ActorID   *primitive.ObjectID `json:"actor_id,omitempty" bson:"actor_id,omitempty"`
+	Action    ActivityAction      `json:"action" bson:"action"`
+	Changes   []FieldChange       `json:"changes,omitempty" bson:"changes,omitempty"`
+	CreatedAt time.Time           `json:"created_at" bson:"created_at"`
+}
+
+type FieldChange struct {
+	Field  string      `json:"field" bson:"field"`
+	Before interface{} `json:"before" bson:"before"`
+	After  interface{} `json:"after" bson:"after"`
+}
+
+// ActivityDAO is read-only; entries are written by TaskDAO in the same
+// transaction as the change they describe.
+type ActivityDAO struct {
+	collection *tenant.Collection
+}
+
+func NewActivityDAO(db *mongo.Database) *ActivityDAO {
+	return &ActivityDAO{
+		collection: tenant.NewCollection(db, "task_activity"),
+	}
+}
+
+func (dao *ActivityDAO) ListByTask(ctx context.Context, taskID primitive.ObjectID, limit, offset int64) ([]*Activity, error) {
+	opts := options.Find()
+	if limit > 0 {
+		opts.SetLimit(limit)
+	}
+	if offset > 0 {
+		opts.SetSkip(offset)
+	}
+	opts.SetSort(bson.D{{Key: "created_at", Value: -1}, {Key: "_id", Value: -1}})
+
+	cursor, err := dao.collection.Find(ctx, bson.M{"task_id": taskID}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	activities := []*Activity{}
+	if err := cursor.All(ctx, &activities); err != nil {
+		return nil, err
+	}
+
+	return activities, nil
+}
+
+func newActivity(taskID, actorID primitive.ObjectID, action ActivityAction, changes []FieldChange) *Activity {
+	activity := &Activity{
+		ID:        primitive.NewObjectID(),
+		TaskID:    taskID,
+		Action:    action,
+		Changes:   changes,
+		CreatedAt: time.Now(),
+	}
+	if !actorID.IsZero() {
+		activity.ActorID = &actorID
+	}
+	return activity
+}
+
+// diffFields compares the stored document with the values about to be set.
+// Values are compared in their BSON form so that, for example, a JSON
+// []interface{} and a stored primitive.A holding the same strings are equal.
+func diffFields(before bson.M, updates bson.M) []FieldChange {
+	var changes []FieldChange
+	for field, after := range updates {
+		if field == "updated_at" {
+			continue
+		}
+		if !bsonEqual(before[field], after) {
+			changes = append(changes, FieldChange{Field: field, Before: before[field], After: after})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Field < changes[j].Field })
+	return changes
+}
+
+func bsonEqual(a, b interface{}) bool {
+	na, errA := normalizeBSON(a)
+	nb, errB := normalizeBSON(b)
+	return errA == nil && errB == nil && reflect.DeepEqual(na, nb)
+}
+
+// normalizeBSON round-trips a value through BSON and turns documents into
+// maps, so that documents with the same fields in a different order, such as
+// encoded Go maps, compare equal.
+func normalizeBSON(v interface{}) (interface{}, error) {
+	raw, err := bson.Marshal(bson.M{"v": v})
+	if err != nil {
+		return nil, err
+	}
+	var doc bson.D
+	if err := bson.Unmarshal(raw, &doc); err != nil {
+		return nil, err
+	}
+	return documentsToMaps(doc[0].Value), nil
+}
+
+func documentsToMaps(v interface{}) interface{} {
+	switch v := v.(type) {
+	case bson.D:
+		m := make(map[string]interface{}, len(v))
+		for _, e := range v {
+			m[e.Key] = documentsToMaps(e.Value)
+		}
+		return m
+	case bson.M:
+		m := make(map[string]interface{}, len(v))
+		for key, value := range v {
+			m[key] = documentsToMaps(value)
+		}
+		return m
+	case bson.A:
+		a := make([]interface{}, len(v))
+		for i, value := range v {
+			a[i] = documentsToMaps(value)
+		}
+		return a
+	}
+	return v
+}
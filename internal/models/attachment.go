@@ -0,0 +1,99 @@
+package models
+
+import (
+	"context"
+	"time"
+
+	"github.com/grewalsk/task-api/internal/tenant"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type Attachment struct {
+	ID          primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	TenantID    primitive.ObjectID `json:"-" bson:"tenant_id,omitempty"`
+	TaskID      primitive.ObjectID `json:"task_id" bson:"task_id"`
+	UploaderID  primitive.ObjectID `json:"uploader_id" bson:"uploader_id"`
+	Filename    string             `json:"filename" bson:"filename"`
+	ContentType string             `json:"content_type" bson:"content_type"`
+	Size        int64              `json:"size" bson:"size"`
+	Checksum    string             `json:"checksum" bson:"checksum"`
+	StorageKey  string             `json:"-" bson:"storage_key"`
+	CreatedAt   time.Time          `json:"created_at" bson:"created_at"`
+}
+
+type AttachmentDAO struct {
+	collection *tenant.Collection
+}
+
+func NewAttachmentDAO(db *mongo.Database) *AttachmentDAO {
+	return &AttachmentDAO{
+		collection: tenant.NewCollection(db, "attachments"),
+	}
+}
+
+func (dao *AttachmentDAO) Create(ctx context.Context, attachment *Attachment) error {
+	if attachment.ID.IsZero() {
+		attachment.ID = primitive.NewObjectID()
+	}
+	attachment.CreatedAt = time.Now()
+
+	_, err := dao.collection.InsertOne(ctx, attachment)
+	return err
+}
+
+func (dao *AttachmentDAO) GetByID(ctx context.Context, taskID, id primitive.ObjectID) (*Attachment, error) {
+	var attachment Attachment
+	err := dao.collection.FindOne(ctx, bson.M{"_id": id, "task_id": taskID}).Decode(&attachment)
+	if err != nil {
+		return nil, err
+	}
+
+	return &attachment, nil
+}
+
+func (dao *AttachmentDAO) ListByTask(ctx context.Context, taskID primitive.ObjectID) ([]*Attachment, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}, {Key: "_id", Value: 1}})
+
+	cursor, err := dao.collection.Find(ctx, bson.M{"task_id": taskID}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	attachments := []*Attachment{}
+	if err := cursor.All(ctx, &attachments); err != nil {
+		return nil, err
+	}
+
+	return attachments, nil
+}
+
+func (dao *AttachmentDAO) Delete(ctx context.Context, id primitive.ObjectID) error {
+	_, err := dao.collection.DeleteOne(ctx, bson.M{"_id": id})
+	return err
+}
+
+// ReferencedKeys reports which of keys are referenced by an attachment.
+func (dao *AttachmentDAO) ReferencedKeys(ctx context.Context, keys []string) (map[string]bool, error) {
+	opts := options.Find().SetProjection(bson.M{"storage_key": 1})
+
+	cursor, err := dao.collection.Find(ctx, bson.M{"storage_key": bson.M{"$in": keys}}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	referenced := map[string]bool{}
+	for cursor.Next(ctx) {
+		var attachment Attachment
+		if err := cursor.Decode(&attachment); err != nil {
+			return nil, err
+		}
+		referenced[attachment.StorageKey] = true
+	}
+
+	return referenced, cursor.Err()
+}
@@ -0,0 +1,125 @@
+package models
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// TaskStatusHistory is the status of one task at the end of every day on
+// which it changed. Initial is the status before the first recorded change;
+// it is nil for tasks whose status never changed.
+type TaskStatusHistory struct {
+	TaskID    primitive.ObjectID
+	CreatedAt time.Time
+	Status    TaskStatus
+	Initial   *TaskStatus
+	Days      []DailyStatus
+}
+
+// DailyStatus holds the last status a task had on Day, formatted as
+// YYYY-MM-DD in the requested timezone.
+type DailyStatus struct {
+	Day    string     `bson:"day"`
+	Status TaskStatus `bson:"status"`
+}
+
+// StatusAt returns the status the task had at the end of day.
+func (h *TaskStatusHistory) StatusAt(day string) TaskStatus {
+	status := h.Status
+	if h.Initial != nil {
+		status = *h.Initial
+	}
+	for _, d := range h.Days {
+		if d.Day > day {
+			break
+		}
+		status = d.Status
+	}
+	return status
+}
+
+// StatusHistory returns the status history of the active tasks matching
+// query. Status changes are read from the activity log and reduced to one
+// entry per task and day by an aggregation.
+func (dao *TaskDAO) StatusHistory(ctx context.Context, query bson.M, timezone string) ([]*TaskStatusHistory, error) {
+	query["deleted"] = false
+	opts := options.Find().SetProjection(bson.M{"_id": 1, "status": 1, "created_at": 1})
+
+	cursor, err := dao.collection.Find(ctx, query, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	var tasks []*Task
+	if err := cursor.All(ctx, &tasks); err != nil {
+		return nil, err
+	}
+
+	histories := make([]*TaskStatusHistory, len(tasks))
+	byID := make(map[primitive.ObjectID]*TaskStatusHistory, len(tasks))
+	ids := make([]primitive.ObjectID, len(tasks))
+	for i, task := range tasks {
+		histories[i] = &TaskStatusHistory{TaskID: task.ID, CreatedAt: task.CreatedAt, Status: task.Status}
+		byID[task.ID] = histories[i]
+		ids[i] = task.ID
+	}
+
+	if len(ids) == 0 {
+		return histories, nil
+	}
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"task_id": bson.M{"$in": ids}, "changes.field": "status"}}},
+		{{Key: "$unwind", Value: "$changes"}},
+		{{Key: "$match", Value: bson.M{"changes.field": "status"}}},
+		{{Key: "$sort", Value: bson.D{{Key: "created_at", Value: 1}, {Key: "_id", Value: 1}}}},
+		{{Key: "$group", Value: bson.M{
+			"_id": bson.M{
+				"task": "$task_id",
+				"day": bson.M{"$dateToString": bson.M{
+					"format":   "%Y-%m-%d",
+					"date":     "$created_at",
+					"timezone": timezone,
+				}},
+			},
+			"before": bson.M{"$first": "$changes.before"},
+			"status": bson.M{"$last": "$changes.after"},
+		}}},
+		{{Key: "$sort", Value: bson.D{{Key: "_id.day", Value: 1}}}},
+		{{Key: "$group", Value: bson.M{
+			"_id":     "$_id.task",
+			"initial": bson.M{"$first": "$before"},
+			"days":    bson.M{"$push": bson.M{"day": "$_id.day", "status": "$status"}},
+		}}},
+	}
+
+	cursor, err = dao.activity.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var changes []struct {
+		TaskID  primitive.ObjectID `bson:"_id"`
+		Initial TaskStatus         `bson:"initial"`
+		Days    []DailyStatus      `bson:"days"`
+	}
+	if err := cursor.All(ctx, &changes); err != nil {
+		return nil, err
+	}
+
+	for _, c := range changes {
+		if h, ok := byID[c.TaskID]; ok {
+			initial := c.Initial
+			h.Initial = &initial
+			h.Days = c.Days
+		}
+	}
+
+	return histories, nil
+}
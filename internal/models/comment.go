@@ -0,0 +1,177 @@
+package models
+
+import (
+	"context"
+	"time"
+
+	"github.com/grewalsk/task-api/internal/tenant"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type Comment struct {
+	ID        primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	TaskID    primitive.ObjectID `json:"task_id" bson:"task_id"`
+	AuthorID  primitive.ObjectID `json:"author_id" bson:"author_id"`
+	Body      string             `json:"body" bson:"body" validate:"required,min=1,max=5000"`
+	Edits     []CommentEdit      `json:"edits,omitempty" bson:"edits,omitempty"`
+	CreatedAt time.Time          `json:"created_at" bson:"created_at"`
+	UpdatedAt time.Time          `json:"updated_at" bson:"updated_at"`
+	DeletedAt *time.Time         `json:"deleted_at,omitempty" bson:"deleted_at,omitempty"`
+}
+
+type CommentEdit struct {
+	Body     string    `json:"body" bson:"body"`
+	EditedAt time.Time `json:"edited_at" bson:"edited_at"`
+}
+
+type CommentDAO struct {
+	collection *tenant.Collection
+}
+
+func NewCommentDAO(db *mongo.Database) *CommentDAO {
+	return &CommentDAO{
+		collection: tenant.NewCollection(db, "comments"),
+	}
+}
+
+func (dao *CommentDAO) Create(ctx context.Context, comment *Comment) error {
+	comment.ID = primitive.NewObjectID()
+	comment.CreatedAt = time.Now()
+	comment.UpdatedAt = comment.CreatedAt
+
+	_, err := dao.collection.InsertOne(ctx, comment)
+	return err
+}
+
+func (dao *CommentDAO) GetByID(ctx context.Context, taskID, id primitive.ObjectID) (*Comment, error) {
+	var comment Comment
+	filter := bson.M{
+		"_id":        id,
+		"task_id":    taskID,
+		"deleted_at": bson.M{"$exists": false},
+	}
+
+	err := dao.collection.FindOne(ctx, filter).Decode(&comment)
+	if err != nil {
+		return nil, err
+	}
+
+	return &comment, nil
+}
+
+// Update replaces the body and appends the previous body to the edit history.
+func (dao *CommentDAO) Update(ctx context.Context, comment *Comment, body string) error {
+	now := time.Now()
+	filter := bson.M{
+		"_id":        comment.ID,
+		"body":       comment.Body,
+		"deleted_at": bson.M{"$exists": false},
+	}
+
+	update := bson.M{
+		"$set": bson.M{
+			"body":       body,
+			"updated_at": now,
+		},
+		"$push": bson.M{
+			"edits": CommentEdit{Body: comment.Body, EditedAt: now},
+		},
+	}
+
+	result, err := dao.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+
+	return nil
+}
+
+func (dao *CommentDAO) Delete(ctx context.Context, id primitive.ObjectID) error {
+	filter := bson.M{
+		"_id":        id,
+		"deleted_at": bson.M{"$exists": false},
+	}
+
+	update := bson.M{
+		"$set": bson.M{
+			"deleted_at": time.Now(),
+			"updated_at": time.Now(),
+		},
+	}
+
+	_, err := dao.collection.UpdateOne(ctx, filter, update)
+	return err
+}
+
+func (dao *CommentDAO) ListByTask(ctx context.Context, taskID primitive.ObjectID, limit, offset int64) ([]*Comment, error) {
+	query := bson.M{
+		"task_id":    taskID,
+		"deleted_at": bson.M{"$exists": false},
+	}
+
+	opts := options.Find()
+	if limit > 0 {
+		opts.SetLimit(limit)
+	}
+	if offset > 0 {
+		opts.SetSkip(offset)
+	}
+	opts.SetSort(bson.D{{Key: "created_at", Value: 1}, {Key: "_id", Value: 1}})
+
+	cursor, err := dao.collection.Find(ctx, query, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	comments := []*Comment{}
+	if err := cursor.All(ctx, &comments); err != nil {
+		return nil, err
+	}
+
+	return comments, nil
+}
+
+// DeleteByTask permanently removes all comments of a purged task.
+func (dao *CommentDAO) DeleteByTask(ctx context.Context, taskID primitive.ObjectID) error {
+	_, err := dao.collection.DeleteMany(ctx, bson.M{"task_id": taskID})
+	return err
+}
+
+// CopyToTask duplicates the visible comments of one task onto another,
+// keeping their authors, edit history and timestamps.
+func (dao *CommentDAO) CopyToTask(ctx context.Context, fromTaskID, toTaskID primitive.ObjectID) error {
+	filter := bson.M{
+		"task_id":    fromTaskID,
+		"deleted_at": bson.M{"$exists": false},
+	}
+
+	cursor, err := dao.collection.Find(ctx, filter)
+	if err != nil {
+		return err
+	}
+
+	var comments []*Comment
+	if err := cursor.All(ctx, &comments); err != nil {
+		return err
+	}
+	if len(comments) == 0 {
+		return nil
+	}
+
+	docs := make([]interface{}, len(comments))
+	for i, comment := range comments {
+		comment.ID = primitive.NewObjectID()
+		comment.TaskID = toTaskID
+		docs[i] = comment
+	}
+
+	_, err = dao.collection.InsertMany(ctx, docs)
+	return err
+}
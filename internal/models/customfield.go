@@ -0,0 +1,335 @@
+package models
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/grewalsk/task-api/internal/tenant"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type CustomFieldType string
+
+const (
+	CustomFieldText        CustomFieldType = "text"
+	CustomFieldNumber      CustomFieldType = "number"
+	CustomFieldDate        CustomFieldType = "date"
+	CustomFieldSelect      CustomFieldType = "select"
+	CustomFieldMultiSelect CustomFieldType = "multi_select"
+	CustomFieldUser        CustomFieldType = "user"
+)
+
+const maxCustomFieldTextLength = 1000
+
+var customFieldKeyPattern = regexp.MustCompile(`^[a-z][a-z0-9_]{0,49}$`)
+
+func ValidCustomFieldKey(key string) bool {
+	return customFieldKeyPattern.MatchString(key)
+}
+
+// CustomFieldDefinition describes one typed custom field. Definitions without
+// a ProjectID apply to every task.
+type CustomFieldDefinition struct {
+	ID        primitive.ObjectID  `json:"id" bson:"_id,omitempty"`
+	ProjectID *primitive.ObjectID `json:"project_id,omitempty" bson:"project_id,omitempty"`
+	Key       string              `json:"key" bson:"key" validate:"required,min=1,max=50"`
+	Name      string              `json:"name" bson:"name" validate:"required,min=1,max=100"`
+	Type      CustomFieldType     `json:"type" bson:"type" validate:"required,oneof=text number date select multi_select user"`
+	Options   []string            `json:"options,omitempty" bson:"options,omitempty" validate:"max=100,dive,min=1,max=100"`
+	Required  bool                `json:"required" bson:"required"`
+	CreatedAt time.Time           `json:"created_at" bson:"created_at"`
+	UpdatedAt time.Time           `json:"updated_at" bson:"updated_at"`
+}
+
+type CustomFieldDAO struct {
+	collection *tenant.Collection
+	tasks      *tenant.Collection
+}
+
+func NewCustomFieldDAO(db *mongo.Database) *CustomFieldDAO {
+	return &CustomFieldDAO{
+		collection: tenant.NewCollection(db, "custom_fields"),
+		tasks:      tenant.NewCollection(db, "tasks"),
+	}
+}
+
+func (dao *CustomFieldDAO) Create(ctx context.Context, def *CustomFieldDefinition) error {
+	def.ID = primitive.NewObjectID()
+	def.CreatedAt = time.Now()
+	def.UpdatedAt = def.CreatedAt
+
+	_, err := dao.collection.InsertOne(ctx, def)
+	return err
+}
+
+func (dao *CustomFieldDAO) GetByID(ctx context.Context, id primitive.ObjectID) (*CustomFieldDefinition, error) {
+	var def CustomFieldDefinition
+	if err := dao.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&def); err != nil {
+		return nil, err
+	}
+
+	return &def, nil
+}
+
+func (dao *CustomFieldDAO) Update(ctx context.Context, id primitive.ObjectID, updates bson.M) error {
+	updates["updated_at"] = time.Now()
+
+	result, err := dao.collection.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": updates})
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+
+	return nil
+}
+
+// Delete removes the definition and the values stored for it on tasks in its
+// scope.
+func (dao *CustomFieldDAO) Delete(ctx context.Context, def *CustomFieldDefinition) error {
+	if _, err := dao.collection.DeleteOne(ctx, bson.M{"_id": def.ID}); err != nil {
+		return err
+	}
+
+	filter := bson.M{"custom_fields." + def.Key: bson.M{"$exists": true}}
+	if def.ProjectID != nil {
+		filter["project_id"] = *def.ProjectID
+	}
+
+	_, err := dao.tasks.UpdateMany(ctx, filter, bson.M{"$unset": bson.M{"custom_fields." + def.Key: ""}, "$inc": bson.M{"version": 1}})
+	return err
+}
+
+// OptionsInUse returns those of the given options that tasks in the
+// definition's scope, including those in the trash, hold as a value.
+func (dao *CustomFieldDAO) OptionsInUse(ctx context.Context, def *CustomFieldDefinition, candidates []string) ([]string, error) {
+	var used []string
+	for _, option := range candidates {
+		filter := bson.M{"custom_fields." + def.Key: option}
+		if def.ProjectID != nil {
+			filter["project_id"] = *def.ProjectID
+		}
+
+		count, err := dao.tasks.CountDocuments(ctx, filter, options.Count().SetLimit(1))
+		if err != nil {
+			return nil, err
+		}
+		if count > 0 {
+			used = append(used, option)
+		}
+	}
+	return used, nil
+}
+
+// KeyInUse reports whether key would clash with an existing definition that
+// applies to the same tasks.
+func (dao *CustomFieldDAO) KeyInUse(ctx context.Context, key string, projectID *primitive.ObjectID) (bool, error) {
+	filter := bson.M{"key": key}
+	if projectID != nil {
+		filter["$or"] = bson.A{
+			bson.M{"project_id": bson.M{"$exists": false}},
+			bson.M{"project_id": *projectID},
+		}
+	}
+
+	count, err := dao.collection.CountDocuments(ctx, filter, options.Count().SetLimit(1))
+	return count > 0, err
+}
+
+// List returns the definitions of one project, or the global ones when
+// projectID is nil.
+func (dao *CustomFieldDAO) List(ctx context.Context, projectID *primitive.ObjectID) ([]*CustomFieldDefinition, error) {
+	filter := bson.M{"project_id": bson.M{"$exists": false}}
+	if projectID != nil {
+		filter = bson.M{"project_id": *projectID}
+	}
+
+	return dao.find(ctx, filter)
+}
+
+// ListApplicable returns the global definitions plus those of the project.
+func (dao *CustomFieldDAO) ListApplicable(ctx context.Context, projectID *primitive.ObjectID) ([]*CustomFieldDefinition, error) {
+	filter := bson.M{"project_id": bson.M{"$exists": false}}
+	if projectID != nil {
+		filter = bson.M{"$or": bson.A{
+			bson.M{"project_id": bson.M{"$exists": false}},
+			bson.M{"project_id": *projectID},
+		}}
+	}
+
+	return dao.find(ctx, filter)
+}
+
+func (dao *CustomFieldDAO) find(ctx context.Context, filter bson.M) ([]*CustomFieldDefinition, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "key", Value: 1}})
+
+	cursor, err := dao.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	defs := []*CustomFieldDefinition{}
+	if err := cursor.All(ctx, &defs); err != nil {
+		return nil, err
+	}
+
+	return defs, nil
+}
+
+// NormalizeCustomFields checks the values in patch against their definitions
+// and merges them over existing, which holds already stored values. A nil
+// value in patch clears the field. Patch values are converted to their stored
+// representation: numbers as float64, dates as time.Time, users as ObjectIDs
+// and multi-selects as []string. Unknown keys and missing required fields
+// are errors.
+func NormalizeCustomFields(defs []*CustomFieldDefinition, patch, existing map[string]interface{}) (map[string]interface{}, error) {
+	byKey := make(map[string]*CustomFieldDefinition, len(defs))
+	for _, def := range defs {
+		byKey[def.Key] = def
+	}
+
+	merged := make(map[string]interface{}, len(existing)+len(patch))
+	for key, value := range existing {
+		if _, ok := byKey[key]; ok {
+			merged[key] = value
+		}
+	}
+
+	var problems []string
+	for key, value := range patch {
+		def, ok := byKey[key]
+		if !ok {
+			problems = append(problems, fmt.Sprintf("custom_fields.%s is not defined", key))
+			continue
+		}
+		if value == nil {
+			delete(merged, key)
+			continue
+		}
+
+		v, err := def.Normalize(value)
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("custom_fields.%s %s", key, err))
+			continue
+		}
+		merged[key] = v
+	}
+
+	for _, def := range defs {
+		if _, ok := merged[def.Key]; def.Required && !ok {
+			problems = append(problems, fmt.Sprintf("custom_fields.%s is required", def.Key))
+		}
+	}
+
+	if len(problems) > 0 {
+		sort.Strings(problems)
+		return nil, fmt.Errorf("%s", strings.Join(problems, ", "))
+	}
+
+	if len(merged) == 0 {
+		return nil, nil
+	}
+	return merged, nil
+}
+
+// Normalize converts a single JSON-decoded value, or a query string value,
+// for this field.
+func (def *CustomFieldDefinition) Normalize(value interface{}) (interface{}, error) {
+	switch def.Type {
+	case CustomFieldText:
+		s, ok := value.(string)
+		if !ok {
+			return nil, fmt.Errorf("must be a string")
+		}
+		if len(s) > maxCustomFieldTextLength {
+			return nil, fmt.Errorf("must be at most %d characters", maxCustomFieldTextLength)
+		}
+		return s, nil
+	case CustomFieldNumber:
+		switch n := value.(type) {
+		case float64:
+			return n, nil
+		case int:
+			return float64(n), nil
+		case int64:
+			return float64(n), nil
+		case string:
+			if f, err := strconv.ParseFloat(n, 64); err == nil {
+				return f, nil
+			}
+		}
+		return nil, fmt.Errorf("must be a number")
+	case CustomFieldDate:
+		s, ok := value.(string)
+		if !ok {
+			return nil, fmt.Errorf("must be a date")
+		}
+		for _, layout := range []string{time.RFC3339, time.DateOnly} {
+			if t, err := time.Parse(layout, s); err == nil {
+				return t.UTC(), nil
+			}
+		}
+		return nil, fmt.Errorf("must be a date in YYYY-MM-DD or RFC 3339 format")
+	case CustomFieldSelect:
+		s, ok := value.(string)
+		if !ok || !def.hasOption(s) {
+			return nil, fmt.Errorf("must be one of %s", strings.Join(def.Options, ", "))
+		}
+		return s, nil
+	case CustomFieldMultiSelect:
+		var items []interface{}
+		switch v := value.(type) {
+		case []interface{}:
+			items = v
+		case string:
+			items = []interface{}{v}
+		default:
+			return nil, fmt.Errorf("must be a list of options")
+		}
+
+		selected := []string{}
+		seen := map[string]bool{}
+		for _, item := range items {
+			s, ok := item.(string)
+			if !ok || !def.hasOption(s) {
+				return nil, fmt.Errorf("must only contain %s", strings.Join(def.Options, ", "))
+			}
+			if !seen[s] {
+				seen[s] = true
+				selected = append(selected, s)
+			}
+		}
+		return selected, nil
+	case CustomFieldUser:
+		s, ok := value.(string)
+		if !ok {
+			return nil, fmt.Errorf("must be a user ID")
+		}
+		id, err := primitive.ObjectIDFromHex(s)
+		if err != nil {
+			return nil, fmt.Errorf("must be a user ID")
+		}
+		return id, nil
+	}
+
+	return nil, fmt.Errorf("has unknown type %q", def.Type)
+}
+
+func (def *CustomFieldDefinition) hasOption(option string) bool {
+	for _, o := range def.Options {
+		if o == option {
+			return true
+		}
+	}
+	return false
+}
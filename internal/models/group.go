@@ -0,0 +1,134 @@
+package models
+
+import (
+	"context"
+	"time"
+
+	"github.com/grewalsk/task-api/internal/tenant"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Group is a named set of users that tasks can be shared with.
+type Group struct {
+	ID        primitive.ObjectID   `json:"id" bson:"_id,omitempty"`
+	Name      string               `json:"name" bson:"name" validate:"required,min=1,max=100"`
+	MemberIDs []primitive.ObjectID `json:"member_ids" bson:"member_ids" validate:"max=500"`
+	CreatedBy primitive.ObjectID   `json:"created_by" bson:"created_by"`
+	CreatedAt time.Time            `json:"created_at" bson:"created_at"`
+	UpdatedAt time.Time            `json:"updated_at" bson:"updated_at"`
+}
+
+type GroupDAO struct {
+	collection *tenant.Collection
+	tasks      *tenant.Collection
+}
+
+func NewGroupDAO(db *mongo.Database) *GroupDAO {
+	return &GroupDAO{
+		collection: tenant.NewCollection(db, "groups"),
+		tasks:      tenant.NewCollection(db, "tasks"),
+	}
+}
+
+func (dao *GroupDAO) Create(ctx context.Context, group *Group) error {
+	group.ID = primitive.NewObjectID()
+	group.CreatedAt = time.Now()
+	group.UpdatedAt = group.CreatedAt
+	if group.MemberIDs == nil {
+		group.MemberIDs = []primitive.ObjectID{}
+	}
+
+	_, err := dao.collection.InsertOne(ctx, group)
+	return err
+}
+
+func (dao *GroupDAO) GetByID(ctx context.Context, id primitive.ObjectID) (*Group, error) {
+	var group Group
+	if err := dao.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&group); err != nil {
+		return nil, err
+	}
+
+	return &group, nil
+}
+
+func (dao *GroupDAO) Update(ctx context.Context, id primitive.ObjectID, updates bson.M) error {
+	updates["updated_at"] = time.Now()
+
+	result, err := dao.collection.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": updates})
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+
+	return nil
+}
+
+// Delete removes the group and every task share granted to it.
+func (dao *GroupDAO) Delete(ctx context.Context, id primitive.ObjectID) error {
+	result, err := dao.collection.DeleteOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+
+	_, err = dao.tasks.UpdateMany(ctx,
+		bson.M{"shares.principal_id": id},
+		bson.M{
+			"$pull": bson.M{"shares": bson.M{"principal_type": SharePrincipalGroup, "principal_id": id}},
+			"$inc":  bson.M{"version": 1},
+		},
+	)
+	return err
+}
+
+func (dao *GroupDAO) List(ctx context.Context, limit, offset int64) ([]*Group, error) {
+	opts := options.Find().
+		SetSort(bson.D{{Key: "name", Value: 1}}).
+		SetLimit(limit).
+		SetSkip(offset)
+
+	cursor, err := dao.collection.Find(ctx, bson.M{}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	groups := []*Group{}
+	if err := cursor.All(ctx, &groups); err != nil {
+		return nil, err
+	}
+
+	return groups, nil
+}
+
+// ListIDsForMember returns the IDs of every group the user belongs to.
+func (dao *GroupDAO) ListIDsForMember(ctx context.Context, userID primitive.ObjectID) ([]primitive.ObjectID, error) {
+	opts := options.Find().SetProjection(bson.M{"_id": 1})
+
+	cursor, err := dao.collection.Find(ctx, bson.M{"member_ids": userID}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var docs []struct {
+		ID primitive.ObjectID `bson:"_id"`
+	}
+	if err := cursor.All(ctx, &docs); err != nil {
+		return nil, err
+	}
+
+	ids := make([]primitive.ObjectID, len(docs))
+	for i, doc := range docs {
+		ids[i] = doc.ID
+	}
+
+	return ids, nil
+}
@@ -0,0 +1,149 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/grewalsk/task-api/internal/tenant"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var (
+	ErrIdempotencyKeyInUse = errors.New("idempotency key is held by another request")
+	ErrIdempotencyKeyLost  = errors.New("idempotency key was taken over by another request")
+)
+
+// IdempotencyRecord remembers a request made with an Idempotency-Key and,
+// once it has completed, its fingerprint and response. While Completed is
+// false the request holding Token owns it until LockedUntil; each
+// acquisition gets a new token, so a request whose lock expired and was
+// taken over can no longer change the record. Records are removed by a TTL
+// index once ExpiresAt passes.
+type IdempotencyRecord struct {
+	ID          primitive.ObjectID  `bson:"_id,omitempty"`
+	UserID      primitive.ObjectID  `bson:"user_id"`
+	Key         string              `bson:"key"`
+	Fingerprint string              `bson:"fingerprint,omitempty"`
+	Token       primitive.ObjectID  `bson:"token"`
+	Completed   bool                `bson:"completed"`
+	LockedUntil time.Time           `bson:"locked_until"`
+	Status      int                 `bson:"status,omitempty"`
+	Header      map[string][]string `bson:"header,omitempty"`
+	Body        []byte              `bson:"body,omitempty"`
+	CreatedAt   time.Time           `bson:"created_at"`
+	ExpiresAt   time.Time           `bson:"expires_at"`
+}
+
+type IdempotencyDAO struct {
+	collection *tenant.Collection
+}
+
+func NewIdempotencyDAO(db *mongo.Database) *IdempotencyDAO {
+	return &IdempotencyDAO{
+		collection: tenant.NewCollection(db, "idempotency_keys"),
+	}
+}
+
+// Acquire claims the user's key for a request, reporting whether it did. A
+// completed record is returned unclaimed. An incomplete record whose lock
+// has expired, left by a request that never finished, is taken over; one
+// that is still locked yields ErrIdempotencyKeyInUse.
+func (dao *IdempotencyDAO) Acquire(ctx context.Context, record *IdempotencyRecord, lock time.Duration) (*IdempotencyRecord, bool, error) {
+	now := time.Now()
+	record.ID = primitive.NewObjectID()
+	record.Token = primitive.NewObjectID()
+	record.Completed = false
+	record.LockedUntil = now.Add(lock)
+	record.CreatedAt = now
+
+	_, err := dao.collection.InsertOne(ctx, record)
+	if err == nil {
+		return record, true, nil
+	}
+	if !mongo.IsDuplicateKeyError(err) {
+		return nil, false, err
+	}
+
+	var existing IdempotencyRecord
+	if err := dao.collection.FindOne(ctx, bson.M{"user_id": record.UserID, "key": record.Key}).Decode(&existing); err != nil {
+		return nil, false, err
+	}
+	if existing.Completed {
+		return &existing, false, nil
+	}
+	if existing.LockedUntil.After(now) {
+		return nil, false, ErrIdempotencyKeyInUse
+	}
+
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	err = dao.collection.FindOneAndUpdate(ctx,
+		bson.M{"_id": existing.ID, "completed": false, "locked_until": existing.LockedUntil},
+		bson.M{"$set": bson.M{"token": record.Token, "locked_until": record.LockedUntil}},
+		opts,
+	).Decode(&existing)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, false, ErrIdempotencyKeyInUse
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	return &existing, true, nil
+}
+
+// Complete stores the fingerprint and response of the request holding the
+// record. It fails with ErrIdempotencyKeyLost if another request has taken
+// the record over.
+func (dao *IdempotencyDAO) Complete(ctx context.Context, record *IdempotencyRecord, fingerprint string, status int, header map[string][]string, body []byte) error {
+	result, err := dao.collection.UpdateOne(ctx, held(record), bson.M{
+		"$set": bson.M{
+			"completed":   true,
+			"fingerprint": fingerprint,
+			"status":      status,
+			"header":      header,
+			"body":        body,
+		},
+	})
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrIdempotencyKeyLost
+	}
+	return nil
+}
+
+// Renew extends the lock of the request holding the record.
+func (dao *IdempotencyDAO) Renew(ctx context.Context, record *IdempotencyRecord, lock time.Duration) error {
+	result, err := dao.collection.UpdateOne(ctx, held(record), bson.M{
+		"$set": bson.M{"locked_until": time.Now().Add(lock)},
+	})
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrIdempotencyKeyLost
+	}
+	return nil
+}
+
+// Release forgets an incomplete record so that the key can be retried.
+func (dao *IdempotencyDAO) Release(ctx context.Context, record *IdempotencyRecord) error {
+	result, err := dao.collection.DeleteOne(ctx, held(record))
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return ErrIdempotencyKeyLost
+	}
+	return nil
+}
+
+// held matches the record only while the acquisition that returned it still
+// holds it.
+func held(record *IdempotencyRecord) bson.M {
+	return bson.M{"_id": record.ID, "token": record.Token, "completed": false}
+}
@@ -0,0 +1,88 @@
+package models
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// CompletionGroup holds the lead and cycle times, in milliseconds, of the
+// tasks sharing one grouping key. Key is nil for the overall group and for
+// tasks without a value for the grouping field. Cycle times are only known
+// for tasks that were started before being completed.
+type CompletionGroup struct {
+	Key        interface{} `bson:"_id"`
+	LeadTimes  []int64     `bson:"lead"`
+	CycleTimes []int64     `bson:"cycle"`
+}
+
+// CompletionTimes collects the lead time (created to completed) and cycle
+// time (started to completed) of the active tasks matching filter that were
+// completed in [from, to). Tasks are grouped by groupField, which must be
+// owner_id, project_id or assignee_ids; a task with several assignees counts
+// towards each of them. The overall group counts every task once.
+func (dao *TaskDAO) CompletionTimes(ctx context.Context, filter TaskFilter, from, to time.Time, groupField string) (*CompletionGroup, []*CompletionGroup, error) {
+	query := bson.M{
+		"deleted":      false,
+		"status":       StatusDone,
+		"completed_at": bson.M{"$gte": from, "$lt": to},
+	}
+	filter.apply(query)
+
+	group := func(key interface{}) bson.D {
+		return bson.D{{Key: "$group", Value: bson.M{
+			"_id":   key,
+			"lead":  bson.M{"$push": "$lead"},
+			"cycle": bson.M{"$push": "$cycle"},
+		}}}
+	}
+	grouping := bson.A{}
+	if groupField == "assignee_ids" {
+		grouping = append(grouping, bson.D{{Key: "$unwind", Value: "$key"}})
+	}
+	grouping = append(grouping, group("$key"), bson.D{{Key: "$sort", Value: bson.M{"_id": 1}}})
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: query}},
+		{{Key: "$project", Value: bson.M{
+			"key":  "$" + groupField,
+			"lead": bson.M{"$subtract": bson.A{"$completed_at", "$created_at"}},
+			"cycle": bson.M{"$cond": bson.A{
+				bson.M{"$eq": bson.A{bson.M{"$type": "$started_at"}, "date"}},
+				bson.M{"$subtract": bson.A{"$completed_at", "$started_at"}},
+				"$$REMOVE",
+			}},
+		}}},
+		{{Key: "$facet", Value: bson.M{
+			"overall": bson.A{group(nil)},
+			"groups":  grouping,
+		}}},
+	}
+
+	cursor, err := dao.collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var result struct {
+		Overall []*CompletionGroup `bson:"overall"`
+		Groups  []*CompletionGroup `bson:"groups"`
+	}
+	if cursor.Next(ctx) {
+		if err := cursor.Decode(&result); err != nil {
+			return nil, nil, err
+		}
+	}
+	if err := cursor.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	overall := &CompletionGroup{}
+	if len(result.Overall) > 0 {
+		overall = result.Overall[0]
+	}
+	return overall, result.Groups, nil
+}
@@ -0,0 +1,100 @@
+package models
+
+import (
+	"context"
+	"time"
+
+	"github.com/grewalsk/task-api/internal/tenant"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Milestone is a project target date that tasks can be grouped under,
+// independently of sprints.
+type Milestone struct {
+	ID          primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	ProjectID   primitive.ObjectID `json:"project_id" bson:"project_id"`
+	Name        string             `json:"name" bson:"name" validate:"required,min=1,max=100"`
+	Description string             `json:"description" bson:"description" validate:"max=1000"`
+	DueDate     time.Time          `json:"due_date" bson:"due_date" validate:"required"`
+	CreatedBy   primitive.ObjectID `json:"created_by" bson:"created_by"`
+	CreatedAt   time.Time          `json:"created_at" bson:"created_at"`
+	UpdatedAt   time.Time          `json:"updated_at" bson:"updated_at"`
+}
+
+type MilestoneDAO struct {
+	collection *tenant.Collection
+	tasks      *tenant.Collection
+}
+
+func NewMilestoneDAO(db *mongo.Database) *MilestoneDAO {
+	return &MilestoneDAO{
+		collection: tenant.NewCollection(db, "milestones"),
+		tasks:      tenant.NewCollection(db, "tasks"),
+	}
+}
+
+func (dao *MilestoneDAO) Create(ctx context.Context, milestone *Milestone) error {
+	milestone.ID = primitive.NewObjectID()
+	milestone.CreatedAt = time.Now()
+	milestone.UpdatedAt = milestone.CreatedAt
+
+	_, err := dao.collection.InsertOne(ctx, milestone)
+	return err
+}
+
+func (dao *MilestoneDAO) GetByID(ctx context.Context, projectID, id primitive.ObjectID) (*Milestone, error) {
+	var milestone Milestone
+	if err := dao.collection.FindOne(ctx, bson.M{"_id": id, "project_id": projectID}).Decode(&milestone); err != nil {
+		return nil, err
+	}
+
+	return &milestone, nil
+}
+
+func (dao *MilestoneDAO) Update(ctx context.Context, id primitive.ObjectID, updates bson.M) error {
+	updates["updated_at"] = time.Now()
+
+	result, err := dao.collection.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": updates})
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+
+	return nil
+}
+
+// Delete removes the milestone and detaches its tasks.
+func (dao *MilestoneDAO) Delete(ctx context.Context, id primitive.ObjectID) error {
+	result, err := dao.collection.DeleteOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+
+	_, err = dao.tasks.UpdateMany(ctx, bson.M{"milestone_id": id}, bson.M{"$unset": bson.M{"milestone_id": ""}, "$inc": bson.M{"version": 1}})
+	return err
+}
+
+func (dao *MilestoneDAO) List(ctx context.Context, projectID primitive.ObjectID) ([]*Milestone, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "due_date", Value: 1}, {Key: "_id", Value: 1}})
+
+	cursor, err := dao.collection.Find(ctx, bson.M{"project_id": projectID}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	milestones := []*Milestone{}
+	if err := cursor.All(ctx, &milestones); err != nil {
+		return nil, err
+	}
+
+	return milestones, nil
+}
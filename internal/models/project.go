@@ -0,0 +1,266 @@
+package models
+
+import (
+	"context"
+	"regexp"
+	"time"
+
+	"github.com/grewalsk/task-api/internal/tenant"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type ProjectRole string
+
+const (
+	ProjectRoleOwner  ProjectRole = "owner"
+	ProjectRoleMember ProjectRole = "member"
+)
+
+var projectKeyPattern = regexp.MustCompile(`^[A-Z][A-Z0-9]{1,9}$`)
+
+func ValidProjectKey(key string) bool {
+	return projectKeyPattern.MatchString(key)
+}
+
+type Project struct {
+	ID          primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	Key         string             `json:"key" bson:"key" validate:"required,min=2,max=10"`
+	Name        string             `json:"name" bson:"name" validate:"required,min=1,max=100"`
+	Description string             `json:"description" bson:"description" validate:"max=1000"`
+	Members     []ProjectMember    `json:"members" bson:"members"`
+	Archived    bool               `json:"archived" bson:"archived"`
+	ArchivedAt  *time.Time         `json:"archived_at,omitempty" bson:"archived_at,omitempty"`
+	CreatedBy   primitive.ObjectID `json:"created_by" bson:"created_by"`
+	CreatedAt   time.Time          `json:"created_at" bson:"created_at"`
+	UpdatedAt   time.Time          `json:"updated_at" bson:"updated_at"`
+}
+
+type ProjectMember struct {
+	UserID  primitive.ObjectID `json:"user_id" bson:"user_id" validate:"required"`
+	Role    ProjectRole        `json:"role" bson:"role" validate:"required,oneof=owner member" openapi:"default=member"`
+	AddedAt time.Time          `json:"added_at" bson:"added_at"`
+}
+
+func (p *Project) Member(userID primitive.ObjectID) (*ProjectMember, bool) {
+	for i := range p.Members {
+		if p.Members[i].UserID == userID {
+			return &p.Members[i], true
+		}
+	}
+	return nil, false
+}
+
+func (p *Project) IsOwner(userID primitive.ObjectID) bool {
+	member, ok := p.Member(userID)
+	return ok && member.Role == ProjectRoleOwner
+}
+
+// LastOwner reports whether userID is the only owner left in the project.
+func (p *Project) LastOwner(userID primitive.ObjectID) bool {
+	return p.IsOwner(userID) && p.ownerCount() == 1
+}
+
+func (p *Project) ownerCount() int {
+	count := 0
+	for _, member := range p.Members {
+		if member.Role == ProjectRoleOwner {
+			count++
+		}
+	}
+	return count
+}
+
+type ProjectDAO struct {
+	collection *tenant.Collection
+	tasks      *tenant.Collection
+}
+
+func NewProjectDAO(db *mongo.Database) *ProjectDAO {
+	return &ProjectDAO{
+		collection: tenant.NewCollection(db, "projects"),
+		tasks:      tenant.NewCollection(db, "tasks"),
+	}
+}
+
+func (dao *ProjectDAO) Create(ctx context.Context, project *Project) error {
+	project.ID = primitive.NewObjectID()
+	project.CreatedAt = time.Now()
+	project.UpdatedAt = project.CreatedAt
+	project.Archived = false
+	project.ArchivedAt = nil
+
+	_, err := dao.collection.InsertOne(ctx, project)
+	return err
+}
+
+func (dao *ProjectDAO) GetByID(ctx context.Context, id primitive.ObjectID) (*Project, error) {
+	var project Project
+	if err := dao.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&project); err != nil {
+		return nil, err
+	}
+
+	return &project, nil
+}
+
+func (dao *ProjectDAO) Update(ctx context.Context, id primitive.ObjectID, updates bson.M) error {
+	updates["updated_at"] = time.Now()
+
+	result, err := dao.collection.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": updates})
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+
+	return nil
+}
+
+// SetArchived archives or unarchives a project. Archived projects keep their
+// tasks but are hidden from listings and accept no new tasks.
+func (dao *ProjectDAO) SetArchived(ctx context.Context, id primitive.ObjectID, archived bool) error {
+	now := time.Now()
+	update := bson.M{
+		"$set": bson.M{"archived": archived, "archived_at": now, "updated_at": now},
+	}
+	if !archived {
+		update = bson.M{
+			"$set":   bson.M{"archived": false, "updated_at": now},
+			"$unset": bson.M{"archived_at": ""},
+		}
+	}
+
+	result, err := dao.collection.UpdateOne(ctx, bson.M{"_id": id}, update)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+
+	return nil
+}
+
+// PutMember adds a member or changes the role of an existing one.
+func (dao *ProjectDAO) PutMember(ctx context.Context, id primitive.ObjectID, member ProjectMember) error {
+	now := time.Now()
+
+	result, err := dao.collection.UpdateOne(ctx,
+		bson.M{"_id": id, "members.user_id": member.UserID},
+		bson.M{"$set": bson.M{"members.$.role": member.Role, "updated_at": now}},
+	)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount > 0 {
+		return nil
+	}
+
+	member.AddedAt = now
+	result, err = dao.collection.UpdateOne(ctx,
+		bson.M{"_id": id, "members.user_id": bson.M{"$ne": member.UserID}},
+		bson.M{
+			"$push": bson.M{"members": member},
+			"$set":  bson.M{"updated_at": now},
+		},
+	)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+
+	return nil
+}
+
+func (dao *ProjectDAO) RemoveMember(ctx context.Context, id, userID primitive.ObjectID) error {
+	result, err := dao.collection.UpdateOne(ctx,
+		bson.M{"_id": id, "members.user_id": userID},
+		bson.M{
+			"$pull": bson.M{"members": bson.M{"user_id": userID}},
+			"$set":  bson.M{"updated_at": time.Now()},
+		},
+	)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+
+	return nil
+}
+
+// Delete removes a project that no longer holds any tasks, including trashed
+// ones, so restoring a task can never leave it pointing at a missing project.
+func (dao *ProjectDAO) Delete(ctx context.Context, id primitive.ObjectID) (bool, error) {
+	count, err := dao.tasks.CountDocuments(ctx, bson.M{"project_id": id}, options.Count().SetLimit(1))
+	if err != nil {
+		return false, err
+	}
+	if count > 0 {
+		return false, nil
+	}
+
+	_, err = dao.collection.DeleteOne(ctx, bson.M{"_id": id})
+	return err == nil, err
+}
+
+// List returns projects ordered by key. A nil memberID lists every project.
+func (dao *ProjectDAO) List(ctx context.Context, memberID *primitive.ObjectID, includeArchived bool, limit, offset int64) ([]*Project, error) {
+	filter := bson.M{}
+	if memberID != nil {
+		filter["members.user_id"] = *memberID
+	}
+	if !includeArchived {
+		filter["archived"] = false
+	}
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: "key", Value: 1}}).
+		SetLimit(limit).
+		SetSkip(offset)
+
+	cursor, err := dao.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	projects := []*Project{}
+	if err := cursor.All(ctx, &projects); err != nil {
+		return nil, err
+	}
+
+	return projects, nil
+}
+
+// ListIDsForMember returns the IDs of every project the user belongs to,
+// archived ones included, for task visibility checks.
+func (dao *ProjectDAO) ListIDsForMember(ctx context.Context, userID primitive.ObjectID) ([]primitive.ObjectID, error) {
+	opts := options.Find().SetProjection(bson.M{"_id": 1})
+
+	cursor, err := dao.collection.Find(ctx, bson.M{"members.user_id": userID}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var docs []struct {
+		ID primitive.ObjectID `bson:"_id"`
+	}
+	if err := cursor.All(ctx, &docs); err != nil {
+		return nil, err
+	}
+
+	ids := make([]primitive.ObjectID, len(docs))
+	for i, doc := range docs {
+		ids[i] = doc.ID
+	}
+
+	return ids, nil
+}
@@ -0,0 +1,51 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type SharePrincipal string
+
+const (
+	SharePrincipalUser  SharePrincipal = "user"
+	SharePrincipalGroup SharePrincipal = "group"
+)
+
+// SharePermission is the level of access a share grants. Each level includes
+// the ones before it: read < comment < edit.
+type SharePermission string
+
+const (
+	SharePermissionRead    SharePermission = "read"
+	SharePermissionComment SharePermission = "comment"
+	SharePermissionEdit    SharePermission = "edit"
+)
+
+// TaskShare grants a user, or every member of a group, access to one task.
+type TaskShare struct {
+	PrincipalType SharePrincipal     `json:"principal_type" bson:"principal_type" validate:"required,oneof=user group"`
+	PrincipalID   primitive.ObjectID `json:"principal_id" bson:"principal_id" validate:"required"`
+	Permission    SharePermission    `json:"permission" bson:"permission" validate:"required,oneof=read comment edit"`
+	GrantedBy     primitive.ObjectID `json:"granted_by" bson:"granted_by"`
+	GrantedAt     time.Time          `json:"granted_at" bson:"granted_at"`
+}
+
+// WithShare returns a copy of shares in which the grant for share's principal
+// is added or replaced.
+func WithShare(shares []TaskShare, share TaskShare) []TaskShare {
+	result := WithoutShare(shares, share.PrincipalType, share.PrincipalID)
+	return append(result, share)
+}
+
+// WithoutShare returns a copy of shares without the grant for the principal.
+func WithoutShare(shares []TaskShare, principalType SharePrincipal, principalID primitive.ObjectID) []TaskShare {
+	result := make([]TaskShare, 0, len(shares)+1)
+	for _, s := range shares {
+		if s.PrincipalType != principalType || s.PrincipalID != principalID {
+			result = append(result, s)
+		}
+	}
+	return result
+}
@@ -0,0 +1,228 @@
+package models
+
+import (
+	"context"
+	"time"
+
+	"github.com/grewalsk/task-api/internal/tenant"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type SprintState string
+
+const (
+	SprintPlanned SprintState = "planned"
+	SprintActive  SprintState = "active"
+	SprintClosed  SprintState = "closed"
+)
+
+// Sprint is a time box within a project. A project has at most one active
+// sprint. CarriedOver lists the unfinished tasks moved out when the sprint
+// was closed, so its burndown still covers them.
+type Sprint struct {
+	ID          primitive.ObjectID   `json:"id" bson:"_id,omitempty"`
+	ProjectID   primitive.ObjectID   `json:"project_id" bson:"project_id"`
+	Name        string               `json:"name" bson:"name" validate:"required,min=1,max=100"`
+	Goal        string               `json:"goal" bson:"goal" validate:"max=1000"`
+	StartDate   time.Time            `json:"start_date" bson:"start_date" validate:"required"`
+	EndDate     time.Time            `json:"end_date" bson:"end_date" validate:"required,gtfield=StartDate"`
+	State       SprintState          `json:"state" bson:"state"`
+	CarriedOver []primitive.ObjectID `json:"carried_over,omitempty" bson:"carried_over,omitempty"`
+	ClosedAt    *time.Time           `json:"closed_at,omitempty" bson:"closed_at,omitempty"`
+	CreatedBy   primitive.ObjectID   `json:"created_by" bson:"created_by"`
+	CreatedAt   time.Time            `json:"created_at" bson:"created_at"`
+	UpdatedAt   time.Time            `json:"updated_at" bson:"updated_at"`
+}
+
+type SprintDAO struct {
+	collection *tenant.Collection
+	tasks      *tenant.Collection
+	activity   *tenant.Collection
+}
+
+func NewSprintDAO(db *mongo.Database) *SprintDAO {
+	return &SprintDAO{
+		collection: tenant.NewCollection(db, "sprints"),
+		tasks:      tenant.NewCollection(db, "tasks"),
+		activity:   tenant.NewCollection(db, "task_activity"),
+	}
+}
+
+func (dao *SprintDAO) Create(ctx context.Context, sprint *Sprint) error {
+	sprint.ID = primitive.NewObjectID()
+	sprint.State = SprintPlanned
+	sprint.CarriedOver = nil
+	sprint.ClosedAt = nil
+	sprint.CreatedAt = time.Now()
+	sprint.UpdatedAt = sprint.CreatedAt
+
+	_, err := dao.collection.InsertOne(ctx, sprint)
+	return err
+}
+
+func (dao *SprintDAO) GetByID(ctx context.Context, projectID, id primitive.ObjectID) (*Sprint, error) {
+	var sprint Sprint
+	if err := dao.collection.FindOne(ctx, bson.M{"_id": id, "project_id": projectID}).Decode(&sprint); err != nil {
+		return nil, err
+	}
+
+	return &sprint, nil
+}
+
+// Update changes a sprint that is not closed.
+func (dao *SprintDAO) Update(ctx context.Context, id primitive.ObjectID, updates bson.M) error {
+	updates["updated_at"] = time.Now()
+
+	filter := bson.M{"_id": id, "state": bson.M{"$ne": SprintClosed}}
+	result, err := dao.collection.UpdateOne(ctx, filter, bson.M{"$set": updates})
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+
+	return nil
+}
+
+// Start activates a planned sprint. Starting a second sprint in the same
+// project fails with a duplicate key error.
+func (dao *SprintDAO) Start(ctx context.Context, id primitive.ObjectID) error {
+	filter := bson.M{"_id": id, "state": SprintPlanned}
+	update := bson.M{"$set": bson.M{"state": SprintActive, "updated_at": time.Now()}}
+
+	result, err := dao.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+
+	return nil
+}
+
+// Close closes an active sprint and moves its unfinished tasks to next, or to
+// the project backlog when next is nil, in one transaction. It returns the
+// IDs of the moved tasks.
+func (dao *SprintDAO) Close(ctx context.Context, sprint *Sprint, next *primitive.ObjectID, actorID primitive.ObjectID) ([]primitive.ObjectID, error) {
+	var moved []primitive.ObjectID
+
+	err := withTransaction(ctx, dao.collection, func(sc mongo.SessionContext) error {
+		opts := options.Find().SetProjection(bson.M{"_id": 1})
+		cursor, err := dao.tasks.Find(sc, bson.M{
+			"sprint_id": sprint.ID,
+			"status":    bson.M{"$ne": StatusDone},
+			"deleted":   false,
+		}, opts)
+		if err != nil {
+			return err
+		}
+
+		var docs []struct {
+			ID primitive.ObjectID `bson:"_id"`
+		}
+		if err := cursor.All(sc, &docs); err != nil {
+			return err
+		}
+
+		now := time.Now()
+		moved = make([]primitive.ObjectID, len(docs))
+		activities := make([]interface{}, len(docs))
+		for i, doc := range docs {
+			moved[i] = doc.ID
+			change := FieldChange{Field: "sprint_id", Before: sprint.ID}
+			if next != nil {
+				change.After = *next
+			}
+			activities[i] = newActivity(doc.ID, actorID, ActivityUpdated, []FieldChange{change})
+		}
+
+		update := bson.M{
+			"$set": bson.M{"state": SprintClosed, "closed_at": now, "updated_at": now, "carried_over": moved},
+		}
+		result, err := dao.collection.UpdateOne(sc, bson.M{"_id": sprint.ID, "state": SprintActive}, update)
+		if err != nil {
+			return err
+		}
+		if result.MatchedCount == 0 {
+			return mongo.ErrNoDocuments
+		}
+
+		if len(moved) == 0 {
+			return nil
+		}
+
+		taskUpdate := bson.M{"$unset": bson.M{"sprint_id": ""}, "$set": bson.M{"updated_at": now}, "$inc": bson.M{"version": 1}}
+		if next != nil {
+			taskUpdate = bson.M{"$set": bson.M{"sprint_id": *next, "updated_at": now}, "$inc": bson.M{"version": 1}}
+		}
+		if _, err := dao.tasks.UpdateMany(sc, bson.M{"_id": bson.M{"$in": moved}}, taskUpdate); err != nil {
+			return err
+		}
+
+		_, err = dao.activity.InsertMany(sc, activities)
+		return err
+	})
+
+	return moved, err
+}
+
+// NextPlanned returns the planned sprint of the project that starts soonest
+// after the given time.
+func (dao *SprintDAO) NextPlanned(ctx context.Context, projectID primitive.ObjectID, after time.Time) (*Sprint, error) {
+	filter := bson.M{
+		"project_id": projectID,
+		"state":      SprintPlanned,
+		"start_date": bson.M{"$gte": after},
+	}
+	opts := options.FindOne().SetSort(bson.D{{Key: "start_date", Value: 1}, {Key: "_id", Value: 1}})
+
+	var sprint Sprint
+	if err := dao.collection.FindOne(ctx, filter, opts).Decode(&sprint); err != nil {
+		return nil, err
+	}
+
+	return &sprint, nil
+}
+
+// Delete removes a sprint that is not active and detaches its tasks.
+func (dao *SprintDAO) Delete(ctx context.Context, id primitive.ObjectID) error {
+	result, err := dao.collection.DeleteOne(ctx, bson.M{"_id": id, "state": bson.M{"$ne": SprintActive}})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+
+	_, err = dao.tasks.UpdateMany(ctx, bson.M{"sprint_id": id}, bson.M{"$unset": bson.M{"sprint_id": ""}, "$inc": bson.M{"version": 1}})
+	return err
+}
+
+// List returns the sprints of a project ordered by start date, optionally
+// restricted to one state.
+func (dao *SprintDAO) List(ctx context.Context, projectID primitive.ObjectID, state SprintState) ([]*Sprint, error) {
+	filter := bson.M{"project_id": projectID}
+	if state != "" {
+		filter["state"] = state
+	}
+
+	opts := options.Find().SetSort(bson.D{{Key: "start_date", Value: 1}, {Key: "_id", Value: 1}})
+
+	cursor, err := dao.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	sprints := []*Sprint{}
+	if err := cursor.All(ctx, &sprints); err != nil {
+		return nil, err
+	}
+
+	return sprints, nil
+}
@@ -2,14 +2,24 @@ package models
 
 import (
 	"context"
+	"errors"
+	"strings"
 	"time"
 
+	"github.com/grewalsk/task-api/internal/tenant"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
+// ErrConcurrentModification is returned when a task changed between being
+// read and being written.
+var (
+	ErrConcurrentModification = errors.New("task was modified concurrently")
+	ErrVersionMismatch        = errors.New("task version does not match")
+)
+
 type TaskStatus string
 
 const (
@@ -19,51 +29,282 @@ const (
 )
 
 type Task struct {
-	ID          primitive.ObjectID `json:"id" bson:"_id,omitempty"`
-	Title       string             `json:"title" bson:"title" validate:"required,min=1,max=200"`
-	Description string             `json:"description" bson:"description" validate:"max=1000"`
-	Status      TaskStatus         `json:"status" bson:"status" validate:"required,oneof=open in_progress done"`
-	OwnerID     primitive.ObjectID `json:"owner_id" bson:"owner_id"`
-	CreatedAt   time.Time          `json:"created_at" bson:"created_at"`
-	UpdatedAt   time.Time          `json:"updated_at" bson:"updated_at"`
-	DeletedAt   *time.Time         `json:"deleted_at,omitempty" bson:"deleted_at,omitempty"`
+	ID                       primitive.ObjectID     `json:"id" bson:"_id,omitempty"`
+	TenantID                 primitive.ObjectID     `json:"-" bson:"tenant_id,omitempty"`
+	Title                    string                 `json:"title" bson:"title" validate:"required,min=1,max=200"`
+	Description              string                 `json:"description" bson:"description" validate:"max=1000"`
+	Status                   TaskStatus             `json:"status" bson:"status" validate:"required,oneof=open in_progress done" openapi:"default=open"`
+	OwnerID                  primitive.ObjectID     `json:"owner_id" bson:"owner_id"`
+	ProjectID                *primitive.ObjectID    `json:"project_id,omitempty" bson:"project_id,omitempty"`
+	ParentID                 *primitive.ObjectID    `json:"parent_id,omitempty" bson:"parent_id,omitempty"`
+	SprintID                 *primitive.ObjectID    `json:"sprint_id,omitempty" bson:"sprint_id,omitempty"`
+	MilestoneID              *primitive.ObjectID    `json:"milestone_id,omitempty" bson:"milestone_id,omitempty"`
+	ClonedFrom               *primitive.ObjectID    `json:"cloned_from,omitempty" bson:"cloned_from,omitempty"`
+	AssigneeIDs              []primitive.ObjectID   `json:"assignee_ids,omitempty" bson:"assignee_ids,omitempty" validate:"max=20"`
+	Labels                   []string               `json:"labels,omitempty" bson:"labels,omitempty" validate:"max=20,dive,min=1,max=50"`
+	Checklist                []ChecklistItem        `json:"checklist,omitempty" bson:"checklist,omitempty" validate:"max=100,dive"`
+	Recurrence               *Recurrence            `json:"recurrence,omitempty" bson:"recurrence,omitempty"`
+	OriginalEstimateMinutes  *int64                 `json:"original_estimate_minutes,omitempty" bson:"original_estimate_minutes,omitempty" validate:"omitempty,min=0"`
+	RemainingEstimateMinutes *int64                 `json:"remaining_estimate_minutes,omitempty" bson:"remaining_estimate_minutes,omitempty" validate:"omitempty,min=0"`
+	CustomFields             map[string]interface{} `json:"custom_fields,omitempty" bson:"custom_fields,omitempty"`
+	Shares                   []TaskShare            `json:"shares,omitempty" bson:"shares,omitempty"`
+	SnoozedUntil             *time.Time             `json:"snoozed_until,omitempty" bson:"snoozed_until,omitempty"`
+	StartedAt                *time.Time             `json:"started_at,omitempty" bson:"started_at,omitempty"`
+	CompletedAt              *time.Time             `json:"completed_at,omitempty" bson:"completed_at,omitempty"`
+	CreatedAt                time.Time              `json:"created_at" bson:"created_at"`
+	UpdatedAt                time.Time              `json:"updated_at" bson:"updated_at"`
+	DeletedAt                *time.Time             `json:"deleted_at,omitempty" bson:"deleted_at,omitempty"`
+	Deleted                  bool                   `json:"-" bson:"deleted"`
+	Version                  int64                  `json:"version" bson:"version"`
+}
+
+type ChecklistItem struct {
+	Text string `json:"text" bson:"text" validate:"required,min=1,max=200"`
+	Done bool   `json:"done" bson:"done"`
+}
+
+// Recurrence links a task to its series. Only RRule, Timezone and
+// OccurrenceAt are taken from clients; the rest is maintained by the server.
+type Recurrence struct {
+	RRule        string             `json:"rrule" bson:"rrule" validate:"required,max=500"`
+	Timezone     string             `json:"timezone" bson:"timezone" validate:"max=64"`
+	SeriesID     primitive.ObjectID `json:"series_id" bson:"series_id"`
+	Index        int                `json:"index" bson:"index"`
+	OccurrenceAt time.Time          `json:"occurrence_at" bson:"occurrence_at"`
+	NextAt       *time.Time         `json:"next_at,omitempty" bson:"next_at,omitempty"`
+	Spawned      bool               `json:"spawned" bson:"spawned"`
 }
 
 type TaskFilter struct {
-	OwnerID *primitive.ObjectID `json:"owner_id,omitempty"`
-	Status  *TaskStatus         `json:"status,omitempty"`
-	Search  string              `json:"search,omitempty"`
-	Limit   int64               `json:"limit"`
-	Offset  int64               `json:"offset"`
+	OwnerID        *primitive.ObjectID    `json:"owner_id,omitempty"`
+	ProjectID      *primitive.ObjectID    `json:"project_id,omitempty"`
+	SprintID       *primitive.ObjectID    `json:"sprint_id,omitempty"`
+	MilestoneID    *primitive.ObjectID    `json:"milestone_id,omitempty"`
+	Scope          *TaskScope             `json:"-"`
+	Status         *TaskStatus            `json:"status,omitempty"`
+	Statuses       []TaskStatus           `json:"statuses,omitempty"`
+	CreatedAfter   *time.Time             `json:"created_after,omitempty"`
+	CreatedBefore  *time.Time             `json:"created_before,omitempty"`
+	UpdatedAfter   *time.Time             `json:"updated_after,omitempty"`
+	UpdatedBefore  *time.Time             `json:"updated_before,omitempty"`
+	HasDescription *bool                  `json:"has_description,omitempty"`
+	Search         string                 `json:"search,omitempty"`
+	CustomFields   map[string]interface{} `json:"custom_fields,omitempty"`
+	Expression     bson.M                 `json:"-"`
+	Sort           []SortKey              `json:"sort,omitempty"`
+	Fields         []string               `json:"-"`
+	HideSnoozed    bool                   `json:"-"`
+	Cursor         *TaskCursor            `json:"-"`
+	Limit          int64                  `json:"limit"`
+	Offset         int64                  `json:"offset"`
+}
+
+// SortKey orders a listing by one document field.
+type SortKey struct {
+	Field string `json:"field"`
+	Desc  bool   `json:"desc,omitempty"`
+}
+
+// TaskScope limits results to tasks a user owns, that belong to one of their
+// projects, or that are shared with one of PrincipalIDs (the user and their
+// groups).
+type TaskScope struct {
+	UserID       primitive.ObjectID
+	ProjectIDs   []primitive.ObjectID
+	PrincipalIDs []primitive.ObjectID
+}
+
+// TaskCursor marks a position in a sorted listing: the values of the sort
+// keys and the ID of the last task seen. Before pages backwards from it.
+type TaskCursor struct {
+	Values []interface{}
+	ID     primitive.ObjectID
+	Before bool
+}
+
+// after matches the tasks that follow the cursor in the listing order, or
+// precede it when paging backwards. Ties on every key are broken by _id,
+// which follows the direction of the last key. Missing values sort before
+// all others.
+func (c *TaskCursor) after(keys []SortKey) bson.M {
+	var branches bson.A
+	equal := bson.M{}
+	for i, key := range keys {
+		var value interface{}
+		if i < len(c.Values) {
+			value = c.Values[i]
+		}
+		if beyond := beyondValue(key.Field, value, key.Desc != c.Before); beyond != nil {
+			branches = append(branches, mergeQuery(equal, beyond))
+		}
+		equal[key.Field] = value
+	}
+
+	last := len(keys) > 0 && keys[len(keys)-1].Desc
+	idOp := "$gt"
+	if last != c.Before {
+		idOp = "$lt"
+	}
+	branches = append(branches, mergeQuery(equal, bson.M{"_id": bson.M{idOp: c.ID}}))
+
+	return bson.M{"$or": branches}
+}
+
+// beyondValue matches values of field strictly after value in ascending
+// order, or strictly before it when desc is set. It returns nil when no
+// value qualifies.
+func beyondValue(field string, value interface{}, desc bool) bson.M {
+	switch {
+	case desc && value == nil:
+		return nil
+	case desc:
+		return bson.M{"$or": bson.A{
+			bson.M{field: bson.M{"$lt": value}},
+			bson.M{field: nil},
+		}}
+	case value == nil:
+		return bson.M{field: bson.M{"$ne": nil}}
+	default:
+		return bson.M{field: bson.M{"$gt": value}}
+	}
+}
+
+// mergeQuery returns a copy of base with the conditions of extra added.
+func mergeQuery(base, extra bson.M) bson.M {
+	merged := make(bson.M, len(base)+len(extra))
+	for k, v := range base {
+		merged[k] = v
+	}
+	for k, v := range extra {
+		merged[k] = v
+	}
+	return merged
+}
+
+// SortValue returns the value of a sortable field, as named in
+// TaskFilter.Sort, or nil if the task has none.
+func (t *Task) SortValue(field string) interface{} {
+	switch field {
+	case "created_at":
+		return t.CreatedAt
+	case "updated_at":
+		return t.UpdatedAt
+	case "title":
+		return t.Title
+	case "status":
+		return t.Status
+	case "started_at":
+		return timeValue(t.StartedAt)
+	case "completed_at":
+		return timeValue(t.CompletedAt)
+	}
+	if key, ok := strings.CutPrefix(field, "custom_fields."); ok {
+		return t.CustomFields[key]
+	}
+	return nil
+}
+
+func timeValue(t *time.Time) interface{} {
+	if t == nil {
+		return nil
+	}
+	return *t
+}
+
+func (f TaskFilter) apply(query bson.M) {
+	if f.OwnerID != nil {
+		query["owner_id"] = *f.OwnerID
+	}
+	if f.ProjectID != nil {
+		query["project_id"] = *f.ProjectID
+	}
+	if f.SprintID != nil {
+		query["sprint_id"] = *f.SprintID
+	}
+	if f.MilestoneID != nil {
+		query["milestone_id"] = *f.MilestoneID
+	}
+	if f.Scope != nil {
+		query["$or"] = bson.A{
+			bson.M{"owner_id": f.Scope.UserID},
+			bson.M{"project_id": bson.M{"$in": f.Scope.ProjectIDs}},
+			bson.M{"shares.principal_id": bson.M{"$in": f.Scope.PrincipalIDs}},
+		}
+	}
 }
 
 type TaskDAO struct {
-	collection *mongo.Collection
+	collection *tenant.Collection
+	activity   *tenant.Collection
 }
 
 func NewTaskDAO(db *mongo.Database) *TaskDAO {
 	return &TaskDAO{
-		collection: db.Collection("tasks"),
+		collection: tenant.NewCollection(db, "tasks"),
+		activity:   tenant.NewCollection(db, "task_activity"),
 	}
 }
 
-func (dao *TaskDAO) Create(ctx context.Context, task *Task) error {
+func (dao *TaskDAO) Create(ctx context.Context, task *Task, actorID primitive.ObjectID) error {
 	task.ID = primitive.NewObjectID()
-	task.CreatedAt = time.Now()
-	task.UpdatedAt = time.Now()
+	return dao.CreateMany(ctx, []*Task{task}, actorID)
+}
 
-	_, err := dao.collection.InsertOne(ctx, task)
-	return err
+// CreateMany inserts tasks and their creation activity in one transaction,
+// e.g. a task together with its subtasks. IDs already set by the caller are
+// kept so subtasks can reference their parent.
+func (dao *TaskDAO) CreateMany(ctx context.Context, tasks []*Task, actorID primitive.ObjectID) error {
+	docs := make([]interface{}, len(tasks))
+	activities := make([]interface{}, len(tasks))
+	for i, task := range tasks {
+		if task.ID.IsZero() {
+			task.ID = primitive.NewObjectID()
+		}
+		task.CreatedAt = time.Now()
+		task.UpdatedAt = task.CreatedAt
+		task.DeletedAt = nil
+		task.Deleted = false
+		task.StartedAt, task.CompletedAt = nil, nil
+		task.Version = 1
+		switch task.Status {
+		case StatusInProgress:
+			task.StartedAt = &task.CreatedAt
+		case StatusDone:
+			task.CompletedAt = &task.CreatedAt
+		}
+		if task.Recurrence != nil && task.Recurrence.SeriesID.IsZero() {
+			task.Recurrence.SeriesID = task.ID
+		}
+
+		docs[i] = task
+		activities[i] = newActivity(task.ID, actorID, ActivityCreated, nil)
+	}
+
+	return withTransaction(ctx, dao.collection, func(sc mongo.SessionContext) error {
+		if _, err := dao.collection.InsertMany(sc, docs); err != nil {
+			return err
+		}
+
+		_, err := dao.activity.InsertMany(sc, activities)
+		return err
+	})
 }
 
-func (dao *TaskDAO) GetByID(ctx context.Context, id primitive.ObjectID) (*Task, error) {
+// GetByID returns an active task. If fields are given, only those document
+// fields and _id are loaded.
+func (dao *TaskDAO) GetByID(ctx context.Context, id primitive.ObjectID, fields ...string) (*Task, error) {
 	var task Task
 	filter := bson.M{
-		"_id":        id,
-		"deleted_at": bson.M{"$exists": false},
+		"_id":     id,
+		"deleted": false,
+	}
+
+	opts := options.FindOne()
+	if len(fields) > 0 {
+		opts.SetProjection(projection(fields))
 	}
 
-	err := dao.collection.FindOne(ctx, filter).Decode(&task)
+	err := dao.collection.FindOne(ctx, filter, opts).Decode(&task)
 	if err != nil {
 		return nil, err
 	}
@@ -71,50 +312,288 @@ func (dao *TaskDAO) GetByID(ctx context.Context, id primitive.ObjectID) (*Task,
 	return &task, nil
 }
 
-func (dao *TaskDAO) Update(ctx context.Context, id primitive.ObjectID, updates bson.M) error {
-	updates["updated_at"] = time.Now()
+// Update sets and removes fields of an active task and bumps its version. A
+// non-zero version makes the update conditional: ErrVersionMismatch is
+// returned if the task has since been changed.
+func (dao *TaskDAO) Update(ctx context.Context, id primitive.ObjectID, version int64, updates bson.M, unset []string, actorID primitive.ObjectID) error {
+	now := time.Now()
+	updates["updated_at"] = now
 
 	filter := bson.M{
-		"_id":        id,
-		"deleted_at": bson.M{"$exists": false},
+		"_id":     id,
+		"deleted": false,
+	}
+	if version != 0 {
+		filter["version"] = version
 	}
 
-	update := bson.M{"$set": updates}
-	_, err := dao.collection.UpdateOne(ctx, filter, update)
-	return err
+	err := withTransaction(ctx, dao.collection, func(sc mongo.SessionContext) error {
+		update := bson.M{"$set": updates, "$inc": bson.M{"version": 1}}
+		if len(unset) > 0 {
+			fields := bson.M{}
+			for _, key := range unset {
+				fields[key] = ""
+			}
+			update["$unset"] = fields
+		}
+		if status, ok := statusValue(updates["status"]); ok {
+			var current Task
+			if err := dao.collection.FindOne(sc, filter).Decode(&current); err != nil {
+				return err
+			}
+			update = withLifecycle(update, &current, status, now)
+		}
+
+		var before bson.M
+		opts := options.FindOneAndUpdate().SetReturnDocument(options.Before)
+		if err := dao.collection.FindOneAndUpdate(sc, filter, update, opts).Decode(&before); err != nil {
+			return err
+		}
+
+		after := bson.M{}
+		for key, value := range updates {
+			after[key] = value
+		}
+		for _, key := range unset {
+			after[key] = nil
+		}
+		changes := diffFields(before, after)
+		if len(changes) == 0 {
+			return nil
+		}
+
+		_, err := dao.activity.InsertOne(sc, newActivity(id, actorID, ActivityUpdated, changes))
+		return err
+	})
+	return dao.checkVersion(ctx, id, version, err)
 }
 
-func (dao *TaskDAO) Delete(ctx context.Context, id primitive.ObjectID) error {
+// Delete moves an active task to the trash. A non-zero version makes the
+// delete conditional, as for Update.
+func (dao *TaskDAO) Delete(ctx context.Context, id primitive.ObjectID, version int64, actorID primitive.ObjectID) error {
 	filter := bson.M{
-		"_id":        id,
-		"deleted_at": bson.M{"$exists": false},
+		"_id":     id,
+		"deleted": false,
+	}
+	if version != 0 {
+		filter["version"] = version
 	}
 
 	update := bson.M{
 		"$set": bson.M{
+			"deleted":    true,
 			"deleted_at": time.Now(),
 			"updated_at": time.Now(),
 		},
+		"$inc": bson.M{"version": 1},
 	}
 
-	_, err := dao.collection.UpdateOne(ctx, filter, update)
+	err := withTransaction(ctx, dao.collection, func(sc mongo.SessionContext) error {
+		if err := dao.collection.FindOneAndUpdate(sc, filter, update).Err(); err != nil {
+			return err
+		}
+
+		_, err := dao.activity.InsertOne(sc, newActivity(id, actorID, ActivityDeleted, nil))
+		return err
+	})
+	return dao.checkVersion(ctx, id, version, err)
+}
+
+// checkVersion turns a failed conditional write on a task that still exists
+// into ErrVersionMismatch.
+func (dao *TaskDAO) checkVersion(ctx context.Context, id primitive.ObjectID, version int64, err error) error {
+	if version == 0 || !errors.Is(err, mongo.ErrNoDocuments) {
+		return err
+	}
+
+	count, countErr := dao.collection.CountDocuments(ctx, bson.M{"_id": id, "deleted": false})
+	if countErr != nil {
+		return countErr
+	}
+	if count > 0 {
+		return ErrVersionMismatch
+	}
 	return err
 }
 
 func (dao *TaskDAO) List(ctx context.Context, filter TaskFilter) ([]*Task, error) {
-	query := bson.M{"deleted_at": bson.M{"$exists": false}}
+	query := filter.listQuery()
 
-	if filter.OwnerID != nil {
-		query["owner_id"] = *filter.OwnerID
+	backwards := filter.Cursor != nil && filter.Cursor.Before
+	if filter.Cursor != nil {
+		query["$and"] = append(andClauses(query), filter.Cursor.after(filter.Sort))
 	}
 
-	if filter.Status != nil {
-		query["status"] = *filter.Status
+	opts := options.Find()
+	if filter.Limit > 0 {
+		opts.SetLimit(filter.Limit)
+	}
+	if filter.Offset > 0 {
+		opts.SetSkip(filter.Offset)
+	}
+	if len(filter.Fields) > 0 {
+		opts.SetProjection(projection(filter.Fields))
+	}
+	if len(filter.Sort) > 0 {
+		sort := bson.D{}
+		for _, key := range filter.Sort {
+			sort = append(sort, bson.E{Key: key.Field, Value: sortDirection(key.Desc, backwards)})
+		}
+		last := filter.Sort[len(filter.Sort)-1]
+		sort = append(sort, bson.E{Key: "_id", Value: sortDirection(last.Desc, backwards)})
+		opts.SetSort(sort)
+	} else {
+		opts.SetSort(bson.M{"created_at": -1})
 	}
 
-	if filter.Search != "" {
-		query["$text"] = bson.M{"$search": filter.Search}
+	cursor, err := dao.collection.Find(ctx, query, opts)
+	if err != nil {
+		return nil, err
 	}
+	defer cursor.Close(ctx)
+
+	var tasks []*Task
+	if err := cursor.All(ctx, &tasks); err != nil {
+		return nil, err
+	}
+
+	if backwards {
+		for i, j := 0, len(tasks)-1; i < j; i, j = i+1, j-1 {
+			tasks[i], tasks[j] = tasks[j], tasks[i]
+		}
+	}
+
+	return tasks, nil
+}
+
+// Count returns the number of active tasks matching the filter, ignoring
+// its cursor and pagination.
+func (dao *TaskDAO) Count(ctx context.Context, filter TaskFilter) (int64, error) {
+	return dao.collection.CountDocuments(ctx, filter.listQuery())
+}
+
+func (f TaskFilter) listQuery() bson.M {
+	query := bson.M{"deleted": false}
+	f.apply(query)
+
+	if f.Status != nil {
+		query["status"] = *f.Status
+	} else if len(f.Statuses) > 0 {
+		query["status"] = bson.M{"$in": f.Statuses}
+	}
+
+	if created := timeRange(f.CreatedAfter, f.CreatedBefore); created != nil {
+		query["created_at"] = created
+	}
+	if updated := timeRange(f.UpdatedAfter, f.UpdatedBefore); updated != nil {
+		query["updated_at"] = updated
+	}
+
+	if f.HasDescription != nil {
+		op := "$in"
+		if *f.HasDescription {
+			op = "$nin"
+		}
+		query["description"] = bson.M{op: bson.A{"", nil}}
+	}
+
+	if f.Search != "" {
+		query["$text"] = bson.M{"$search": f.Search}
+	}
+
+	if f.HideSnoozed {
+		query["snoozed_until"] = bson.M{"$not": bson.M{"$gt": time.Now()}}
+	}
+
+	for key, value := range f.CustomFields {
+		query["custom_fields."+key] = value
+	}
+
+	if f.Expression != nil {
+		query["$and"] = append(andClauses(query), f.Expression)
+	}
+
+	return query
+}
+
+// projection includes the given document fields. Paths below a field that
+// is itself included are dropped, since MongoDB rejects the overlap.
+func projection(fields []string) bson.M {
+	p := make(bson.M, len(fields))
+	for _, field := range fields {
+		p[field] = 1
+	}
+	for _, field := range fields {
+		for parent := field; strings.Contains(parent, "."); {
+			parent = parent[:strings.LastIndex(parent, ".")]
+			if _, ok := p[parent]; ok {
+				delete(p, field)
+				break
+			}
+		}
+	}
+	return p
+}
+
+// andClauses returns the $and conditions already in query.
+func andClauses(query bson.M) bson.A {
+	clauses, _ := query["$and"].(bson.A)
+	return clauses
+}
+
+// timeRange matches times from after, inclusive, up to before, exclusive.
+func timeRange(after, before *time.Time) bson.M {
+	if after == nil && before == nil {
+		return nil
+	}
+	r := bson.M{}
+	if after != nil {
+		r["$gte"] = *after
+	}
+	if before != nil {
+		r["$lt"] = *before
+	}
+	return r
+}
+
+func sortDirection(desc, reverse bool) int {
+	if desc != reverse {
+		return -1
+	}
+	return 1
+}
+
+// ListChildren returns the active subtasks of the given parents.
+func (dao *TaskDAO) ListChildren(ctx context.Context, parentIDs []primitive.ObjectID) ([]*Task, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}, {Key: "_id", Value: 1}})
+
+	cursor, err := dao.collection.Find(ctx, bson.M{"parent_id": bson.M{"$in": parentIDs}, "deleted": false}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	tasks := []*Task{}
+	if err := cursor.All(ctx, &tasks); err != nil {
+		return nil, err
+	}
+
+	return tasks, nil
+}
+
+func (dao *TaskDAO) GetDeletedByID(ctx context.Context, id primitive.ObjectID) (*Task, error) {
+	var task Task
+	err := dao.collection.FindOne(ctx, bson.M{"_id": id, "deleted": true}).Decode(&task)
+	if err != nil {
+		return nil, err
+	}
+
+	return &task, nil
+}
+
+func (dao *TaskDAO) ListDeleted(ctx context.Context, filter TaskFilter) ([]*Task, error) {
+	query := bson.M{"deleted": true}
+	filter.apply(query)
 
 	opts := options.Find()
 	if filter.Limit > 0 {
@@ -123,8 +602,165 @@ func (dao *TaskDAO) List(ctx context.Context, filter TaskFilter) ([]*Task, error
 	if filter.Offset > 0 {
 		opts.SetSkip(filter.Offset)
 	}
-	opts.SetSort(bson.M{"created_at": -1})
+	opts.SetSort(bson.D{{Key: "deleted_at", Value: -1}, {Key: "_id", Value: -1}})
+
+	cursor, err := dao.collection.Find(ctx, query, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	tasks := []*Task{}
+	if err := cursor.All(ctx, &tasks); err != nil {
+		return nil, err
+	}
+
+	return tasks, nil
+}
+
+func (dao *TaskDAO) Restore(ctx context.Context, id primitive.ObjectID, actorID primitive.ObjectID) error {
+	filter := bson.M{
+		"_id":     id,
+		"deleted": true,
+	}
+
+	update := bson.M{
+		"$set":   bson.M{"deleted": false, "updated_at": time.Now()},
+		"$unset": bson.M{"deleted_at": ""},
+		"$inc":   bson.M{"version": 1},
+	}
+
+	return withTransaction(ctx, dao.collection, func(sc mongo.SessionContext) error {
+		if err := dao.collection.FindOneAndUpdate(sc, filter, update).Err(); err != nil {
+			return err
+		}
+
+		_, err := dao.activity.InsertOne(sc, newActivity(id, actorID, ActivityRestored, nil))
+		return err
+	})
+}
+
+// Unsnooze clears the snooze of an active task. When until is set, the snooze
+// is only cleared if it still ends at that time, so that a concurrent
+// re-snooze is not lost. It reports whether a snooze was cleared.
+func (dao *TaskDAO) Unsnooze(ctx context.Context, id primitive.ObjectID, until *time.Time, actorID primitive.ObjectID) (bool, error) {
+	filter := bson.M{
+		"_id":           id,
+		"deleted":       false,
+		"snoozed_until": bson.M{"$type": "date"},
+	}
+	if until != nil {
+		filter["snoozed_until"] = *until
+	}
+
+	update := bson.M{
+		"$set":   bson.M{"updated_at": time.Now()},
+		"$unset": bson.M{"snoozed_until": ""},
+		"$inc":   bson.M{"version": 1},
+	}
+
+	cleared := false
+	err := withTransaction(ctx, dao.collection, func(sc mongo.SessionContext) error {
+		var before Task
+		err := dao.collection.FindOneAndUpdate(sc, filter, update).Decode(&before)
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			cleared = false
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		cleared = true
+
+		change := FieldChange{Field: "snoozed_until", Before: *before.SnoozedUntil}
+		_, err = dao.activity.InsertOne(sc, newActivity(id, actorID, ActivityUpdated, []FieldChange{change}))
+		return err
+	})
+	return cleared, err
+}
+
+// ListSnoozeExpired returns active tasks whose snooze ended by now.
+func (dao *TaskDAO) ListSnoozeExpired(ctx context.Context, now time.Time, limit int64) ([]*Task, error) {
+	query := bson.M{
+		"deleted":       false,
+		"snoozed_until": bson.M{"$lte": now},
+	}
+
+	opts := options.Find().SetLimit(limit).SetSort(bson.M{"snoozed_until": 1})
+	cursor, err := dao.collection.Find(ctx, query, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var tasks []*Task
+	if err := cursor.All(ctx, &tasks); err != nil {
+		return nil, err
+	}
+
+	return tasks, nil
+}
+
+// ListExpired returns tasks, with only their ID and workspace loaded, that
+// have been in the trash since before the cutoff. Tasks in skip are left out.
+func (dao *TaskDAO) ListExpired(ctx context.Context, cutoff time.Time, skip []primitive.ObjectID, limit int64) ([]*Task, error) {
+	query := bson.M{
+		"deleted":    true,
+		"deleted_at": bson.M{"$lt": cutoff},
+	}
+	if len(skip) > 0 {
+		query["_id"] = bson.M{"$nin": skip}
+	}
+
+	opts := options.Find().
+		SetLimit(limit).
+		SetSort(bson.M{"deleted_at": 1}).
+		SetProjection(bson.M{"_id": 1, "tenant_id": 1})
+
+	cursor, err := dao.collection.Find(ctx, query, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
 
+	var tasks []*Task
+	if err := cursor.All(ctx, &tasks); err != nil {
+		return nil, err
+	}
+
+	return tasks, nil
+}
+
+// Purge permanently removes a task together with its activity history.
+// Comments and attachments are removed by their own DAOs beforehand.
+func (dao *TaskDAO) Purge(ctx context.Context, id primitive.ObjectID) error {
+	return withTransaction(ctx, dao.collection, func(sc mongo.SessionContext) error {
+		if _, err := dao.activity.DeleteMany(sc, bson.M{"task_id": id}); err != nil {
+			return err
+		}
+
+		if _, err := dao.collection.UpdateMany(sc, bson.M{"parent_id": id}, bson.M{
+			"$unset": bson.M{"parent_id": ""},
+			"$inc":   bson.M{"version": 1},
+		}); err != nil {
+			return err
+		}
+
+		_, err := dao.collection.DeleteOne(sc, bson.M{"_id": id})
+		return err
+	})
+}
+
+// ListDueRecurrences returns recurring tasks whose next occurrence has begun
+// but has not been generated yet.
+func (dao *TaskDAO) ListDueRecurrences(ctx context.Context, now time.Time, limit int64) ([]*Task, error) {
+	query := bson.M{
+		"deleted":            false,
+		"recurrence.spawned": false,
+		"recurrence.next_at": bson.M{"$lte": now},
+	}
+
+	opts := options.Find().SetLimit(limit).SetSort(bson.M{"recurrence.next_at": 1})
 	cursor, err := dao.collection.Find(ctx, query, opts)
 	if err != nil {
 		return nil, err
@@ -137,4 +773,169 @@ func (dao *TaskDAO) List(ctx context.Context, filter TaskFilter) ([]*Task, error
 	}
 
 	return tasks, nil
-}
\ No newline at end of file
+}
+
+func (dao *TaskDAO) MarkRecurrenceSpawned(ctx context.Context, id primitive.ObjectID) error {
+	filter := bson.M{"_id": id, "recurrence": bson.M{"$exists": true}}
+	update := bson.M{"$set": bson.M{"recurrence.spawned": true}, "$inc": bson.M{"version": 1}}
+
+	_, err := dao.collection.UpdateOne(ctx, filter, update)
+	return err
+}
+
+// TaskWrite is one change in a bulk operation. Task is the state the change
+// was computed from; the write only applies if the task is still unchanged.
+type TaskWrite struct {
+	Task   *Task
+	Action ActivityAction
+	Set    bson.M
+	Unset  []string
+}
+
+// ApplyBulk executes writes with a single BulkWrite and records their
+// activity in the same transaction. If any task changed since it was read,
+// nothing is written and ErrConcurrentModification is returned.
+func (dao *TaskDAO) ApplyBulk(ctx context.Context, writes []TaskWrite, actorID primitive.ObjectID) error {
+	if len(writes) == 0 {
+		return nil
+	}
+
+	now := time.Now()
+	ops := make([]mongo.WriteModel, len(writes))
+	activities := make([]interface{}, len(writes))
+	for i, w := range writes {
+		set := bson.M{"updated_at": now}
+		for key, value := range w.Set {
+			set[key] = value
+		}
+		update := bson.M{"$set": set, "$inc": bson.M{"version": 1}}
+		if len(w.Unset) > 0 {
+			unset := bson.M{}
+			for _, key := range w.Unset {
+				unset[key] = ""
+			}
+			update["$unset"] = unset
+		}
+
+		if status, ok := statusValue(w.Set["status"]); ok {
+			update = withLifecycle(update, w.Task, status, now)
+		}
+
+		filter := bson.M{"_id": w.Task.ID, "version": w.Task.Version}
+		ops[i] = mongo.NewUpdateOneModel().SetFilter(filter).SetUpdate(update)
+
+		var changes []FieldChange
+		if w.Action == ActivityUpdated {
+			before, err := toM(w.Task)
+			if err != nil {
+				return err
+			}
+			changes = diffFields(before, w.Set)
+		}
+		activities[i] = newActivity(w.Task.ID, actorID, w.Action, changes)
+	}
+
+	return withTransaction(ctx, dao.collection, func(sc mongo.SessionContext) error {
+		result, err := dao.collection.BulkWrite(sc, ops, options.BulkWrite().SetOrdered(false))
+		if err != nil {
+			return err
+		}
+		if result.MatchedCount != int64(len(ops)) {
+			return ErrConcurrentModification
+		}
+
+		_, err = dao.activity.InsertMany(sc, activities)
+		return err
+	})
+}
+
+// withLifecycle adds the lifecycle timestamps caused by moving task to
+// status to an update document: started_at is stamped the first time a task
+// enters in_progress, and completed_at whenever it enters done. Leaving done
+// clears completed_at.
+func withLifecycle(update bson.M, task *Task, status TaskStatus, now time.Time) bson.M {
+	set := bson.M{}
+	if existing, ok := update["$set"].(bson.M); ok {
+		for key, value := range existing {
+			set[key] = value
+		}
+	}
+	unset := bson.M{}
+	if existing, ok := update["$unset"].(bson.M); ok {
+		for key, value := range existing {
+			unset[key] = value
+		}
+	}
+
+	if status == StatusInProgress && task.StartedAt == nil {
+		set["started_at"] = now
+	}
+	switch {
+	case status == StatusDone && task.Status != StatusDone:
+		set["completed_at"] = now
+	case status != StatusDone && task.CompletedAt != nil:
+		unset["completed_at"] = ""
+	}
+
+	result := bson.M{}
+	for op, value := range update {
+		result[op] = value
+	}
+	result["$set"] = set
+	if len(unset) > 0 {
+		result["$unset"] = unset
+	}
+	return result
+}
+
+func statusValue(v interface{}) (TaskStatus, bool) {
+	switch status := v.(type) {
+	case TaskStatus:
+		return status, true
+	case string:
+		return TaskStatus(status), true
+	}
+	return "", false
+}
+
+func toM(v interface{}) (bson.M, error) {
+	raw, err := bson.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	var m bson.M
+	if err := bson.Unmarshal(raw, &m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// Transaction runs fn in a multi-document transaction. Task changes made
+// with the context it is given join the transaction.
+func (dao *TaskDAO) Transaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	return withTransaction(ctx, dao.collection, func(sc mongo.SessionContext) error {
+		return fn(sc)
+	})
+}
+
+// withTransaction runs fn in a multi-document transaction so task changes
+// and their activity entries are committed together. A context that is
+// already in a transaction keeps using it. This requires MongoDB to run as a
+// replica set.
+func withTransaction(ctx context.Context, collection *tenant.Collection, fn func(sc mongo.SessionContext) error) error {
+	if sc, ok := ctx.(mongo.SessionContext); ok {
+		return fn(sc)
+	}
+
+	session, err := collection.StartSession()
+	if err != nil {
+		return err
+	}
+	defer session.EndSession(ctx)
+
+	_, err = session.WithTransaction(ctx, func(sc mongo.SessionContext) (interface{}, error) {
+		return nil, fn(sc)
+	})
+	return err
+}
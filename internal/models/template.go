@@ -0,0 +1,151 @@
+package models
+
+import (
+	"context"
+	"time"
+
+	"github.com/grewalsk/task-api/internal/tenant"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Template is one immutable version of a task template. All versions share
+// TemplateID; editing a template inserts a new version.
+type Template struct {
+	ID                 primitive.ObjectID `json:"version_id" bson:"_id,omitempty"`
+	TemplateID         primitive.ObjectID `json:"id" bson:"template_id"`
+	Version            int                `json:"version" bson:"version"`
+	OwnerID            primitive.ObjectID `json:"owner_id" bson:"owner_id"`
+	Shared             bool               `json:"shared" bson:"shared"`
+	Name               string             `json:"name" bson:"name" validate:"required,min=1,max=100"`
+	TitlePattern       string             `json:"title_pattern" bson:"title_pattern" validate:"required,min=1,max=200"`
+	DescriptionPattern string             `json:"description_pattern" bson:"description_pattern" validate:"max=1000"`
+	DefaultStatus      TaskStatus         `json:"default_status" bson:"default_status" validate:"omitempty,oneof=open in_progress done"`
+	Labels             []string           `json:"labels,omitempty" bson:"labels,omitempty" validate:"max=20,dive,min=1,max=50"`
+	Checklist          []ChecklistItem    `json:"checklist,omitempty" bson:"checklist,omitempty" validate:"max=100,dive"`
+	Subtasks           []TemplateSubtask  `json:"subtasks,omitempty" bson:"subtasks,omitempty" validate:"max=50,dive"`
+	CreatedBy          primitive.ObjectID `json:"created_by" bson:"created_by"`
+	CreatedAt          time.Time          `json:"created_at" bson:"created_at"`
+}
+
+type TemplateSubtask struct {
+	TitlePattern       string          `json:"title_pattern" bson:"title_pattern" validate:"required,min=1,max=200"`
+	DescriptionPattern string          `json:"description_pattern" bson:"description_pattern" validate:"max=1000"`
+	Checklist          []ChecklistItem `json:"checklist,omitempty" bson:"checklist,omitempty" validate:"max=100,dive"`
+}
+
+type TemplateDAO struct {
+	collection *tenant.Collection
+}
+
+func NewTemplateDAO(db *mongo.Database) *TemplateDAO {
+	return &TemplateDAO{
+		collection: tenant.NewCollection(db, "templates"),
+	}
+}
+
+// Create stores the first version of a new template.
+func (dao *TemplateDAO) Create(ctx context.Context, template *Template) error {
+	template.TemplateID = primitive.NewObjectID()
+	template.Version = 1
+	return dao.insertVersion(ctx, template)
+}
+
+// CreateVersion stores template as the version after previous. Concurrent
+// edits of the same version collide on the unique (template_id, version)
+// index and surface as a duplicate key error.
+func (dao *TemplateDAO) CreateVersion(ctx context.Context, previous, template *Template) error {
+	template.TemplateID = previous.TemplateID
+	template.Version = previous.Version + 1
+	template.OwnerID = previous.OwnerID
+	return dao.insertVersion(ctx, template)
+}
+
+func (dao *TemplateDAO) insertVersion(ctx context.Context, template *Template) error {
+	template.ID = primitive.NewObjectID()
+	template.CreatedAt = time.Now()
+
+	_, err := dao.collection.InsertOne(ctx, template)
+	return err
+}
+
+// Get returns the given version of a template, or the latest when version
+// is zero.
+func (dao *TemplateDAO) Get(ctx context.Context, templateID primitive.ObjectID, version int) (*Template, error) {
+	filter := bson.M{"template_id": templateID}
+	if version > 0 {
+		filter["version"] = version
+	}
+
+	opts := options.FindOne().SetSort(bson.M{"version": -1})
+
+	var template Template
+	if err := dao.collection.FindOne(ctx, filter, opts).Decode(&template); err != nil {
+		return nil, err
+	}
+
+	return &template, nil
+}
+
+func (dao *TemplateDAO) ListVersions(ctx context.Context, templateID primitive.ObjectID) ([]*Template, error) {
+	opts := options.Find().SetSort(bson.M{"version": -1})
+
+	cursor, err := dao.collection.Find(ctx, bson.M{"template_id": templateID}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	templates := []*Template{}
+	if err := cursor.All(ctx, &templates); err != nil {
+		return nil, err
+	}
+
+	return templates, nil
+}
+
+// ListLatest returns the latest version of every template the user owns or
+// that is shared. Admins see every template when ownerID is nil.
+func (dao *TemplateDAO) ListLatest(ctx context.Context, ownerID *primitive.ObjectID, limit, offset int64) ([]*Template, error) {
+	pipeline := mongo.Pipeline{
+		{{Key: "$sort", Value: bson.D{{Key: "template_id", Value: 1}, {Key: "version", Value: -1}}}},
+		{{Key: "$group", Value: bson.M{
+			"_id":    "$template_id",
+			"latest": bson.M{"$first": "$$ROOT"},
+		}}},
+		{{Key: "$replaceRoot", Value: bson.M{"newRoot": "$latest"}}},
+	}
+
+	if ownerID != nil {
+		pipeline = append(pipeline, bson.D{{Key: "$match", Value: bson.M{"$or": bson.A{
+			bson.M{"owner_id": *ownerID},
+			bson.M{"shared": true},
+		}}}})
+	}
+
+	pipeline = append(pipeline,
+		bson.D{{Key: "$sort", Value: bson.D{{Key: "name", Value: 1}, {Key: "template_id", Value: 1}}}},
+		bson.D{{Key: "$skip", Value: offset}},
+		bson.D{{Key: "$limit", Value: limit}},
+	)
+
+	cursor, err := dao.collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	templates := []*Template{}
+	if err := cursor.All(ctx, &templates); err != nil {
+		return nil, err
+	}
+
+	return templates, nil
+}
+
+func (dao *TemplateDAO) Delete(ctx context.Context, templateID primitive.ObjectID) error {
+	_, err := dao.collection.DeleteMany(ctx, bson.M{"template_id": templateID})
+	return err
+}
@@ -1,9 +1,14 @@
 package models
 
 import (
+	"context"
 	"time"
 
+	"github.com/grewalsk/task-api/internal/tenant"
+	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 type UserRole string
@@ -15,6 +20,7 @@ const (
 
 type User struct {
 	ID        primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	TenantID  primitive.ObjectID `json:"-" bson:"tenant_id,omitempty"`
 	Email     string             `json:"email" bson:"email" validate:"required,email"`
 	Password  string             `json:"-" bson:"password"`
 	Role      UserRole           `json:"role" bson:"role"`
@@ -22,12 +28,114 @@ type User struct {
 	UpdatedAt time.Time          `json:"updated_at" bson:"updated_at"`
 }
 
+// UserSummary is the part of a user embedded in other resources.
+type UserSummary struct {
+	ID    primitive.ObjectID `json:"id" bson:"_id"`
+	Email string             `json:"email" bson:"email"`
+	Role  UserRole           `json:"role" bson:"role"`
+}
+
 type LoginRequest struct {
-	Email    string `json:"email" validate:"required,email"`
-	Password string `json:"password" validate:"required,min=6"`
+	Workspace string `json:"workspace"`
+	Email     string `json:"email" validate:"required,email"`
+	Password  string `json:"password" validate:"required,min=6"`
 }
 
 type LoginResponse struct {
 	Token string `json:"token"`
 	User  User   `json:"user"`
-}
\ No newline at end of file
+}
+
+type UserDAO struct {
+	collection *tenant.Collection
+}
+
+func NewUserDAO(db *mongo.Database) *UserDAO {
+	return &UserDAO{
+		collection: tenant.NewCollection(db, "users"),
+	}
+}
+
+func (dao *UserDAO) Create(ctx context.Context, user *User) error {
+	if user.ID.IsZero() {
+		user.ID = primitive.NewObjectID()
+	}
+	user.CreatedAt = time.Now()
+	user.UpdatedAt = user.CreatedAt
+
+	_, err := dao.collection.InsertOne(ctx, user)
+	return err
+}
+
+func (dao *UserDAO) GetByID(ctx context.Context, id primitive.ObjectID) (*User, error) {
+	var user User
+	if err := dao.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&user); err != nil {
+		return nil, err
+	}
+
+	return &user, nil
+}
+
+// Summaries loads the users with the given IDs in one query, keyed by ID.
+// IDs of users that do not exist are left out.
+func (dao *UserDAO) Summaries(ctx context.Context, ids []primitive.ObjectID) (map[primitive.ObjectID]*UserSummary, error) {
+	summaries := make(map[primitive.ObjectID]*UserSummary, len(ids))
+	if len(ids) == 0 {
+		return summaries, nil
+	}
+
+	opts := options.Find().SetProjection(bson.M{"email": 1, "role": 1})
+	cursor, err := dao.collection.Find(ctx, bson.M{"_id": bson.M{"$in": ids}}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var summary UserSummary
+		if err := cursor.Decode(&summary); err != nil {
+			return nil, err
+		}
+		summaries[summary.ID] = &summary
+	}
+
+	return summaries, cursor.Err()
+}
+
+func (dao *UserDAO) GetByEmail(ctx context.Context, email string) (*User, error) {
+	var user User
+	if err := dao.collection.FindOne(ctx, bson.M{"email": email}).Decode(&user); err != nil {
+		return nil, err
+	}
+
+	return &user, nil
+}
+
+func (dao *UserDAO) List(ctx context.Context, limit, offset int64) ([]*User, error) {
+	opts := options.Find().
+		SetSort(bson.D{{Key: "email", Value: 1}}).
+		SetLimit(limit).
+		SetSkip(offset)
+
+	cursor, err := dao.collection.Find(ctx, bson.M{}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	users := []*User{}
+	if err := cursor.All(ctx, &users); err != nil {
+		return nil, err
+	}
+
+	return users, nil
+}
+
+func (dao *UserDAO) Count(ctx context.Context) (int64, error) {
+	return dao.collection.CountDocuments(ctx, bson.M{})
+}
+
+// CountByIDs returns how many of the given IDs refer to users.
+func (dao *UserDAO) CountByIDs(ctx context.Context, ids []primitive.ObjectID) (int64, error) {
+	return dao.collection.CountDocuments(ctx, bson.M{"_id": bson.M{"$in": ids}})
+}
@@ -0,0 +1,157 @@
+package models
+
+import (
+	"context"
+	"time"
+
+	"github.com/grewalsk/task-api/internal/tenant"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// SavedView is a named task listing. Filters holds task list query
+// parameters (status, owner, project_id, cf.<key>, ...) and Sort the value of
+// the sort parameter; Columns and GroupBy are presentation hints for clients.
+// A view with a ProjectID is shared with that project's members. Each user
+// has at most one default view among the views they own.
+type SavedView struct {
+	ID        primitive.ObjectID  `json:"id" bson:"_id,omitempty"`
+	OwnerID   primitive.ObjectID  `json:"owner_id" bson:"owner_id"`
+	ProjectID *primitive.ObjectID `json:"project_id,omitempty" bson:"project_id,omitempty"`
+	Name      string              `json:"name" bson:"name" validate:"required,min=1,max=100"`
+	Filters   map[string]string   `json:"filters" bson:"filters" validate:"max=30,dive,max=2000"`
+	Sort      string              `json:"sort,omitempty" bson:"sort,omitempty" validate:"max=100"`
+	Columns   []string            `json:"columns" bson:"columns" validate:"max=50,dive,min=1,max=100"`
+	GroupBy   string              `json:"group_by,omitempty" bson:"group_by,omitempty" validate:"max=100"`
+	Default   bool                `json:"default" bson:"default"`
+	CreatedAt time.Time           `json:"created_at" bson:"created_at"`
+	UpdatedAt time.Time           `json:"updated_at" bson:"updated_at"`
+}
+
+type ViewDAO struct {
+	collection *tenant.Collection
+}
+
+func NewViewDAO(db *mongo.Database) *ViewDAO {
+	return &ViewDAO{
+		collection: tenant.NewCollection(db, "views"),
+	}
+}
+
+func (dao *ViewDAO) Create(ctx context.Context, view *SavedView) error {
+	view.ID = primitive.NewObjectID()
+	view.CreatedAt = time.Now()
+	view.UpdatedAt = view.CreatedAt
+	if view.Filters == nil {
+		view.Filters = map[string]string{}
+	}
+	if view.Columns == nil {
+		view.Columns = []string{}
+	}
+
+	return withTransaction(ctx, dao.collection, func(sc mongo.SessionContext) error {
+		if view.Default {
+			if err := dao.clearDefault(sc, view.OwnerID); err != nil {
+				return err
+			}
+		}
+
+		_, err := dao.collection.InsertOne(sc, view)
+		return err
+	})
+}
+
+func (dao *ViewDAO) GetByID(ctx context.Context, id primitive.ObjectID) (*SavedView, error) {
+	var view SavedView
+	if err := dao.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&view); err != nil {
+		return nil, err
+	}
+
+	return &view, nil
+}
+
+// GetDefault returns the user's default view.
+func (dao *ViewDAO) GetDefault(ctx context.Context, ownerID primitive.ObjectID) (*SavedView, error) {
+	var view SavedView
+	if err := dao.collection.FindOne(ctx, bson.M{"owner_id": ownerID, "default": true}).Decode(&view); err != nil {
+		return nil, err
+	}
+
+	return &view, nil
+}
+
+// Update sets and unsets fields of the view. Making it the default clears
+// the owner's previous default.
+func (dao *ViewDAO) Update(ctx context.Context, view *SavedView, set bson.M, unset []string) error {
+	set["updated_at"] = time.Now()
+	update := bson.M{"$set": set}
+	if len(unset) > 0 {
+		fields := bson.M{}
+		for _, field := range unset {
+			fields[field] = ""
+		}
+		update["$unset"] = fields
+	}
+
+	return withTransaction(ctx, dao.collection, func(sc mongo.SessionContext) error {
+		if isDefault, _ := set["default"].(bool); isDefault {
+			if err := dao.clearDefault(sc, view.OwnerID); err != nil {
+				return err
+			}
+		}
+
+		result, err := dao.collection.UpdateOne(sc, bson.M{"_id": view.ID}, update)
+		if err != nil {
+			return err
+		}
+		if result.MatchedCount == 0 {
+			return mongo.ErrNoDocuments
+		}
+		return nil
+	})
+}
+
+func (dao *ViewDAO) Delete(ctx context.Context, id primitive.ObjectID) error {
+	result, err := dao.collection.DeleteOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return mongo.ErrNoDocuments
+	}
+
+	return nil
+}
+
+// ListVisible returns the views the user owns and those shared with any of
+// projectIDs.
+func (dao *ViewDAO) ListVisible(ctx context.Context, ownerID primitive.ObjectID, projectIDs []primitive.ObjectID) ([]*SavedView, error) {
+	query := bson.M{"$or": bson.A{
+		bson.M{"owner_id": ownerID},
+		bson.M{"project_id": bson.M{"$in": projectIDs}},
+	}}
+	opts := options.Find().SetSort(bson.D{{Key: "name", Value: 1}, {Key: "_id", Value: 1}})
+
+	cursor, err := dao.collection.Find(ctx, query, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	views := []*SavedView{}
+	if err := cursor.All(ctx, &views); err != nil {
+		return nil, err
+	}
+
+	return views, nil
+}
+
+func (dao *ViewDAO) clearDefault(ctx context.Context, ownerID primitive.ObjectID) error {
+	_, err := dao.collection.UpdateMany(ctx,
+		bson.M{"owner_id": ownerID, "default": true},
+		bson.M{"$set": bson.M{"default": false}},
+	)
+	return err
+}
@@ -0,0 +1,235 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/grewalsk/task-api/internal/tenant"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var ErrTimerRunning = errors.New("a timer is already running for this user")
+
+type Worklog struct {
+	ID              primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	TaskID          primitive.ObjectID `json:"task_id" bson:"task_id"`
+	UserID          primitive.ObjectID `json:"user_id" bson:"user_id"`
+	StartedAt       time.Time          `json:"started_at" bson:"started_at" validate:"required"`
+	EndedAt         *time.Time         `json:"ended_at,omitempty" bson:"ended_at,omitempty"`
+	DurationSeconds int64              `json:"duration_seconds" bson:"duration_seconds" validate:"min=0,max=86400"`
+	Note            string             `json:"note,omitempty" bson:"note,omitempty" validate:"max=1000"`
+	Running         bool               `json:"running" bson:"running"`
+	CreatedAt       time.Time          `json:"created_at" bson:"created_at"`
+	UpdatedAt       time.Time          `json:"updated_at" bson:"updated_at"`
+}
+
+type WorklogTotal struct {
+	ID      primitive.ObjectID `json:"id" bson:"_id"`
+	Seconds int64              `json:"seconds" bson:"seconds"`
+}
+
+type TimesheetEntry struct {
+	Date    string             `json:"date" bson:"date"`
+	TaskID  primitive.ObjectID `json:"task_id" bson:"task_id"`
+	Seconds int64              `json:"seconds" bson:"seconds"`
+}
+
+type WorklogDAO struct {
+	collection *tenant.Collection
+}
+
+func NewWorklogDAO(db *mongo.Database) *WorklogDAO {
+	return &WorklogDAO{
+		collection: tenant.NewCollection(db, "worklogs"),
+	}
+}
+
+func (dao *WorklogDAO) Create(ctx context.Context, worklog *Worklog) error {
+	worklog.ID = primitive.NewObjectID()
+	worklog.CreatedAt = time.Now()
+	worklog.UpdatedAt = worklog.CreatedAt
+
+	_, err := dao.collection.InsertOne(ctx, worklog)
+	if mongo.IsDuplicateKeyError(err) {
+		return ErrTimerRunning
+	}
+	return err
+}
+
+// StartTimer relies on the unique partial index on running worklogs to keep
+// a single running timer per user, even under concurrent requests.
+func (dao *WorklogDAO) StartTimer(ctx context.Context, taskID, userID primitive.ObjectID) (*Worklog, error) {
+	worklog := &Worklog{
+		TaskID:    taskID,
+		UserID:    userID,
+		StartedAt: time.Now(),
+		Running:   true,
+	}
+
+	if err := dao.Create(ctx, worklog); err != nil {
+		return nil, err
+	}
+
+	return worklog, nil
+}
+
+func (dao *WorklogDAO) GetRunning(ctx context.Context, userID primitive.ObjectID) (*Worklog, error) {
+	var worklog Worklog
+	err := dao.collection.FindOne(ctx, bson.M{"user_id": userID, "running": true}).Decode(&worklog)
+	if err != nil {
+		return nil, err
+	}
+
+	return &worklog, nil
+}
+
+func (dao *WorklogDAO) StopTimer(ctx context.Context, worklog *Worklog) (*Worklog, error) {
+	now := time.Now()
+	filter := bson.M{"_id": worklog.ID, "running": true}
+	update := bson.M{
+		"$set": bson.M{
+			"running":          false,
+			"ended_at":         now,
+			"duration_seconds": int64(now.Sub(worklog.StartedAt).Seconds()),
+			"updated_at":       now,
+		},
+	}
+
+	var stopped Worklog
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	if err := dao.collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&stopped); err != nil {
+		return nil, err
+	}
+
+	return &stopped, nil
+}
+
+func (dao *WorklogDAO) GetByID(ctx context.Context, taskID, id primitive.ObjectID) (*Worklog, error) {
+	var worklog Worklog
+	err := dao.collection.FindOne(ctx, bson.M{"_id": id, "task_id": taskID}).Decode(&worklog)
+	if err != nil {
+		return nil, err
+	}
+
+	return &worklog, nil
+}
+
+func (dao *WorklogDAO) Delete(ctx context.Context, id primitive.ObjectID) error {
+	_, err := dao.collection.DeleteOne(ctx, bson.M{"_id": id})
+	return err
+}
+
+func (dao *WorklogDAO) DeleteByTask(ctx context.Context, taskID primitive.ObjectID) error {
+	_, err := dao.collection.DeleteMany(ctx, bson.M{"task_id": taskID})
+	return err
+}
+
+func (dao *WorklogDAO) ListByTask(ctx context.Context, taskID primitive.ObjectID, limit, offset int64) ([]*Worklog, error) {
+	opts := options.Find()
+	if limit > 0 {
+		opts.SetLimit(limit)
+	}
+	if offset > 0 {
+		opts.SetSkip(offset)
+	}
+	opts.SetSort(bson.D{{Key: "started_at", Value: -1}, {Key: "_id", Value: -1}})
+
+	cursor, err := dao.collection.Find(ctx, bson.M{"task_id": taskID}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	worklogs := []*Worklog{}
+	if err := cursor.All(ctx, &worklogs); err != nil {
+		return nil, err
+	}
+
+	return worklogs, nil
+}
+
+// TotalsByTask sums logged time on a task per user.
+func (dao *WorklogDAO) TotalsByTask(ctx context.Context, taskID primitive.ObjectID) ([]*WorklogTotal, error) {
+	return dao.totals(ctx, bson.M{"task_id": taskID, "running": false}, "$user_id")
+}
+
+// TotalsByUser sums a user's logged time per task within [from, to).
+func (dao *WorklogDAO) TotalsByUser(ctx context.Context, userID primitive.ObjectID, from, to time.Time) ([]*WorklogTotal, error) {
+	match := bson.M{
+		"user_id":    userID,
+		"running":    false,
+		"started_at": bson.M{"$gte": from, "$lt": to},
+	}
+	return dao.totals(ctx, match, "$task_id")
+}
+
+func (dao *WorklogDAO) totals(ctx context.Context, match bson.M, groupBy string) ([]*WorklogTotal, error) {
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: match}},
+		{{Key: "$group", Value: bson.M{
+			"_id":     groupBy,
+			"seconds": bson.M{"$sum": "$duration_seconds"},
+		}}},
+		{{Key: "$sort", Value: bson.D{{Key: "seconds", Value: -1}, {Key: "_id", Value: 1}}}},
+	}
+
+	cursor, err := dao.collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	totals := []*WorklogTotal{}
+	if err := cursor.All(ctx, &totals); err != nil {
+		return nil, err
+	}
+
+	return totals, nil
+}
+
+// Timesheet sums a user's logged time per calendar day (in loc) and task
+// within [from, to). Entries count toward the day they started on.
+func (dao *WorklogDAO) Timesheet(ctx context.Context, userID primitive.ObjectID, from, to time.Time, loc *time.Location) ([]*TimesheetEntry, error) {
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{
+			"user_id":    userID,
+			"running":    false,
+			"started_at": bson.M{"$gte": from, "$lt": to},
+		}}},
+		{{Key: "$group", Value: bson.M{
+			"_id": bson.M{
+				"date": bson.M{"$dateToString": bson.M{
+					"format":   "%Y-%m-%d",
+					"date":     "$started_at",
+					"timezone": loc.String(),
+				}},
+				"task_id": "$task_id",
+			},
+			"seconds": bson.M{"$sum": "$duration_seconds"},
+		}}},
+		{{Key: "$project", Value: bson.M{
+			"_id":     0,
+			"date":    "$_id.date",
+			"task_id": "$_id.task_id",
+			"seconds": 1,
+		}}},
+		{{Key: "$sort", Value: bson.D{{Key: "date", Value: 1}, {Key: "task_id", Value: 1}}}},
+	}
+
+	cursor, err := dao.collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	entries := []*TimesheetEntry{}
+	if err := cursor.All(ctx, &entries); err != nil {
+		return nil, err
+	}
+
+	return entries, nil
+}
@@ -0,0 +1,88 @@
+package models
+
+import (
+	"context"
+	"regexp"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// DefaultWorkspaceSlug names the workspace that pre-existing data is migrated
+// into. Its admins may provision further workspaces.
+const DefaultWorkspaceSlug = "default"
+
+var workspaceSlugPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{1,49}$`)
+
+func ValidWorkspaceSlug(slug string) bool {
+	return workspaceSlugPattern.MatchString(slug)
+}
+
+// Workspace is a tenant. All other documents belong to exactly one workspace.
+type Workspace struct {
+	ID        primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	Slug      string             `json:"slug" bson:"slug" validate:"required,min=2,max=50"`
+	Name      string             `json:"name" bson:"name" validate:"required,min=1,max=100"`
+	CreatedAt time.Time          `json:"created_at" bson:"created_at"`
+}
+
+// WorkspaceDAO manages the workspace registry, which is the one collection
+// that is not itself scoped to a workspace.
+type WorkspaceDAO struct {
+	collection *mongo.Collection
+}
+
+func NewWorkspaceDAO(db *mongo.Database) *WorkspaceDAO {
+	return &WorkspaceDAO{
+		collection: db.Collection("workspaces"),
+	}
+}
+
+func (dao *WorkspaceDAO) Create(ctx context.Context, workspace *Workspace) error {
+	workspace.ID = primitive.NewObjectID()
+	workspace.CreatedAt = time.Now()
+
+	_, err := dao.collection.InsertOne(ctx, workspace)
+	return err
+}
+
+func (dao *WorkspaceDAO) GetByID(ctx context.Context, id primitive.ObjectID) (*Workspace, error) {
+	var workspace Workspace
+	if err := dao.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&workspace); err != nil {
+		return nil, err
+	}
+
+	return &workspace, nil
+}
+
+func (dao *WorkspaceDAO) GetBySlug(ctx context.Context, slug string) (*Workspace, error) {
+	var workspace Workspace
+	if err := dao.collection.FindOne(ctx, bson.M{"slug": slug}).Decode(&workspace); err != nil {
+		return nil, err
+	}
+
+	return &workspace, nil
+}
+
+// EnsureDefault returns the default workspace, creating it on first start.
+func (dao *WorkspaceDAO) EnsureDefault(ctx context.Context) (*Workspace, error) {
+	opts := options.FindOneAndUpdate().
+		SetUpsert(true).
+		SetReturnDocument(options.After)
+
+	update := bson.M{"$setOnInsert": bson.M{
+		"name":       "Default",
+		"created_at": time.Now(),
+	}}
+
+	var workspace Workspace
+	err := dao.collection.FindOneAndUpdate(ctx, bson.M{"slug": DefaultWorkspaceSlug}, update, opts).Decode(&workspace)
+	if err != nil {
+		return nil, err
+	}
+
+	return &workspace, nil
+}
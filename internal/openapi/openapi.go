@@ -0,0 +1,326 @@
+// Package openapi describes the API as an OpenAPI 3 document, built by
+// walking the router and reflecting on the Go types its handlers read and
+// write, and validates JSON values against the schemas in it.
+package openapi
+
+import (
+	"net/http"
+	"reflect"
+	"regexp"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/go-chi/chi/v5"
+)
+
+const Version = "3.0.3"
+
+// Document is an OpenAPI 3.0 document.
+type Document struct {
+	OpenAPI    string                `json:"openapi"`
+	Info       Info                  `json:"info"`
+	Paths      map[string]PathItem   `json:"paths"`
+	Components Components            `json:"components"`
+	Security   []map[string][]string `json:"security"`
+
+	operations []*route
+}
+
+type Info struct {
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"`
+	Version     string `json:"version"`
+}
+
+// PathItem holds the operations of a path by lower-case method.
+type PathItem map[string]*Operation
+
+type Operation struct {
+	OperationID string               `json:"operationId"`
+	Summary     string               `json:"summary,omitempty"`
+	Tags        []string             `json:"tags,omitempty"`
+	Parameters  []*Parameter         `json:"parameters,omitempty"`
+	RequestBody *RequestBody         `json:"requestBody,omitempty"`
+	Responses   map[string]*Response `json:"responses"`
+	// Security is empty, rather than absent, on public operations.
+	Security *[]map[string][]string `json:"security,omitempty"`
+}
+
+type Parameter struct {
+	Name        string  `json:"name"`
+	In          string  `json:"in"`
+	Description string  `json:"description,omitempty"`
+	Required    bool    `json:"required,omitempty"`
+	Schema      *Schema `json:"schema"`
+}
+
+type RequestBody struct {
+	Required bool                  `json:"required,omitempty"`
+	Content  map[string]*MediaType `json:"content"`
+}
+
+type Response struct {
+	Description string                `json:"description"`
+	Content     map[string]*MediaType `json:"content,omitempty"`
+}
+
+type MediaType struct {
+	Schema *Schema `json:"schema,omitempty"`
+}
+
+type Components struct {
+	Schemas         map[string]*Schema         `json:"schemas"`
+	SecuritySchemes map[string]*SecurityScheme `json:"securitySchemes"`
+}
+
+type SecurityScheme struct {
+	Type         string `json:"type"`
+	Scheme       string `json:"scheme"`
+	BearerFormat string `json:"bearerFormat,omitempty"`
+}
+
+// Endpoint documents a handler. Bodies are described by example values of
+// the Go types the handler decodes and encodes; their contents are ignored.
+type Endpoint struct {
+	Summary string
+	// Public endpoints do not require a bearer token.
+	Public bool
+	Params []*Parameter
+	// Request is the JSON request body. Requests lists bodies by content
+	// type for handlers that accept more than JSON.
+	Request  interface{}
+	Requests map[string]interface{}
+	// OptionalBody marks a request body that may be left out.
+	OptionalBody bool
+	// Status is the success status, 200 by default or 204 with no Response.
+	Status   int
+	Response interface{}
+	// ResponseType is the content type of the response, JSON by default.
+	ResponseType string
+	// Bare responses are written as they are rather than inside the
+	// {"data": ...} envelope that successful 200 responses use.
+	Bare bool
+}
+
+// Query documents a query parameter whose type is that of value.
+func Query(name string, value interface{}, description string) *Parameter {
+	return &Parameter{Name: name, In: "query", Description: description, Schema: newSchemas().schema(reflect.TypeOf(value))}
+}
+
+// Header documents a string request header.
+func Header(name, description string) *Parameter {
+	return &Parameter{Name: name, In: "header", Description: description, Schema: &Schema{Type: "string"}}
+}
+
+// Path documents a path parameter, for those that are not IDs.
+func Path(name string, schema *Schema, description string) *Parameter {
+	return &Parameter{Name: name, In: "path", Description: description, Required: true, Schema: schema}
+}
+
+// Enum returns a string schema limited to values.
+func Enum(values ...string) *Schema {
+	schema := &Schema{Type: "string"}
+	for _, value := range values {
+		schema.Enum = append(schema.Enum, value)
+	}
+	return schema
+}
+
+// Spec builds the document for a router on first use, once every route has
+// been registered.
+type Spec struct {
+	routes    chi.Routes
+	info      Info
+	endpoints map[string]Endpoint
+	problem   interface{}
+
+	once         sync.Once
+	doc          *Document
+	undocumented []string
+}
+
+// NewSpec describes routes. Endpoints are keyed by handler, as
+// "TaskHandler.Create" for a method or "ProblemType" for a function, and
+// problem is the body of error responses.
+func NewSpec(routes chi.Routes, info Info, endpoints map[string]Endpoint, problem interface{}) *Spec {
+	return &Spec{
+		routes:    routes,
+		info:      info,
+		endpoints: endpoints,
+		problem:   problem,
+	}
+}
+
+// Document returns the document, building it on the first call.
+func (s *Spec) Document() *Document {
+	s.once.Do(s.build)
+	return s.doc
+}
+
+// Undocumented lists the routes whose handler has no endpoint. They appear
+// in the document without a summary or body.
+func (s *Spec) Undocumented() []string {
+	s.once.Do(s.build)
+	return s.undocumented
+}
+
+func (s *Spec) build() {
+	gen := newSchemas()
+	doc := &Document{
+		OpenAPI: Version,
+		Info:    s.info,
+		Paths:   map[string]PathItem{},
+		Components: Components{
+			Schemas: gen.components,
+			SecuritySchemes: map[string]*SecurityScheme{
+				"bearerAuth": {Type: "http", Scheme: "bearer", BearerFormat: "JWT"},
+			},
+		},
+		Security: []map[string][]string{{"bearerAuth": {}}},
+	}
+	problem := gen.schema(reflect.TypeOf(s.problem))
+
+	chi.Walk(s.routes, func(method, pattern string, handler http.Handler, _ ...func(http.Handler) http.Handler) error {
+		path := pattern
+		if len(path) > 1 {
+			path = strings.TrimSuffix(path, "/")
+		}
+
+		name := handlerName(handler)
+		endpoint, ok := s.endpoints[name]
+		if !ok {
+			s.undocumented = append(s.undocumented, method+" "+path)
+		}
+
+		op := endpoint.operation(gen, method, path, problem)
+		if doc.Paths[path] == nil {
+			doc.Paths[path] = PathItem{}
+		}
+		doc.Paths[path][strings.ToLower(method)] = op
+		doc.operations = append(doc.operations, &route{
+			method:    method,
+			pattern:   pathPattern(path),
+			params:    strings.Count(path, "{"),
+			operation: op,
+		})
+		return nil
+	})
+
+	sort.Strings(s.undocumented)
+	s.doc = doc
+}
+
+func (e *Endpoint) operation(gen *schemas, method, path string, problem *Schema) *Operation {
+	op := &Operation{
+		OperationID: operationID(method, path),
+		Summary:     e.Summary,
+		Tags:        []string{tag(path)},
+		Responses:   map[string]*Response{},
+	}
+	if e.Public {
+		op.Security = &[]map[string][]string{}
+	}
+
+	documented := map[string]bool{}
+	for _, param := range e.Params {
+		documented[param.In+":"+param.Name] = true
+	}
+	for _, segment := range strings.Split(path, "/") {
+		name, ok := strings.CutPrefix(segment, "{")
+		if !ok {
+			continue
+		}
+		name = strings.TrimSuffix(name, "}")
+		if !documented["path:"+name] {
+			op.Parameters = append(op.Parameters, &Parameter{Name: name, In: "path", Required: true, Schema: &Schema{Type: "string", Pattern: ObjectIDPattern}})
+		}
+	}
+	op.Parameters = append(op.Parameters, e.Params...)
+	if method == http.MethodPost && !e.Public {
+		op.Parameters = append(op.Parameters, Header("Idempotency-Key", "Makes the request safe to retry; see the README"))
+	}
+
+	requests := e.Requests
+	if e.Request != nil {
+		requests = map[string]interface{}{"application/json": e.Request}
+	}
+	if len(requests) > 0 {
+		op.RequestBody = &RequestBody{Required: !e.OptionalBody, Content: map[string]*MediaType{}}
+		for contentType, body := range requests {
+			op.RequestBody.Content[contentType] = &MediaType{Schema: gen.schema(reflect.TypeOf(body))}
+		}
+	}
+
+	status := e.Status
+	if status == 0 {
+		status = http.StatusOK
+		if e.Response == nil {
+			status = http.StatusNoContent
+		}
+	}
+	response := &Response{Description: http.StatusText(status)}
+	if e.Response != nil {
+		schema := gen.schema(reflect.TypeOf(e.Response))
+		if status == http.StatusOK && !e.Bare {
+			schema = &Schema{Type: "object", Properties: map[string]*Schema{"data": schema}}
+		}
+		contentType := e.ResponseType
+		if contentType == "" {
+			contentType = "application/json"
+		}
+		response.Content = map[string]*MediaType{contentType: {Schema: schema}}
+	}
+	op.Responses[strconv.Itoa(status)] = response
+	op.Responses["default"] = &Response{
+		Description: "Error",
+		Content:     map[string]*MediaType{"application/problem+json": {Schema: problem}},
+	}
+	return op
+}
+
+// handlerName names the function behind a handler as "Type.Method" or
+// "Function".
+func handlerName(handler http.Handler) string {
+	fn, ok := handler.(http.HandlerFunc)
+	if !ok {
+		return ""
+	}
+	name := runtime.FuncForPC(reflect.ValueOf(fn).Pointer()).Name()
+	name = name[strings.LastIndex(name, "/")+1:]
+	name = strings.TrimSuffix(name, "-fm")
+	name = strings.NewReplacer("(*", "", ")", "").Replace(name)
+	_, name, _ = strings.Cut(name, ".")
+	return name
+}
+
+func operationID(method, path string) string {
+	id := strings.ToLower(method)
+	for _, segment := range strings.Split(path, "/") {
+		segment = strings.Trim(segment, "{}")
+		if segment != "" {
+			id += "_" + strings.NewReplacer(".", "_", "-", "_").Replace(segment)
+		}
+	}
+	return id
+}
+
+// tag groups an operation by the first segment of its path after the
+// version.
+func tag(path string) string {
+	for _, segment := range strings.Split(path, "/") {
+		if segment != "" && segment != "v1" {
+			segment, _, _ = strings.Cut(segment, ".")
+			return segment
+		}
+	}
+	return "root"
+}
+
+var pathParam = regexp.MustCompile(`\\\{[^/]+\}`)
+
+func pathPattern(path string) *regexp.Regexp {
+	return regexp.MustCompile("^" + pathParam.ReplaceAllString(regexp.QuoteMeta(path), "[^/]+") + "/?$")
+}
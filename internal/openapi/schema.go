@@ -0,0 +1,255 @@
+package openapi
+
+import (
+	"encoding/json"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ObjectIDPattern matches the hexadecimal form of a MongoDB ObjectID.
+const ObjectIDPattern = "^[0-9a-fA-F]{24}$"
+
+// Schema is an OpenAPI 3.0 schema object, limited to the keywords the API
+// uses.
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Pattern              string             `json:"pattern,omitempty"`
+	Enum                 []interface{}      `json:"enum,omitempty"`
+	Default              interface{}        `json:"default,omitempty"`
+	Nullable             bool               `json:"nullable,omitempty"`
+	MinLength            *int               `json:"minLength,omitempty"`
+	MaxLength            *int               `json:"maxLength,omitempty"`
+	Minimum              *float64           `json:"minimum,omitempty"`
+	Maximum              *float64           `json:"maximum,omitempty"`
+	MinItems             *int               `json:"minItems,omitempty"`
+	MaxItems             *int               `json:"maxItems,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+	AllOf                []*Schema          `json:"allOf,omitempty"`
+}
+
+// File stands for a body of raw bytes, such as an uploaded or downloaded
+// attachment.
+type File struct{}
+
+var (
+	timeType     = reflect.TypeOf(time.Time{})
+	objectIDType = reflect.TypeOf(primitive.ObjectID{})
+	rawType      = reflect.TypeOf(json.RawMessage{})
+	fileType     = reflect.TypeOf(File{})
+)
+
+// schemas generates schemas from Go types as encoding/json encodes them.
+// Named struct types become components, referenced by their exported name.
+type schemas struct {
+	components map[string]*Schema
+	names      map[reflect.Type]string
+}
+
+func newSchemas() *schemas {
+	return &schemas{
+		components: map[string]*Schema{},
+		names:      map[reflect.Type]string{},
+	}
+}
+
+func (s *schemas) schema(t reflect.Type) *Schema {
+	switch t {
+	case timeType:
+		return &Schema{Type: "string", Format: "date-time"}
+	case objectIDType:
+		return &Schema{Type: "string", Pattern: ObjectIDPattern}
+	case rawType:
+		return &Schema{}
+	case fileType:
+		return &Schema{Type: "string", Format: "binary"}
+	}
+
+	switch t.Kind() {
+	case reflect.Pointer:
+		elem := s.schema(t.Elem())
+		if elem.Ref != "" {
+			return &Schema{AllOf: []*Schema{elem}, Nullable: true}
+		}
+		elem.Nullable = true
+		return elem
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return &Schema{Type: "integer", Format: "int32"}
+	case reflect.Int64, reflect.Uint64:
+		return &Schema{Type: "integer", Format: "int64"}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}
+	case reflect.Slice:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string", Format: "byte", Nullable: true}
+		}
+		return &Schema{Type: "array", Items: s.schema(t.Elem()), Nullable: true}
+	case reflect.Array:
+		return &Schema{Type: "array", Items: s.schema(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: s.schema(t.Elem()), Nullable: true}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return s.object(t)
+		}
+		return &Schema{Ref: "#/components/schemas/" + s.component(t)}
+	}
+	// Interfaces hold any JSON value.
+	return &Schema{}
+}
+
+// component registers the schema of a named struct type and returns its
+// name.
+func (s *schemas) component(t reflect.Type) string {
+	if name, ok := s.names[t]; ok {
+		return name
+	}
+
+	name := exportedName(t.Name())
+	for i := 2; s.components[name] != nil; i++ {
+		name = exportedName(t.Name()) + strconv.Itoa(i)
+	}
+	s.names[t] = name
+	// Reserve the name before recursing, for types that refer to themselves.
+	s.components[name] = &Schema{}
+	*s.components[name] = *s.object(t)
+	return name
+}
+
+func (s *schemas) object(t reflect.Type) *Schema {
+	obj := &Schema{Type: "object", Properties: map[string]*Schema{}}
+	s.addFields(obj, t)
+	return obj
+}
+
+func (s *schemas) addFields(obj *Schema, t reflect.Type) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if field.Anonymous && name == "" {
+			embedded := field.Type
+			if embedded.Kind() == reflect.Pointer {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				s.addFields(obj, embedded)
+				continue
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		prop := s.schema(field.Type)
+		required := applyRules(prop, field.Tag.Get("validate"), field.Type.Kind() == reflect.Pointer)
+		// Fields the server fills in when they are left out are tagged
+		// openapi:"default=<value>".
+		if value, ok := strings.CutPrefix(field.Tag.Get("openapi"), "default="); ok {
+			prop.Default = value
+			required = false
+		}
+		if required {
+			obj.Required = append(obj.Required, name)
+		}
+		obj.Properties[name] = prop
+	}
+}
+
+// applyRules narrows a property's schema with the validator rules in tag,
+// reporting whether the property is required. Rules after dive apply to the
+// elements of a slice or the values of a map. omitempty lets the zero value
+// of a non-pointer through, so lower bounds no longer hold.
+func applyRules(prop *Schema, tag string, pointer bool) bool {
+	if tag == "" {
+		return false
+	}
+
+	rules, elemRules, dive := strings.Cut(tag, ",dive")
+	if dive {
+		elem := prop.Items
+		if elem == nil {
+			elem = prop.AdditionalProperties
+		}
+		if elem != nil && elem.Ref == "" {
+			applyRules(elem, strings.TrimPrefix(elemRules, ","), false)
+		}
+	}
+
+	required := false
+	omitEmpty := false
+	for _, rule := range strings.Split(rules, ",") {
+		name, param, _ := strings.Cut(rule, "=")
+		if omitEmpty && !pointer && (name == "min" || name == "gte") {
+			continue
+		}
+		switch name {
+		case "omitempty":
+			omitEmpty = true
+		case "required":
+			required = true
+		case "email":
+			prop.Format = "email"
+		case "oneof":
+			if prop.Type == "string" {
+				for _, value := range strings.Fields(param) {
+					prop.Enum = append(prop.Enum, value)
+				}
+			}
+		case "min", "max", "gte", "lte":
+			n, err := strconv.ParseFloat(param, 64)
+			if err != nil {
+				continue
+			}
+			lower := name == "min" || name == "gte"
+			switch prop.Type {
+			case "string":
+				setBound(lower, &prop.MinLength, &prop.MaxLength, int(n))
+			case "array":
+				setBound(lower, &prop.MinItems, &prop.MaxItems, int(n))
+			case "integer", "number":
+				if lower {
+					prop.Minimum = &n
+				} else {
+					prop.Maximum = &n
+				}
+			}
+		}
+	}
+	return required
+}
+
+func setBound(lower bool, min, max **int, n int) {
+	if lower {
+		*min = &n
+	} else {
+		*max = &n
+	}
+}
+
+func exportedName(name string) string {
+	if name == "" {
+		return name
+	}
+	r := []rune(name)
+	r[0] = unicode.ToUpper(r[0])
+	return string(r)
+}
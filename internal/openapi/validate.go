@@ -0,0 +1,287 @@
+package openapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net/mail"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// maxViolations bounds the violations reported for one value.
+const maxViolations = 20
+
+type route struct {
+	method    string
+	pattern   *regexp.Regexp
+	params    int
+	operation *Operation
+}
+
+// Violation is a place where a value does not match its schema. Path uses
+// the field[index].field form of validation errors and is empty for the
+// value itself; Rule is the schema keyword that failed.
+type Violation struct {
+	Path    string
+	Rule    string
+	Message string
+}
+
+func (v Violation) Error() string {
+	if v.Path == "" {
+		return v.Message
+	}
+	return v.Path + ": " + v.Message
+}
+
+// Match finds the operation for a request. Literal path segments take
+// precedence over parameters, as they do in the router.
+func (d *Document) Match(method, path string) (*Operation, bool) {
+	var match *route
+	for _, r := range d.operations {
+		if r.method != method || !r.pattern.MatchString(path) {
+			continue
+		}
+		if match == nil || r.params < match.params {
+			match = r
+		}
+	}
+	if match == nil {
+		return nil, false
+	}
+	return match.operation, true
+}
+
+// RequestSchema returns the schema of the operation's request body for a
+// content type.
+func (op *Operation) RequestSchema(contentType string) (*Schema, bool) {
+	if op.RequestBody == nil {
+		return nil, false
+	}
+	return mediaSchema(op.RequestBody.Content, contentType)
+}
+
+// ResponseSchema returns the schema of the operation's response for a
+// status and content type, falling back to the default response.
+func (op *Operation) ResponseSchema(status int, contentType string) (*Schema, bool) {
+	response, ok := op.Responses[strconv.Itoa(status)]
+	if !ok {
+		response, ok = op.Responses["default"]
+	}
+	if !ok {
+		return nil, false
+	}
+	return mediaSchema(response.Content, contentType)
+}
+
+func mediaSchema(content map[string]*MediaType, contentType string) (*Schema, bool) {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return nil, false
+	}
+	media, ok := content[mediaType]
+	if !ok || media.Schema == nil {
+		return nil, false
+	}
+	return media.Schema, true
+}
+
+// ValidateRequest checks a JSON request body against schema.
+func (d *Document) ValidateRequest(schema *Schema, body []byte) []Violation {
+	return d.validate(schema, body, true)
+}
+
+// ValidateResponse checks a JSON response body against schema. Required
+// properties are those clients must send, so they are not enforced on
+// responses, which may leave fields out, as sparse fieldsets do.
+func (d *Document) ValidateResponse(schema *Schema, body []byte) []Violation {
+	return d.validate(schema, body, false)
+}
+
+func (d *Document) validate(schema *Schema, body []byte, request bool) []Violation {
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+	var value interface{}
+	if err := dec.Decode(&value); err != nil {
+		return []Violation{{Rule: "type", Message: "is not valid JSON"}}
+	}
+
+	v := &validator{doc: d, request: request}
+	v.check(schema, value, "")
+	return v.violations
+}
+
+type validator struct {
+	doc        *Document
+	request    bool
+	violations []Violation
+}
+
+func (v *validator) fail(path, rule, format string, args ...interface{}) {
+	if len(v.violations) < maxViolations {
+		v.violations = append(v.violations, Violation{Path: path, Rule: rule, Message: fmt.Sprintf(format, args...)})
+	}
+}
+
+func (v *validator) check(schema *Schema, value interface{}, path string) {
+	if schema.Ref != "" {
+		resolved, ok := v.doc.Components.Schemas[strings.TrimPrefix(schema.Ref, "#/components/schemas/")]
+		if !ok {
+			v.fail(path, "$ref", "refers to unknown schema %s", schema.Ref)
+			return
+		}
+		schema = resolved
+	}
+
+	if value == nil {
+		if !schema.Nullable && (schema.Type != "" || len(schema.AllOf) > 0) {
+			v.fail(path, "nullable", "must not be null")
+		}
+		return
+	}
+	for _, sub := range schema.AllOf {
+		v.check(sub, value, path)
+	}
+
+	switch schema.Type {
+	case "string":
+		s, ok := value.(string)
+		if !ok {
+			v.fail(path, "type", "must be a string")
+			return
+		}
+		v.checkString(schema, s, path)
+
+	case "integer", "number":
+		n, ok := value.(json.Number)
+		if !ok {
+			v.fail(path, "type", "must be %s", article(schema.Type))
+			return
+		}
+		if schema.Type == "integer" {
+			if _, err := n.Int64(); err != nil {
+				v.fail(path, "type", "must be an integer")
+				return
+			}
+		}
+		f, _ := n.Float64()
+		if schema.Minimum != nil && f < *schema.Minimum {
+			v.fail(path, "minimum", "must be at least %v", *schema.Minimum)
+		}
+		if schema.Maximum != nil && f > *schema.Maximum {
+			v.fail(path, "maximum", "must be at most %v", *schema.Maximum)
+		}
+
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			v.fail(path, "type", "must be a boolean")
+		}
+
+	case "array":
+		items, ok := value.([]interface{})
+		if !ok {
+			v.fail(path, "type", "must be an array")
+			return
+		}
+		if schema.MinItems != nil && len(items) < *schema.MinItems {
+			v.fail(path, "minItems", "must have at least %d items", *schema.MinItems)
+		}
+		if schema.MaxItems != nil && len(items) > *schema.MaxItems {
+			v.fail(path, "maxItems", "must have at most %d items", *schema.MaxItems)
+		}
+		if schema.Items != nil {
+			for i, item := range items {
+				v.check(schema.Items, item, path+"["+strconv.Itoa(i)+"]")
+			}
+		}
+
+	case "object":
+		obj, ok := value.(map[string]interface{})
+		if !ok {
+			v.fail(path, "type", "must be an object")
+			return
+		}
+		if v.request {
+			for _, name := range schema.Required {
+				if _, ok := obj[name]; !ok {
+					v.fail(join(path, name), "required", "is required")
+				}
+			}
+		}
+		names := make([]string, 0, len(obj))
+		for name := range obj {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			item := obj[name]
+			if prop, ok := schema.Properties[name]; ok {
+				v.check(prop, item, join(path, name))
+			} else if schema.AdditionalProperties != nil {
+				v.check(schema.AdditionalProperties, item, join(path, name))
+			}
+		}
+	}
+}
+
+func (v *validator) checkString(schema *Schema, s, path string) {
+	length := utf8.RuneCountInString(s)
+	if schema.MinLength != nil && length < *schema.MinLength {
+		v.fail(path, "minLength", "must be at least %d characters", *schema.MinLength)
+	}
+	if schema.MaxLength != nil && length > *schema.MaxLength {
+		v.fail(path, "maxLength", "must be at most %d characters", *schema.MaxLength)
+	}
+	if schema.Pattern != "" {
+		if re, err := regexp.Compile(schema.Pattern); err == nil && !re.MatchString(s) {
+			v.fail(path, "pattern", "must match %s", schema.Pattern)
+		}
+	}
+	if len(schema.Enum) > 0 {
+		found := false
+		for _, allowed := range schema.Enum {
+			if allowed == s {
+				found = true
+				break
+			}
+		}
+		if !found {
+			allowed := make([]string, len(schema.Enum))
+			for i, value := range schema.Enum {
+				allowed[i] = fmt.Sprint(value)
+			}
+			v.fail(path, "enum", "must be one of %s", strings.Join(allowed, ", "))
+		}
+	}
+
+	switch schema.Format {
+	case "date-time":
+		if _, err := time.Parse(time.RFC3339Nano, s); err != nil {
+			v.fail(path, "format", "must be an RFC 3339 date-time")
+		}
+	case "email":
+		if _, err := mail.ParseAddress(s); err != nil {
+			v.fail(path, "format", "must be a valid email address")
+		}
+	}
+}
+
+func join(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
+
+func article(typ string) string {
+	if typ == "integer" {
+		return "an integer"
+	}
+	return "a number"
+}
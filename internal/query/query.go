@@ -0,0 +1,279 @@
+// Package query parses compact filter expressions such as
+//
+//	status in (open, in_progress) and (labels = urgent or created_at >= 2024-01-01)
+//
+// into MongoDB queries. Only fields known to a Resolver can be referenced,
+// and every literal is converted by its field before it reaches the query.
+//
+// Conditions are "field op value" with op one of = != < <= > >= ~ (contains,
+// case-insensitive), or "field [not] in (v1, v2, ...)". Values are bare words
+// or double-quoted strings with \" and \\ escapes; null matches missing
+// values. Conditions combine with and, or, not and parentheses; and binds
+// tighter than or. Keywords are case-insensitive.
+package query
+
+import (
+	"fmt"
+	"regexp"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+const (
+	MaxLength     = 2000
+	maxConditions = 50
+	maxDepth      = 10
+)
+
+// Field describes how a filterable field is queried.
+type Field struct {
+	// Path is the document path the field is stored at.
+	Path string
+	// Parse converts a literal to the stored type.
+	Parse func(string) (interface{}, error)
+	// Ordered fields support < <= > >=; Text fields support ~.
+	Ordered bool
+	Text    bool
+}
+
+// Resolver returns the field for a name used in an expression.
+type Resolver func(name string) (*Field, error)
+
+// Error reports an invalid expression and the byte offset it was found at.
+type Error struct {
+	Pos int
+	Msg string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("filter: %s at position %d", e.Msg, e.Pos)
+}
+
+// Parse compiles expr into a MongoDB query document.
+func Parse(expr string, resolve Resolver) (bson.M, error) {
+	if len(expr) > MaxLength {
+		return nil, &Error{Pos: MaxLength, Msg: fmt.Sprintf("expression is longer than %d characters", MaxLength)}
+	}
+
+	tokens, err := lex(expr)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &parser{tokens: tokens, resolve: resolve}
+	query, err := p.or(0)
+	if err != nil {
+		return nil, err
+	}
+	if tok := p.peek(); tok.kind != tokenEOF {
+		return nil, &Error{Pos: tok.pos, Msg: fmt.Sprintf("unexpected %s", tok)}
+	}
+
+	return query, nil
+}
+
+type parser struct {
+	tokens     []token
+	pos        int
+	conditions int
+	resolve    Resolver
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	tok := p.tokens[p.pos]
+	if tok.kind != tokenEOF {
+		p.pos++
+	}
+	return tok
+}
+
+func (p *parser) expect(kind tokenKind, what string) (token, error) {
+	tok := p.next()
+	if tok.kind != kind {
+		return tok, &Error{Pos: tok.pos, Msg: fmt.Sprintf("expected %s, found %s", what, tok)}
+	}
+	return tok, nil
+}
+
+func (p *parser) or(depth int) (bson.M, error) {
+	return p.list(depth, "or", "$or", p.and)
+}
+
+func (p *parser) and(depth int) (bson.M, error) {
+	return p.list(depth, "and", "$and", p.unary)
+}
+
+// list parses operands separated by keyword and combines them with op.
+func (p *parser) list(depth int, keyword, op string, operand func(int) (bson.M, error)) (bson.M, error) {
+	first, err := operand(depth)
+	if err != nil {
+		return nil, err
+	}
+
+	operands := bson.A{first}
+	for p.peek().isKeyword(keyword) {
+		p.next()
+		next, err := operand(depth)
+		if err != nil {
+			return nil, err
+		}
+		operands = append(operands, next)
+	}
+
+	if len(operands) == 1 {
+		return first, nil
+	}
+	return bson.M{op: operands}, nil
+}
+
+func (p *parser) unary(depth int) (bson.M, error) {
+	tok := p.peek()
+	if depth > maxDepth {
+		return nil, &Error{Pos: tok.pos, Msg: "expression is nested too deeply"}
+	}
+
+	if tok.isKeyword("not") {
+		p.next()
+		operand, err := p.unary(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		return bson.M{"$nor": bson.A{operand}}, nil
+	}
+
+	if tok.kind == tokenLParen {
+		p.next()
+		inner, err := p.or(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := p.expect(tokenRParen, `")"`); err != nil {
+			return nil, err
+		}
+		return inner, nil
+	}
+
+	return p.condition()
+}
+
+func (p *parser) condition() (bson.M, error) {
+	name, err := p.expect(tokenWord, "a field name")
+	if err != nil {
+		return nil, err
+	}
+
+	p.conditions++
+	if p.conditions > maxConditions {
+		return nil, &Error{Pos: name.pos, Msg: fmt.Sprintf("expression has more than %d conditions", maxConditions)}
+	}
+
+	field, err := p.resolve(name.text)
+	if err != nil {
+		return nil, &Error{Pos: name.pos, Msg: err.Error()}
+	}
+
+	op := p.next()
+	switch {
+	case op.isKeyword("in"):
+		return p.in(field, name.text, "$in")
+	case op.isKeyword("not"):
+		if _, err := p.expectKeyword("in"); err != nil {
+			return nil, err
+		}
+		return p.in(field, name.text, "$nin")
+	case op.kind != tokenOperator:
+		return nil, &Error{Pos: op.pos, Msg: fmt.Sprintf("expected an operator after %s, found %s", name.text, op)}
+	}
+
+	switch op.text {
+	case "<", "<=", ">", ">=":
+		if !field.Ordered {
+			return nil, &Error{Pos: op.pos, Msg: fmt.Sprintf("%s cannot be compared with %s", name.text, op.text)}
+		}
+	case "~":
+		if !field.Text {
+			return nil, &Error{Pos: op.pos, Msg: fmt.Sprintf("%s does not support ~", name.text)}
+		}
+	}
+
+	valueTok := p.next()
+	if valueTok.kind != tokenWord && valueTok.kind != tokenString {
+		return nil, &Error{Pos: valueTok.pos, Msg: fmt.Sprintf("expected a value, found %s", valueTok)}
+	}
+
+	if op.text == "~" {
+		return bson.M{field.Path: bson.M{"$regex": regexp.QuoteMeta(valueTok.text), "$options": "i"}}, nil
+	}
+
+	value, err := p.value(field, name.text, valueTok)
+	if err != nil {
+		return nil, err
+	}
+
+	switch op.text {
+	case "=":
+		return bson.M{field.Path: value}, nil
+	case "!=":
+		return bson.M{field.Path: bson.M{"$ne": value}}, nil
+	}
+
+	if value == nil {
+		return nil, &Error{Pos: valueTok.pos, Msg: "null can only be compared with = and !="}
+	}
+	ops := map[string]string{"<": "$lt", "<=": "$lte", ">": "$gt", ">=": "$gte"}
+	return bson.M{field.Path: bson.M{ops[op.text]: value}}, nil
+}
+
+func (p *parser) in(field *Field, name, op string) (bson.M, error) {
+	if _, err := p.expect(tokenLParen, `"("`); err != nil {
+		return nil, err
+	}
+
+	values := bson.A{}
+	for {
+		tok := p.next()
+		if tok.kind != tokenWord && tok.kind != tokenString {
+			return nil, &Error{Pos: tok.pos, Msg: fmt.Sprintf("expected a value, found %s", tok)}
+		}
+		value, err := p.value(field, name, tok)
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+
+		sep := p.next()
+		if sep.kind == tokenRParen {
+			break
+		}
+		if sep.kind != tokenComma {
+			return nil, &Error{Pos: sep.pos, Msg: fmt.Sprintf(`expected "," or ")", found %s`, sep)}
+		}
+	}
+
+	return bson.M{field.Path: bson.M{op: values}}, nil
+}
+
+func (p *parser) expectKeyword(keyword string) (token, error) {
+	tok := p.next()
+	if !tok.isKeyword(keyword) {
+		return tok, &Error{Pos: tok.pos, Msg: fmt.Sprintf("expected %q, found %s", keyword, tok)}
+	}
+	return tok, nil
+}
+
+// value converts a literal; the bare word null stands for a missing value.
+func (p *parser) value(field *Field, name string, tok token) (interface{}, error) {
+	if tok.kind == tokenWord && tok.isKeyword("null") {
+		return nil, nil
+	}
+
+	value, err := field.Parse(tok.text)
+	if err != nil {
+		return nil, &Error{Pos: tok.pos, Msg: fmt.Sprintf("invalid value for %s: %s", name, err)}
+	}
+	return value, nil
+}
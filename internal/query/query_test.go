@@ -0,0 +1,222 @@
+package query
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func parseString(s string) (interface{}, error) { return s, nil }
+
+func parseDate(s string) (interface{}, error) {
+	return time.Parse("2006-01-02", s)
+}
+
+func parseInt(s string) (interface{}, error) {
+	return strconv.Atoi(s)
+}
+
+var testFields = map[string]*Field{
+	"status":     {Path: "status", Parse: parseString},
+	"labels":     {Path: "labels", Parse: parseString},
+	"title":      {Path: "title", Parse: parseString, Text: true},
+	"points":     {Path: "custom_fields.points", Parse: parseInt, Ordered: true},
+	"created_at": {Path: "created_at", Parse: parseDate, Ordered: true},
+}
+
+func resolve(name string) (*Field, error) {
+	field, ok := testFields[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown field %s", name)
+	}
+	return field, nil
+}
+
+func TestParse(t *testing.T) {
+	jan1 := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name string
+		expr string
+		want bson.M
+	}{
+		{"equals", "status = open", bson.M{"status": "open"}},
+		{"not equals", "status != done", bson.M{"status": bson.M{"$ne": "done"}}},
+		{"field path", "points > 3", bson.M{"custom_fields.points": bson.M{"$gt": 3}}},
+		{"ordered", "created_at >= 2024-01-01", bson.M{"created_at": bson.M{"$gte": jan1}}},
+		{"operators without spaces", "points<=3", bson.M{"custom_fields.points": bson.M{"$lte": 3}}},
+		{"null", "status = null", bson.M{"status": nil}},
+		{"not null", "status != NULL", bson.M{"status": bson.M{"$ne": nil}}},
+		{"quoted null", `status = "null"`, bson.M{"status": "null"}},
+		{"in", "status in (open, in_progress)", bson.M{"status": bson.M{"$in": bson.A{"open", "in_progress"}}}},
+		{"not in", "status NOT IN (done)", bson.M{"status": bson.M{"$nin": bson.A{"done"}}}},
+		{"quoted", `labels = "needs review"`, bson.M{"labels": "needs review"}},
+		{"escapes", `title = "say \"hi\" \\ now"`, bson.M{"title": `say "hi" \ now`}},
+		{"other backslashes kept", `title = "a\nb"`, bson.M{"title": `a\nb`}},
+		{"quoted keyword", `labels = "and"`, bson.M{"labels": "and"}},
+		{
+			"and binds tighter than or",
+			"status = open or labels = urgent and points > 3",
+			bson.M{"$or": bson.A{
+				bson.M{"status": "open"},
+				bson.M{"$and": bson.A{
+					bson.M{"labels": "urgent"},
+					bson.M{"custom_fields.points": bson.M{"$gt": 3}},
+				}},
+			}},
+		},
+		{
+			"parentheses",
+			"(status = open or labels = urgent) and points > 3",
+			bson.M{"$and": bson.A{
+				bson.M{"$or": bson.A{
+					bson.M{"status": "open"},
+					bson.M{"labels": "urgent"},
+				}},
+				bson.M{"custom_fields.points": bson.M{"$gt": 3}},
+			}},
+		},
+		{
+			"not binds to one condition",
+			"not status = done and labels = urgent",
+			bson.M{"$and": bson.A{
+				bson.M{"$nor": bson.A{bson.M{"status": "done"}}},
+				bson.M{"labels": "urgent"},
+			}},
+		},
+		{
+			"keywords are case-insensitive",
+			"status = open AND labels = a Or labels = b",
+			bson.M{"$or": bson.A{
+				bson.M{"$and": bson.A{
+					bson.M{"status": "open"},
+					bson.M{"labels": "a"},
+				}},
+				bson.M{"labels": "b"},
+			}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Parse(tt.expr, resolve)
+			if err != nil {
+				t.Fatalf("Parse(%q): %v", tt.expr, err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Parse(%q) = %v, want %v", tt.expr, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestContainsIsQuoted(t *testing.T) {
+	tests := []string{"a.b*(c", `x|y`, `^$`, `[a-z]+`, `\d`}
+
+	for _, value := range tests {
+		expr := `title ~ "` + strings.ReplaceAll(value, `\`, `\\`) + `"`
+		got, err := Parse(expr, resolve)
+		if err != nil {
+			t.Fatalf("Parse(%q): %v", expr, err)
+		}
+		want := bson.M{"title": bson.M{"$regex": regexp.QuoteMeta(value), "$options": "i"}}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("Parse(%q) = %v, want %v", expr, got, want)
+		}
+
+		re := regexp.MustCompile("(?i)" + regexp.QuoteMeta(value))
+		if !re.MatchString("prefix " + strings.ToUpper(value) + " suffix") {
+			t.Errorf("pattern for %q does not match the literal text", value)
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		expr string
+		pos  int
+		msg  string
+	}{
+		{"unknown field", "nope = 1", 0, "unknown field nope"},
+		{"not ordered", "title < x", 6, "title cannot be compared with <"},
+		{"not text", "status ~ x", 7, "status does not support ~"},
+		{"ordered null", "created_at < null", 13, "null can only be compared with = and !="},
+		{"invalid value", "created_at = yesterday", 13, "invalid value for created_at"},
+		{"invalid value in list", "points in (1, two)", 14, "invalid value for points"},
+		{"bare bang", "status ! open", 7, `expected "!="`},
+		{"unterminated string", `title = "abc`, 8, "unterminated string"},
+		{"missing operator", "status open", 7, `expected an operator after status, found "open"`},
+		{"missing value", "status =", 8, "expected a value, found end of expression"},
+		{"dangling and", "status = open and", 17, "expected a field name, found end of expression"},
+		{"trailing token", "status = open )", 14, `unexpected ")"`},
+		{"unclosed parenthesis", "(status = open", 14, `expected ")", found end of expression`},
+		{"unclosed list", "status in (open", 15, `expected "," or ")", found end of expression`},
+		{"empty list", "status in ()", 11, `expected a value, found ")"`},
+		{"not without in", "status not open", 11, `expected "in", found "open"`},
+		{"empty expression", "", 0, "expected a field name, found end of expression"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse(tt.expr, resolve)
+			var qerr *Error
+			if !errors.As(err, &qerr) {
+				t.Fatalf("Parse(%q) error = %v, want *Error", tt.expr, err)
+			}
+			if qerr.Pos != tt.pos || !strings.Contains(qerr.Msg, tt.msg) {
+				t.Errorf("Parse(%q) error = %q at %d, want %q at %d", tt.expr, qerr.Msg, qerr.Pos, tt.msg, tt.pos)
+			}
+		})
+	}
+}
+
+func TestLimits(t *testing.T) {
+	nested := func(prefix, suffix string, depth int) string {
+		return strings.Repeat(prefix, depth) + "status = open" + strings.Repeat(suffix, depth)
+	}
+	conditions := func(n int) string {
+		parts := make([]string, n)
+		for i := range parts {
+			parts[i] = "status = open"
+		}
+		return strings.Join(parts, " or ")
+	}
+
+	tests := []struct {
+		name string
+		expr string
+		msg  string
+	}{
+		{"parentheses at the limit", nested("(", ")", maxDepth), ""},
+		{"parentheses too deep", nested("(", ")", maxDepth+1), "expression is nested too deeply"},
+		{"not at the limit", nested("not ", "", maxDepth), ""},
+		{"not too deep", nested("not ", "", maxDepth+1), "expression is nested too deeply"},
+		{"conditions at the limit", conditions(maxConditions), ""},
+		{"too many conditions", conditions(maxConditions + 1), fmt.Sprintf("more than %d conditions", maxConditions)},
+		{"too long", "status = " + strings.Repeat("x", MaxLength), fmt.Sprintf("longer than %d characters", MaxLength)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse(tt.expr, resolve)
+			if tt.msg == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			var qerr *Error
+			if !errors.As(err, &qerr) || !strings.Contains(qerr.Msg, tt.msg) {
+				t.Errorf("error = %v, want %q", err, tt.msg)
+			}
+		})
+	}
+}
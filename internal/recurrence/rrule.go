@@ -0,0 +1,322 @@
+package recurrence
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Rule is the subset of an RFC 5545 RRULE supported for recurring tasks:
+// FREQ, INTERVAL, COUNT, UNTIL, BYDAY, BYMONTHDAY and BYMONTH.
+type Rule struct {
+	Freq       Frequency
+	Interval   int
+	Count      int
+	Until      time.Time
+	ByDay      []Weekday
+	ByMonthDay []int
+	ByMonth    []time.Month
+}
+
+type Frequency string
+
+const (
+	Daily   Frequency = "DAILY"
+	Weekly  Frequency = "WEEKLY"
+	Monthly Frequency = "MONTHLY"
+	Yearly  Frequency = "YEARLY"
+)
+
+// Weekday is a BYDAY entry; N is the optional ordinal such as 1 in 1MO or
+// -1 in -1FR and is zero when the rule names every matching weekday.
+type Weekday struct {
+	Day time.Weekday
+	N   int
+}
+
+// maxPeriods bounds the search for the next occurrence so rules that can
+// never match (e.g. BYMONTHDAY=31;BYMONTH=2) terminate.
+const maxPeriods = 1000
+
+var weekdays = map[string]time.Weekday{
+	"SU": time.Sunday,
+	"MO": time.Monday,
+	"TU": time.Tuesday,
+	"WE": time.Wednesday,
+	"TH": time.Thursday,
+	"FR": time.Friday,
+	"SA": time.Saturday,
+}
+
+func Parse(s string) (*Rule, error) {
+	s = strings.TrimPrefix(strings.TrimSpace(s), "RRULE:")
+	if s == "" {
+		return nil, fmt.Errorf("rrule is empty")
+	}
+
+	rule := &Rule{Interval: 1}
+	for _, part := range strings.Split(s, ";") {
+		key, value, ok := strings.Cut(part, "=")
+		if !ok || value == "" {
+			return nil, fmt.Errorf("invalid rrule part %q", part)
+		}
+
+		var err error
+		switch strings.ToUpper(key) {
+		case "FREQ":
+			rule.Freq = Frequency(strings.ToUpper(value))
+			switch rule.Freq {
+			case Daily, Weekly, Monthly, Yearly:
+			default:
+				err = fmt.Errorf("unsupported FREQ %q", value)
+			}
+		case "INTERVAL":
+			rule.Interval, err = positiveInt(value)
+		case "COUNT":
+			rule.Count, err = positiveInt(value)
+		case "UNTIL":
+			rule.Until, err = parseUntil(value)
+		case "BYDAY":
+			rule.ByDay, err = parseByDay(value)
+		case "BYMONTHDAY":
+			rule.ByMonthDay, err = parseByMonthDay(value)
+		case "BYMONTH":
+			rule.ByMonth, err = parseByMonth(value)
+		case "WKST":
+			if strings.ToUpper(value) != "MO" {
+				err = fmt.Errorf("only WKST=MO is supported")
+			}
+		default:
+			err = fmt.Errorf("unsupported rrule part %q", key)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if rule.Freq == "" {
+		return nil, fmt.Errorf("rrule requires FREQ")
+	}
+	if rule.Count > 0 && !rule.Until.IsZero() {
+		return nil, fmt.Errorf("rrule cannot combine COUNT and UNTIL")
+	}
+	for _, wd := range rule.ByDay {
+		if wd.N != 0 && rule.Freq != Monthly && (rule.Freq != Yearly || len(rule.ByMonth) == 0) {
+			return nil, fmt.Errorf("ordinal BYDAY requires FREQ=MONTHLY or FREQ=YEARLY with BYMONTH")
+		}
+	}
+
+	return rule, nil
+}
+
+// Next returns the occurrence following prev, which must itself be the
+// index-th occurrence (1-based) of the series. The wall-clock time of prev in
+// loc is preserved across daylight-saving changes. ok is false once the
+// series is exhausted.
+func (r *Rule) Next(prev time.Time, index int, loc *time.Location) (time.Time, bool) {
+	if r.Count > 0 && index >= r.Count {
+		return time.Time{}, false
+	}
+
+	prev = prev.In(loc)
+	for period := 0; period < maxPeriods; period++ {
+		for _, candidate := range r.candidates(prev, period*r.Interval) {
+			if !candidate.After(prev) || !r.monthAllowed(candidate.Month()) {
+				continue
+			}
+			if !r.Until.IsZero() && candidate.After(r.Until) {
+				return time.Time{}, false
+			}
+			return candidate, true
+		}
+	}
+
+	return time.Time{}, false
+}
+
+// candidates lists the occurrences within the period that is offset periods
+// after the one containing prev, in chronological order.
+func (r *Rule) candidates(prev time.Time, offset int) []time.Time {
+	y, m, d := prev.Date()
+	h, mi, s := prev.Clock()
+	loc := prev.Location()
+	at := func(year int, month time.Month, day int) time.Time {
+		return time.Date(year, month, day, h, mi, s, 0, loc)
+	}
+
+	var days []time.Time
+	switch r.Freq {
+	case Daily:
+		day := at(y, m, d+offset)
+		if r.dayAllowed(day) {
+			days = append(days, day)
+		}
+	case Weekly:
+		monday := d - (int(prev.Weekday())+6)%7 + offset*7
+		for i := 0; i < 7; i++ {
+			day := at(y, m, monday+i)
+			if len(r.ByDay) == 0 && day.Weekday() == prev.Weekday() || r.weekdayListed(day.Weekday()) {
+				days = append(days, day)
+			}
+		}
+	case Monthly:
+		first := at(y, m+time.Month(offset), 1)
+		days = r.daysInMonth(first.Year(), first.Month(), d, at)
+	case Yearly:
+		months := r.ByMonth
+		if len(months) == 0 && len(r.ByMonthDay) == 0 && len(r.ByDay) == 0 {
+			months = []time.Month{m}
+		} else if len(months) == 0 {
+			months = []time.Month{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12}
+		}
+		for _, month := range months {
+			days = append(days, r.daysInMonth(y+offset, month, d, at)...)
+		}
+	}
+
+	sort.Slice(days, func(i, j int) bool { return days[i].Before(days[j]) })
+	return days
+}
+
+func (r *Rule) daysInMonth(year int, month time.Month, defaultDay int, at func(int, time.Month, int) time.Time) []time.Time {
+	last := time.Date(year, month+1, 0, 0, 0, 0, 0, time.UTC).Day()
+
+	var days []time.Time
+	if len(r.ByMonthDay) == 0 && len(r.ByDay) == 0 {
+		if defaultDay <= last {
+			days = append(days, at(year, month, defaultDay))
+		}
+		return days
+	}
+
+	for day := 1; day <= last; day++ {
+		t := at(year, month, day)
+		if len(r.ByMonthDay) > 0 && !monthDayListed(r.ByMonthDay, day, last) {
+			continue
+		}
+		if len(r.ByDay) > 0 && !r.ordinalWeekdayListed(t.Weekday(), day, last) {
+			continue
+		}
+		days = append(days, t)
+	}
+	return days
+}
+
+func (r *Rule) dayAllowed(t time.Time) bool {
+	last := time.Date(t.Year(), t.Month()+1, 0, 0, 0, 0, 0, time.UTC).Day()
+	if len(r.ByMonthDay) > 0 && !monthDayListed(r.ByMonthDay, t.Day(), last) {
+		return false
+	}
+	return len(r.ByDay) == 0 || r.weekdayListed(t.Weekday())
+}
+
+func (r *Rule) weekdayListed(day time.Weekday) bool {
+	for _, wd := range r.ByDay {
+		if wd.Day == day {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *Rule) ordinalWeekdayListed(day time.Weekday, monthDay, lastDay int) bool {
+	fromStart := (monthDay-1)/7 + 1
+	fromEnd := -((lastDay-monthDay)/7 + 1)
+	for _, wd := range r.ByDay {
+		if wd.Day == day && (wd.N == 0 || wd.N == fromStart || wd.N == fromEnd) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *Rule) monthAllowed(month time.Month) bool {
+	if len(r.ByMonth) == 0 {
+		return true
+	}
+	for _, m := range r.ByMonth {
+		if m == month {
+			return true
+		}
+	}
+	return false
+}
+
+func monthDayListed(list []int, day, lastDay int) bool {
+	for _, d := range list {
+		if d == day || d < 0 && lastDay+d+1 == day {
+			return true
+		}
+	}
+	return false
+}
+
+func positiveInt(value string) (int, error) {
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 1 {
+		return 0, fmt.Errorf("expected a positive integer, got %q", value)
+	}
+	return n, nil
+}
+
+func parseUntil(value string) (time.Time, error) {
+	for _, layout := range []string{"20060102T150405Z", "20060102"} {
+		if t, err := time.Parse(layout, value); err == nil {
+			if layout == "20060102" {
+				t = t.Add(24*time.Hour - time.Second)
+			}
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid UNTIL %q", value)
+}
+
+func parseByDay(value string) ([]Weekday, error) {
+	var days []Weekday
+	for _, item := range strings.Split(strings.ToUpper(value), ",") {
+		if len(item) < 2 {
+			return nil, fmt.Errorf("invalid BYDAY %q", item)
+		}
+		day, ok := weekdays[item[len(item)-2:]]
+		if !ok {
+			return nil, fmt.Errorf("invalid BYDAY %q", item)
+		}
+
+		wd := Weekday{Day: day}
+		if prefix := item[:len(item)-2]; prefix != "" {
+			n, err := strconv.Atoi(prefix)
+			if err != nil || n == 0 || n < -5 || n > 5 {
+				return nil, fmt.Errorf("invalid BYDAY %q", item)
+			}
+			wd.N = n
+		}
+		days = append(days, wd)
+	}
+	return days, nil
+}
+
+func parseByMonthDay(value string) ([]int, error) {
+	var days []int
+	for _, item := range strings.Split(value, ",") {
+		n, err := strconv.Atoi(item)
+		if err != nil || n == 0 || n < -31 || n > 31 {
+			return nil, fmt.Errorf("invalid BYMONTHDAY %q", item)
+		}
+		days = append(days, n)
+	}
+	return days, nil
+}
+
+func parseByMonth(value string) ([]time.Month, error) {
+	var months []time.Month
+	for _, item := range strings.Split(value, ",") {
+		n, err := strconv.Atoi(item)
+		if err != nil || n < 1 || n > 12 {
+			return nil, fmt.Errorf("invalid BYMONTH %q", item)
+		}
+		months = append(months, time.Month(n))
+	}
+	return months, nil
+}
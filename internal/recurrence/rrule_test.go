@@ -0,0 +1,168 @@
+package recurrence
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		in   string
+		want Rule
+	}{
+		{"FREQ=DAILY", Rule{Freq: Daily, Interval: 1}},
+		{
+			"RRULE:freq=monthly;interval=2;byday=mo,-1fr;until=20241231",
+			Rule{
+				Freq:     Monthly,
+				Interval: 2,
+				ByDay:    []Weekday{{Day: time.Monday}, {Day: time.Friday, N: -1}},
+				Until:    time.Date(2024, 12, 31, 23, 59, 59, 0, time.UTC),
+			},
+		},
+		{
+			"FREQ=YEARLY;BYMONTH=11;BYDAY=4TH;COUNT=5",
+			Rule{
+				Freq:     Yearly,
+				Interval: 1,
+				Count:    5,
+				ByDay:    []Weekday{{Day: time.Thursday, N: 4}},
+				ByMonth:  []time.Month{time.November},
+			},
+		},
+		{
+			"FREQ=MONTHLY;BYMONTHDAY=1,-1;UNTIL=20240630T120000Z;WKST=MO",
+			Rule{
+				Freq:       Monthly,
+				Interval:   1,
+				ByMonthDay: []int{1, -1},
+				Until:      time.Date(2024, 6, 30, 12, 0, 0, 0, time.UTC),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := Parse(tt.in)
+			if err != nil {
+				t.Fatalf("Parse(%q): %v", tt.in, err)
+			}
+			if !reflect.DeepEqual(*got, tt.want) {
+				t.Errorf("Parse(%q) = %+v, want %+v", tt.in, *got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []string{
+		"",
+		"FREQ",
+		"FREQ=HOURLY",
+		"INTERVAL=2",
+		"FREQ=DAILY;INTERVAL=0",
+		"FREQ=DAILY;COUNT=-1",
+		"FREQ=DAILY;COUNT=2;UNTIL=20240101",
+		"FREQ=DAILY;UNTIL=2024-01-01",
+		"FREQ=WEEKLY;BYDAY=XX",
+		"FREQ=WEEKLY;BYDAY=1MO",
+		"FREQ=YEARLY;BYDAY=1MO",
+		"FREQ=MONTHLY;BYDAY=6MO",
+		"FREQ=MONTHLY;BYMONTHDAY=32",
+		"FREQ=MONTHLY;BYMONTHDAY=0",
+		"FREQ=YEARLY;BYMONTH=13",
+		"FREQ=DAILY;WKST=SU",
+		"FREQ=DAILY;BYHOUR=9",
+	}
+
+	for _, in := range tests {
+		if _, err := Parse(in); err == nil {
+			t.Errorf("Parse(%q) succeeded, want an error", in)
+		}
+	}
+}
+
+func TestNext(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skipf("time zone data unavailable: %v", err)
+	}
+	utc := func(y int, m time.Month, d, h int) time.Time {
+		return time.Date(y, m, d, h, 0, 0, 0, time.UTC)
+	}
+	ny := func(y int, m time.Month, d, h int) time.Time {
+		return time.Date(y, m, d, h, 0, 0, 0, newYork)
+	}
+
+	tests := []struct {
+		name  string
+		rule  string
+		prev  time.Time
+		index int
+		loc   *time.Location
+		want  time.Time
+	}{
+		{"daily", "FREQ=DAILY", utc(2024, 1, 1, 9), 1, time.UTC, utc(2024, 1, 2, 9)},
+		{"daily interval", "FREQ=DAILY;INTERVAL=3", utc(2024, 1, 1, 9), 1, time.UTC, utc(2024, 1, 4, 9)},
+		{"weekdays skip the weekend", "FREQ=DAILY;BYDAY=MO,TU,WE,TH,FR", utc(2024, 1, 5, 9), 1, time.UTC, utc(2024, 1, 8, 9)},
+		{"weekly keeps the weekday", "FREQ=WEEKLY", utc(2024, 1, 3, 9), 1, time.UTC, utc(2024, 1, 10, 9)},
+		{"weekly byday within the week", "FREQ=WEEKLY;BYDAY=MO,WE,FR", utc(2024, 1, 1, 9), 1, time.UTC, utc(2024, 1, 3, 9)},
+		{"weekly byday into next week", "FREQ=WEEKLY;BYDAY=MO,WE,FR", utc(2024, 1, 5, 9), 1, time.UTC, utc(2024, 1, 8, 9)},
+		{"fortnightly byday", "FREQ=WEEKLY;INTERVAL=2;BYDAY=TU,TH", utc(2024, 1, 4, 9), 1, time.UTC, utc(2024, 1, 16, 9)},
+		{"second tuesday", "FREQ=MONTHLY;BYDAY=2TU", utc(2024, 1, 9, 9), 1, time.UTC, utc(2024, 2, 13, 9)},
+		{"last friday", "FREQ=MONTHLY;BYDAY=-1FR", utc(2024, 1, 26, 9), 1, time.UTC, utc(2024, 2, 23, 9)},
+		{"monthly skips short months", "FREQ=MONTHLY", utc(2024, 1, 31, 9), 1, time.UTC, utc(2024, 3, 31, 9)},
+		{"monthday skips short months", "FREQ=MONTHLY;BYMONTHDAY=31", utc(2024, 1, 31, 9), 1, time.UTC, utc(2024, 3, 31, 9)},
+		{"last day of month", "FREQ=MONTHLY;BYMONTHDAY=-1", utc(2024, 1, 31, 9), 1, time.UTC, utc(2024, 2, 29, 9)},
+		{"yearly leap day", "FREQ=YEARLY", utc(2024, 2, 29, 9), 1, time.UTC, utc(2028, 2, 29, 9)},
+		{"fourth thursday of november", "FREQ=YEARLY;BYMONTH=11;BYDAY=4TH", utc(2024, 11, 28, 9), 1, time.UTC, utc(2025, 11, 27, 9)},
+		{"below count", "FREQ=DAILY;COUNT=3", utc(2024, 1, 2, 9), 2, time.UTC, utc(2024, 1, 3, 9)},
+		{"on until", "FREQ=DAILY;UNTIL=20240103T090000Z", utc(2024, 1, 2, 9), 1, time.UTC, utc(2024, 1, 3, 9)},
+		{"until date includes the day", "FREQ=DAILY;UNTIL=20240103", utc(2024, 1, 2, 9), 1, time.UTC, utc(2024, 1, 3, 9)},
+		{"days follow the location", "FREQ=DAILY", utc(2024, 1, 1, 3), 1, newYork, utc(2024, 1, 2, 3)},
+		{"spring forward keeps the wall clock", "FREQ=DAILY", ny(2024, 3, 9, 9), 1, newYork, ny(2024, 3, 10, 9)},
+		{"fall back keeps the wall clock", "FREQ=WEEKLY", ny(2024, 10, 30, 9), 1, newYork, ny(2024, 11, 6, 9)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule, err := Parse(tt.rule)
+			if err != nil {
+				t.Fatalf("Parse(%q): %v", tt.rule, err)
+			}
+			got, ok := rule.Next(tt.prev, tt.index, tt.loc)
+			if !ok {
+				t.Fatalf("Next(%v) reported the series exhausted, want %v", tt.prev, tt.want)
+			}
+			if !got.Equal(tt.want) {
+				t.Errorf("Next(%v) = %v, want %v", tt.prev, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNextExhausted(t *testing.T) {
+	tests := []struct {
+		name  string
+		rule  string
+		prev  time.Time
+		index int
+	}{
+		{"count reached", "FREQ=DAILY;COUNT=3", time.Date(2024, 1, 3, 9, 0, 0, 0, time.UTC), 3},
+		{"past until", "FREQ=DAILY;UNTIL=20240103", time.Date(2024, 1, 3, 9, 0, 0, 0, time.UTC), 1},
+		{"never matches", "FREQ=YEARLY;BYMONTH=2;BYMONTHDAY=30", time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC), 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule, err := Parse(tt.rule)
+			if err != nil {
+				t.Fatalf("Parse(%q): %v", tt.rule, err)
+			}
+			if got, ok := rule.Next(tt.prev, tt.index, time.UTC); ok {
+				t.Errorf("Next(%v) = %v, want the series exhausted", tt.prev, got)
+			}
+		})
+	}
+}
@@ -1,47 +1,227 @@
 package routes
 
 import (
+	"net/http"
+	"time"
+
 	"github.com/go-chi/chi/v5"
 	"github.com/grewalsk/task-api/internal/handlers"
 	"github.com/grewalsk/task-api/internal/middleware"
+	"github.com/grewalsk/task-api/internal/models"
+	"github.com/grewalsk/task-api/internal/openapi"
+	"github.com/grewalsk/task-api/internal/utils"
 	"github.com/rs/cors"
 	"go.uber.org/zap"
 )
 
 func Setup(
 	taskHandler *handlers.TaskHandler,
+	bulkHandler *handlers.BulkHandler,
+	cloneHandler *handlers.CloneHandler,
+	commentHandler *handlers.CommentHandler,
+	attachmentHandler *handlers.AttachmentHandler,
+	activityHandler *handlers.ActivityHandler,
+	worklogHandler *handlers.WorklogHandler,
+	customFieldHandler *handlers.CustomFieldHandler,
+	templateHandler *handlers.TemplateHandler,
+	projectHandler *handlers.ProjectHandler,
+	sprintHandler *handlers.SprintHandler,
+	milestoneHandler *handlers.MilestoneHandler,
+	shareHandler *handlers.ShareHandler,
+	groupHandler *handlers.GroupHandler,
+	metricsHandler *handlers.MetricsHandler,
+	viewHandler *handlers.ViewHandler,
+	workspaceHandler *handlers.WorkspaceHandler,
+	userHandler *handlers.UserHandler,
 	authHandler *handlers.AuthHandler,
 	healthHandler *handlers.HealthHandler,
 	jwtSecret string,
+	idempotencyDAO *models.IdempotencyDAO,
+	idempotencyTTL time.Duration,
+	validateContract bool,
 	logger *zap.Logger,
 ) *chi.Mux {
 	r := chi.NewRouter()
 
+	spec := openapi.NewSpec(r, openapi.Info{Title: "Task API", Version: "1.0.0"}, handlers.Endpoints, utils.Problem{})
+	openAPIHandler := handlers.NewOpenAPIHandler(spec)
+
+	r.Use(middleware.RequestID)
 	r.Use(middleware.Recovery(logger))
 	r.Use(middleware.Logging(logger))
+	if validateContract {
+		r.Use(middleware.ValidateContract(spec, logger))
+	}
+
+	r.NotFound(func(w http.ResponseWriter, r *http.Request) {
+		utils.WriteError(w, http.StatusNotFound, "not_found", "No route matches "+r.URL.Path)
+	})
+	r.MethodNotAllowed(func(w http.ResponseWriter, r *http.Request) {
+		utils.WriteError(w, http.StatusMethodNotAllowed, "method_not_allowed", r.Method+" is not allowed on "+r.URL.Path)
+	})
 
 	c := cors.New(cors.Options{
 		AllowedOrigins:   []string{"*"},
 		AllowedMethods:   []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
 		AllowedHeaders:   []string{"*"},
+		ExposedHeaders:   []string{"ETag", "Link", "X-Total-Count", "X-Request-ID", "Idempotent-Replayed", "Retry-After"},
 		AllowCredentials: true,
 	})
 	r.Use(c.Handler)
 
+	// Authenticated routes honour Idempotency-Key on POST requests.
+	authenticated := chi.Chain(
+		middleware.JWTAuth(jwtSecret),
+		middleware.Idempotency(idempotencyDAO, idempotencyTTL, logger),
+	)
+
 	r.Route("/v1", func(r chi.Router) {
 		r.Post("/login", authHandler.Login)
+		r.Get("/problems/{code}", handlers.ProblemType)
+
+		r.Group(func(r chi.Router) {
+			r.Use(authenticated...)
+			r.Get("/workspace", workspaceHandler.Current)
+			r.Post("/workspaces", workspaceHandler.Create)
+			r.Get("/timer", worklogHandler.CurrentTimer)
+			r.Get("/worklogs/totals", worklogHandler.UserTotals)
+			r.Get("/timesheet", worklogHandler.Timesheet)
+			r.Get("/metrics/cycle-time", metricsHandler.CycleTime)
+		})
+
+		r.Route("/users", func(r chi.Router) {
+			r.Use(authenticated...)
+			r.Post("/", userHandler.Create)
+			r.Get("/", userHandler.List)
+			r.Get("/{id}", userHandler.GetByID)
+		})
+
+		r.Route("/groups", func(r chi.Router) {
+			r.Use(authenticated...)
+			r.Post("/", groupHandler.Create)
+			r.Get("/", groupHandler.List)
+			r.Get("/{id}", groupHandler.GetByID)
+			r.Patch("/{id}", groupHandler.Update)
+			r.Delete("/{id}", groupHandler.Delete)
+		})
+
+		r.Route("/views", func(r chi.Router) {
+			r.Use(authenticated...)
+			r.Post("/", viewHandler.Create)
+			r.Get("/", viewHandler.List)
+			r.Get("/default", viewHandler.Default)
+			r.Get("/{id}", viewHandler.GetByID)
+			r.Patch("/{id}", viewHandler.Update)
+			r.Delete("/{id}", viewHandler.Delete)
+			r.Get("/{id}/tasks", viewHandler.Tasks)
+		})
+
+		r.Route("/custom-fields", func(r chi.Router) {
+			r.Use(authenticated...)
+			r.Post("/", customFieldHandler.Create)
+			r.Get("/", customFieldHandler.List)
+			r.Get("/{id}", customFieldHandler.GetByID)
+			r.Patch("/{id}", customFieldHandler.Update)
+			r.Delete("/{id}", customFieldHandler.Delete)
+		})
+
+		r.Route("/projects", func(r chi.Router) {
+			r.Use(authenticated...)
+			r.Post("/", projectHandler.Create)
+			r.Get("/", projectHandler.List)
+			r.Get("/{projectID}", projectHandler.GetByID)
+			r.Patch("/{projectID}", projectHandler.Update)
+			r.Delete("/{projectID}", projectHandler.Delete)
+			r.Put("/{projectID}/members", projectHandler.PutMember)
+			r.Delete("/{projectID}/members/{userID}", projectHandler.RemoveMember)
+			r.Post("/{projectID}/tasks", taskHandler.Create)
+			r.Get("/{projectID}/tasks", taskHandler.List)
+
+			r.Route("/{projectID}/sprints", func(r chi.Router) {
+				r.Post("/", sprintHandler.Create)
+				r.Get("/", sprintHandler.List)
+				r.Get("/{sprintID}", sprintHandler.GetByID)
+				r.Patch("/{sprintID}", sprintHandler.Update)
+				r.Delete("/{sprintID}", sprintHandler.Delete)
+				r.Post("/{sprintID}/start", sprintHandler.Start)
+				r.Post("/{sprintID}/close", sprintHandler.Close)
+				r.Get("/{sprintID}/burndown", sprintHandler.Burndown)
+			})
+
+			r.Route("/{projectID}/milestones", func(r chi.Router) {
+				r.Post("/", milestoneHandler.Create)
+				r.Get("/", milestoneHandler.List)
+				r.Get("/{milestoneID}", milestoneHandler.GetByID)
+				r.Patch("/{milestoneID}", milestoneHandler.Update)
+				r.Delete("/{milestoneID}", milestoneHandler.Delete)
+				r.Get("/{milestoneID}/burndown", milestoneHandler.Burndown)
+			})
+		})
+
+		r.Route("/templates", func(r chi.Router) {
+			r.Use(authenticated...)
+			r.Post("/", templateHandler.Create)
+			r.Get("/", templateHandler.List)
+			r.Get("/{id}", templateHandler.GetByID)
+			r.Put("/{id}", templateHandler.Update)
+			r.Delete("/{id}", templateHandler.Delete)
+			r.Get("/{id}/versions", templateHandler.Versions)
+		})
 
 		r.Route("/tasks", func(r chi.Router) {
-			r.Use(middleware.JWTAuth(jwtSecret))
+			r.Use(authenticated...)
 			r.Post("/", taskHandler.Create)
+			r.Post("/from-template/{id}", templateHandler.Instantiate)
 			r.Get("/", taskHandler.List)
+			r.Post("/bulk", bulkHandler.Run)
+			r.Get("/trash", taskHandler.Trash)
+			r.Delete("/trash/{id}", taskHandler.Purge)
 			r.Get("/{id}", taskHandler.GetByID)
 			r.Patch("/{id}", taskHandler.Update)
 			r.Delete("/{id}", taskHandler.Delete)
+			r.Post("/{id}/restore", taskHandler.Restore)
+			r.Post("/{id}/snooze", taskHandler.Snooze)
+			r.Delete("/{id}/snooze", taskHandler.Unsnooze)
+			r.Post("/{id}/clone", cloneHandler.Clone)
+			r.Get("/{id}/activity", activityHandler.List)
+			r.Get("/{id}/shares", shareHandler.List)
+			r.Put("/{id}/shares", shareHandler.Grant)
+			r.Delete("/{id}/shares/{principalType}/{principalID}", shareHandler.Revoke)
+
+			r.Post("/{id}/timer/start", worklogHandler.StartTimer)
+			r.Post("/{id}/timer/stop", worklogHandler.StopTimer)
+			r.Route("/{id}/worklogs", func(r chi.Router) {
+				r.Post("/", worklogHandler.Create)
+				r.Get("/", worklogHandler.List)
+				r.Get("/totals", worklogHandler.TaskTotals)
+				r.Delete("/{worklogID}", worklogHandler.Delete)
+			})
+
+			r.Route("/{id}/comments", func(r chi.Router) {
+				r.Post("/", commentHandler.Create)
+				r.Get("/", commentHandler.List)
+				r.Get("/{commentID}", commentHandler.GetByID)
+				r.Patch("/{commentID}", commentHandler.Update)
+				r.Delete("/{commentID}", commentHandler.Delete)
+			})
+
+			r.Route("/{id}/attachments", func(r chi.Router) {
+				r.Post("/", attachmentHandler.Upload)
+				r.Get("/", attachmentHandler.List)
+				r.Get("/{attachmentID}", attachmentHandler.GetByID)
+				r.Get("/{attachmentID}/content", attachmentHandler.Download)
+				r.Delete("/{attachmentID}", attachmentHandler.Delete)
+			})
 		})
 	})
 
 	r.Get("/healthz", healthHandler.Check)
+	r.Get("/openapi.json", openAPIHandler.Spec)
+	r.Get("/docs", openAPIHandler.Docs)
+
+	if undocumented := spec.Undocumented(); len(undocumented) > 0 {
+		logger.Warn("Routes missing from the OpenAPI document", zap.Strings("routes", undocumented))
+	}
 
 	return r
 }
\ No newline at end of file
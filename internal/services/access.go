@@ -0,0 +1,201 @@
+package services
+
+import (
+	"context"
+	"errors"
+
+	"github.com/grewalsk/task-api/internal/middleware"
+	"github.com/grewalsk/task-api/internal/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+var (
+	ErrForbidden       = errors.New("forbidden")
+	ErrProjectArchived = errors.New("project is archived")
+)
+
+// Permission is the level of access a user has to a task. Each level
+// includes the ones below it.
+type Permission int
+
+const (
+	PermissionNone Permission = iota
+	PermissionRead
+	PermissionComment
+	PermissionEdit
+	// PermissionManage is held by admins, task owners and project members. It
+	// is required to change a task's shares.
+	PermissionManage
+)
+
+var sharePermissions = map[models.SharePermission]Permission{
+	models.SharePermissionRead:    PermissionRead,
+	models.SharePermissionComment: PermissionComment,
+	models.SharePermissionEdit:    PermissionEdit,
+}
+
+// AccessService decides which tasks and projects a user may see. Admins see
+// everything; other users see tasks they own, tasks in projects they are
+// members of, and tasks shared with them or one of their groups.
+type AccessService struct {
+	taskDAO    *models.TaskDAO
+	projectDAO *models.ProjectDAO
+	groupDAO   *models.GroupDAO
+}
+
+func NewAccessService(taskDAO *models.TaskDAO, projectDAO *models.ProjectDAO, groupDAO *models.GroupDAO) *AccessService {
+	return &AccessService{
+		taskDAO:    taskDAO,
+		projectDAO: projectDAO,
+		groupDAO:   groupDAO,
+	}
+}
+
+func isAdmin(user *middleware.Claims) bool {
+	return user.Role == string(models.RoleAdmin)
+}
+
+// taskAccessFields are the task fields permissions are derived from.
+var taskAccessFields = []string{"owner_id", "project_id", "shares"}
+
+// Task loads an active task and checks that the user holds at least the
+// required permission on it. If fields are given, only those and the fields
+// needed for the check are loaded.
+func (s *AccessService) Task(ctx context.Context, user *middleware.Claims, id primitive.ObjectID, required Permission, fields ...string) (*models.Task, error) {
+	if len(fields) > 0 {
+		fields = append(fields[:len(fields):len(fields)], taskAccessFields...)
+	}
+	task, err := s.taskDAO.GetByID(ctx, id, fields...)
+	if err != nil {
+		return nil, err
+	}
+
+	ok, err := s.CanAccessTask(ctx, user, task, required)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrForbidden
+	}
+
+	return task, nil
+}
+
+func (s *AccessService) CanAccessTask(ctx context.Context, user *middleware.Claims, task *models.Task, required Permission) (bool, error) {
+	permission, err := s.TaskPermission(ctx, user, task)
+	if err != nil {
+		return false, err
+	}
+	return permission >= required, nil
+}
+
+// TaskPermission returns the highest permission the user holds on the task.
+func (s *AccessService) TaskPermission(ctx context.Context, user *middleware.Claims, task *models.Task) (Permission, error) {
+	if isAdmin(user) || task.OwnerID == user.UserID {
+		return PermissionManage, nil
+	}
+
+	if task.ProjectID != nil {
+		project, err := s.projectDAO.GetByID(ctx, *task.ProjectID)
+		if err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+			return PermissionNone, err
+		}
+		if err == nil {
+			if _, ok := project.Member(user.UserID); ok {
+				return PermissionManage, nil
+			}
+		}
+	}
+
+	if len(task.Shares) == 0 {
+		return PermissionNone, nil
+	}
+
+	principals, err := s.principalIDs(ctx, user)
+	if err != nil {
+		return PermissionNone, err
+	}
+
+	permission := PermissionNone
+	for _, share := range task.Shares {
+		if p := sharePermissions[share.Permission]; p > permission && containsID(principals, share.PrincipalID) {
+			permission = p
+		}
+	}
+
+	return permission, nil
+}
+
+// TaskScope returns the visibility restriction for task listings, or nil for
+// admins.
+func (s *AccessService) TaskScope(ctx context.Context, user *middleware.Claims) (*models.TaskScope, error) {
+	if isAdmin(user) {
+		return nil, nil
+	}
+
+	projectIDs, err := s.projectDAO.ListIDsForMember(ctx, user.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	principals, err := s.principalIDs(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.TaskScope{UserID: user.UserID, ProjectIDs: projectIDs, PrincipalIDs: principals}, nil
+}
+
+// principalIDs returns the IDs a share may name to reach the user: the user
+// and every group they belong to.
+func (s *AccessService) principalIDs(ctx context.Context, user *middleware.Claims) ([]primitive.ObjectID, error) {
+	groupIDs, err := s.groupDAO.ListIDsForMember(ctx, user.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	return append([]primitive.ObjectID{user.UserID}, groupIDs...), nil
+}
+
+func containsID(ids []primitive.ObjectID, id primitive.ObjectID) bool {
+	for _, candidate := range ids {
+		if candidate == id {
+			return true
+		}
+	}
+	return false
+}
+
+// Project loads a project the user is a member of.
+func (s *AccessService) Project(ctx context.Context, user *middleware.Claims, id primitive.ObjectID) (*models.Project, error) {
+	project, err := s.projectDAO.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, ok := project.Member(user.UserID); !ok && !isAdmin(user) {
+		return nil, ErrForbidden
+	}
+
+	return project, nil
+}
+
+// ProjectForNewTask checks that the user may add tasks to the project.
+func (s *AccessService) ProjectForNewTask(ctx context.Context, user *middleware.Claims, id primitive.ObjectID) (*models.Project, error) {
+	project, err := s.Project(ctx, user, id)
+	if err != nil {
+		return nil, err
+	}
+	if project.Archived {
+		return nil, ErrProjectArchived
+	}
+
+	return project, nil
+}
+
+// CanManageProject reports whether the user may edit the project and its
+// membership: project owners and admins.
+func (s *AccessService) CanManageProject(user *middleware.Claims, project *models.Project) bool {
+	return isAdmin(user) || project.IsOwner(user.UserID)
+}
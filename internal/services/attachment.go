@@ -0,0 +1,246 @@
+package services
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/grewalsk/task-api/internal/models"
+	"github.com/grewalsk/task-api/internal/storage"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+)
+
+var (
+	ErrAttachmentTooLarge   = errors.New("attachment exceeds the maximum size")
+	ErrUnsupportedMediaType = errors.New("attachment media type is not allowed")
+	ErrChecksumMismatch     = errors.New("attachment checksum does not match")
+)
+
+// blobGracePeriod is how long a blob may go unreferenced before the sweeper
+// deletes it, comfortably longer than any upload takes to be recorded.
+const blobGracePeriod = 24 * time.Hour
+
+type AttachmentService struct {
+	attachmentDAO *models.AttachmentDAO
+	store         storage.BlobStore
+	maxBytes      int64
+	allowedTypes  []string
+	logger        *zap.Logger
+}
+
+func NewAttachmentService(attachmentDAO *models.AttachmentDAO, store storage.BlobStore, maxBytes int64, allowedTypes []string, logger *zap.Logger) *AttachmentService {
+	return &AttachmentService{
+		attachmentDAO: attachmentDAO,
+		store:         store,
+		maxBytes:      maxBytes,
+		allowedTypes:  allowedTypes,
+		logger:        logger,
+	}
+}
+
+type UploadInput struct {
+	TaskID     primitive.ObjectID
+	UploaderID primitive.ObjectID
+	Filename   string
+	Checksum   string
+	Body       io.Reader
+}
+
+func (s *AttachmentService) MaxBytes() int64 {
+	return s.maxBytes
+}
+
+// Upload sniffs the content type from the first bytes of the stream, then
+// streams the body into the blob store while hashing it. Nothing is recorded
+// unless the size, type and optional client checksum all check out.
+func (s *AttachmentService) Upload(ctx context.Context, in UploadInput) (*models.Attachment, error) {
+	body := bufio.NewReaderSize(in.Body, 512)
+	head, err := body.Peek(512)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+
+	contentType := http.DetectContentType(head)
+	if !s.allowed(contentType) {
+		return nil, ErrUnsupportedMediaType
+	}
+
+	attachment := &models.Attachment{
+		ID:          primitive.NewObjectID(),
+		TaskID:      in.TaskID,
+		UploaderID:  in.UploaderID,
+		Filename:    in.Filename,
+		ContentType: contentType,
+	}
+	attachment.StorageKey = fmt.Sprintf("tasks/%s/%s", in.TaskID.Hex(), attachment.ID.Hex())
+
+	hasher := sha256.New()
+	limited := &io.LimitedReader{R: io.TeeReader(body, hasher), N: s.maxBytes + 1}
+
+	size, err := s.store.Put(ctx, attachment.StorageKey, limited)
+	if err != nil {
+		s.deleteBlob(ctx, attachment.StorageKey)
+		return nil, err
+	}
+
+	if size > s.maxBytes {
+		s.deleteBlob(ctx, attachment.StorageKey)
+		return nil, ErrAttachmentTooLarge
+	}
+
+	attachment.Size = size
+	attachment.Checksum = hex.EncodeToString(hasher.Sum(nil))
+
+	if in.Checksum != "" && !strings.EqualFold(in.Checksum, attachment.Checksum) {
+		s.deleteBlob(ctx, attachment.StorageKey)
+		return nil, ErrChecksumMismatch
+	}
+
+	if err := s.attachmentDAO.Create(ctx, attachment); err != nil {
+		s.deleteBlob(ctx, attachment.StorageKey)
+		return nil, err
+	}
+
+	return attachment, nil
+}
+
+func (s *AttachmentService) Open(ctx context.Context, attachment *models.Attachment) (io.ReadCloser, error) {
+	return s.store.Open(ctx, attachment.StorageKey)
+}
+
+// Remove deletes the attachment. Its blob may be shared with a clone, or
+// about to be, so it is left for the sweeper to delete once nothing
+// references it.
+func (s *AttachmentService) Remove(ctx context.Context, attachment *models.Attachment) error {
+	return s.attachmentDAO.Delete(ctx, attachment.ID)
+}
+
+// CopyToTask references the attachments of one task from another without
+// copying their blobs.
+func (s *AttachmentService) CopyToTask(ctx context.Context, fromTaskID, toTaskID primitive.ObjectID) error {
+	attachments, err := s.attachmentDAO.ListByTask(ctx, fromTaskID)
+	if err != nil {
+		return err
+	}
+
+	for _, attachment := range attachments {
+		attachment.ID = primitive.NilObjectID
+		attachment.TaskID = toTaskID
+		if err := s.attachmentDAO.Create(ctx, attachment); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// PurgeTask removes every attachment of a permanently deleted task.
+func (s *AttachmentService) PurgeTask(ctx context.Context, taskID primitive.ObjectID) error {
+	attachments, err := s.attachmentDAO.ListByTask(ctx, taskID)
+	if err != nil {
+		return err
+	}
+
+	for _, attachment := range attachments {
+		if err := s.Remove(ctx, attachment); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// SweepBlobs deletes blobs that no attachment references any longer, such as
+// those of removed attachments or of uploads that failed before their
+// attachment was recorded. Only blobs older than the grace period are
+// considered, so uploads in progress are left alone.
+func (s *AttachmentService) SweepBlobs(ctx context.Context) (int, error) {
+	removed := 0
+	var keys []string
+
+	flush := func() error {
+		referenced, err := s.attachmentDAO.ReferencedKeys(ctx, keys)
+		if err != nil {
+			return err
+		}
+		for _, key := range keys {
+			if referenced[key] {
+				continue
+			}
+			if err := s.store.Delete(ctx, key); err != nil && !errors.Is(err, storage.ErrNotFound) {
+				return err
+			}
+			removed++
+		}
+		keys = keys[:0]
+		return nil
+	}
+
+	err := s.store.List(ctx, time.Now().Add(-blobGracePeriod), func(key string) error {
+		keys = append(keys, key)
+		if len(keys) < 100 {
+			return nil
+		}
+		return flush()
+	})
+	if err == nil && len(keys) > 0 {
+		err = flush()
+	}
+	return removed, err
+}
+
+func (s *AttachmentService) RunBlobSweeper(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			removed, err := s.SweepBlobs(ctx)
+			if err != nil {
+				s.logger.Error("Failed to sweep unreferenced attachment blobs", zap.Error(err))
+			} else if removed > 0 {
+				s.logger.Info("Removed unreferenced attachment blobs", zap.Int("count", removed))
+			}
+		}
+	}
+}
+
+func (s *AttachmentService) allowed(contentType string) bool {
+	if len(s.allowedTypes) == 0 {
+		return true
+	}
+
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+
+	for _, allowed := range s.allowedTypes {
+		if allowed == mediaType {
+			return true
+		}
+		if prefix, ok := strings.CutSuffix(allowed, "/*"); ok && strings.HasPrefix(mediaType, prefix+"/") {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (s *AttachmentService) deleteBlob(ctx context.Context, key string) {
+	if err := s.store.Delete(ctx, key); err != nil && !errors.Is(err, storage.ErrNotFound) {
+		s.logger.Error("Failed to delete attachment blob", zap.String("key", key), zap.Error(err))
+	}
+}
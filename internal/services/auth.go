@@ -32,10 +32,11 @@ func (s *AuthService) VerifyPassword(password, hash string) bool {
 	return s.HashPassword(password) == hash
 }
 
-func (s *AuthService) GenerateToken(userID primitive.ObjectID, role string) (string, error) {
+func (s *AuthService) GenerateToken(userID, tenantID primitive.ObjectID, role string) (string, error) {
 	claims := &middleware.Claims{
-		UserID: userID,
-		Role:   role,
+		UserID:   userID,
+		TenantID: tenantID,
+		Role:     role,
 		RegisteredClaims: jwt.RegisteredClaims{
 			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Duration(s.expiryHours) * time.Hour)),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
@@ -46,10 +47,10 @@ func (s *AuthService) GenerateToken(userID primitive.ObjectID, role string) (str
 	return token.SignedString([]byte(s.jwtSecret))
 }
 
+// CreateDefaultUser returns the admin seeded into the default workspace on
+// first start.
 func (s *AuthService) CreateDefaultUser() *models.User {
-	userID := primitive.NewObjectID()
 	return &models.User{
-		ID:        userID,
 		Email:     "admin@example.com",
 		Password:  s.HashPassword("admin123"),
 		Role:      models.RoleAdmin,
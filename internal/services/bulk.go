@@ -0,0 +1,334 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/grewalsk/task-api/internal/middleware"
+	"github.com/grewalsk/task-api/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.uber.org/zap"
+)
+
+// MaxBulkItems is the largest number of tasks one bulk request may touch.
+const MaxBulkItems = 500
+
+var (
+	ErrBulkTooLarge     = fmt.Errorf("bulk operations are limited to %d tasks", MaxBulkItems)
+	ErrInvalidAssignees = errors.New("assignee_ids must refer to users in this workspace")
+)
+
+type BulkOperationType string
+
+const (
+	BulkSetStatus BulkOperationType = "set_status"
+	BulkAssign    BulkOperationType = "assign"
+	BulkLabel     BulkOperationType = "label"
+	BulkDelete    BulkOperationType = "delete"
+	BulkRestore   BulkOperationType = "restore"
+)
+
+// BulkOperation describes the change applied to every selected task. Assign
+// replaces the assignees; label adds and removes individual labels.
+type BulkOperation struct {
+	Type         BulkOperationType    `json:"type" validate:"required,oneof=set_status assign label delete restore"`
+	Status       models.TaskStatus    `json:"status" validate:"required_if=Type set_status,omitempty,oneof=open in_progress done"`
+	AssigneeIDs  []primitive.ObjectID `json:"assignee_ids" validate:"max=20"`
+	AddLabels    []string             `json:"add_labels" validate:"max=20,dive,min=1,max=50"`
+	RemoveLabels []string             `json:"remove_labels" validate:"max=20,dive,min=1,max=50"`
+}
+
+// BulkFilter selects tasks by the same criteria as task listings.
+type BulkFilter struct {
+	Status      *models.TaskStatus  `json:"status" validate:"omitempty,oneof=open in_progress done"`
+	OwnerID     *primitive.ObjectID `json:"owner_id"`
+	ProjectID   *primitive.ObjectID `json:"project_id"`
+	SprintID    *primitive.ObjectID `json:"sprint_id"`
+	MilestoneID *primitive.ObjectID `json:"milestone_id"`
+	Search      string              `json:"search" validate:"max=200"`
+}
+
+// BulkRequest selects tasks either by IDs or by a filter, which is applied
+// within the tasks the user can see.
+type BulkRequest struct {
+	IDs       []primitive.ObjectID `json:"ids" validate:"max=500"`
+	Filter    *BulkFilter          `json:"filter"`
+	Operation BulkOperation        `json:"operation"`
+	DryRun    bool                 `json:"dry_run"`
+}
+
+type BulkItemStatus string
+
+const (
+	BulkItemUpdated     BulkItemStatus = "updated"
+	BulkItemWouldUpdate BulkItemStatus = "would_update"
+	BulkItemUnchanged   BulkItemStatus = "unchanged"
+	BulkItemForbidden   BulkItemStatus = "forbidden"
+	BulkItemNotFound    BulkItemStatus = "not_found"
+	BulkItemInvalid     BulkItemStatus = "invalid"
+)
+
+type BulkItemResult struct {
+	ID     primitive.ObjectID `json:"id"`
+	Status BulkItemStatus     `json:"status"`
+	Error  string             `json:"error,omitempty"`
+}
+
+type BulkResult struct {
+	DryRun  bool                   `json:"dry_run"`
+	Matched int                    `json:"matched"`
+	Applied int                    `json:"applied"`
+	Items   []BulkItemResult       `json:"items"`
+	Counts  map[BulkItemStatus]int `json:"counts"`
+}
+
+type BulkService struct {
+	taskDAO           *models.TaskDAO
+	userDAO           *models.UserDAO
+	access            *AccessService
+	recurrenceService *RecurrenceService
+	logger            *zap.Logger
+}
+
+func NewBulkService(taskDAO *models.TaskDAO, userDAO *models.UserDAO, access *AccessService, recurrenceService *RecurrenceService, logger *zap.Logger) *BulkService {
+	return &BulkService{
+		taskDAO:           taskDAO,
+		userDAO:           userDAO,
+		access:            access,
+		recurrenceService: recurrenceService,
+		logger:            logger,
+	}
+}
+
+// Run authorizes and plans the operation for every selected task, then
+// applies all permitted changes in one BulkWrite unless DryRun is set. Items
+// the user may not edit are reported and skipped rather than failing the
+// whole request.
+func (s *BulkService) Run(ctx context.Context, user *middleware.Claims, req *BulkRequest) (*BulkResult, error) {
+	if req.Operation.Type == BulkAssign && len(req.Operation.AssigneeIDs) > 0 {
+		count, err := s.userDAO.CountByIDs(ctx, req.Operation.AssigneeIDs)
+		if err != nil {
+			return nil, err
+		}
+		if count != int64(len(uniqueIDs(req.Operation.AssigneeIDs))) {
+			return nil, ErrInvalidAssignees
+		}
+	}
+
+	result := &BulkResult{DryRun: req.DryRun, Items: []BulkItemResult{}, Counts: map[BulkItemStatus]int{}}
+	report := func(id primitive.ObjectID, status BulkItemStatus, msg string) {
+		result.Items = append(result.Items, BulkItemResult{ID: id, Status: status, Error: msg})
+		result.Counts[status]++
+	}
+
+	tasks, missing, err := s.selectTasks(ctx, user, req)
+	if err != nil {
+		return nil, err
+	}
+	for _, id := range missing {
+		report(id, BulkItemNotFound, "")
+	}
+	result.Matched = len(tasks)
+
+	var writes []models.TaskWrite
+	for _, task := range tasks {
+		allowed, err := s.access.CanAccessTask(ctx, user, task, PermissionEdit)
+		if err != nil {
+			return nil, err
+		}
+		if !allowed {
+			report(task.ID, BulkItemForbidden, "")
+			continue
+		}
+
+		write, err := req.Operation.plan(task)
+		switch {
+		case err != nil:
+			report(task.ID, BulkItemInvalid, err.Error())
+		case write == nil:
+			report(task.ID, BulkItemUnchanged, "")
+		default:
+			writes = append(writes, *write)
+		}
+	}
+
+	status := BulkItemWouldUpdate
+	if !req.DryRun {
+		if err := s.taskDAO.ApplyBulk(ctx, writes, user.UserID); err != nil {
+			return nil, err
+		}
+		status = BulkItemUpdated
+		result.Applied = len(writes)
+	}
+	for _, write := range writes {
+		report(write.Task.ID, status, "")
+	}
+
+	if !req.DryRun && req.Operation.Type == BulkSetStatus && req.Operation.Status == models.StatusDone {
+		for _, write := range writes {
+			if err := s.recurrenceService.SpawnNext(ctx, write.Task); err != nil {
+				s.logger.Error("Failed to generate next recurring task", zap.Error(err))
+			}
+		}
+	}
+
+	return result, nil
+}
+
+// selectTasks loads the tasks named by the request. Restores select from the
+// trash. IDs that match no task are returned separately.
+func (s *BulkService) selectTasks(ctx context.Context, user *middleware.Claims, req *BulkRequest) ([]*models.Task, []primitive.ObjectID, error) {
+	restore := req.Operation.Type == BulkRestore
+
+	if req.Filter == nil {
+		var tasks []*models.Task
+		var missing []primitive.ObjectID
+		for _, id := range uniqueIDs(req.IDs) {
+			var task *models.Task
+			var err error
+			if restore {
+				task, err = s.taskDAO.GetDeletedByID(ctx, id)
+			} else {
+				task, err = s.taskDAO.GetByID(ctx, id)
+			}
+			switch {
+			case errors.Is(err, mongo.ErrNoDocuments):
+				missing = append(missing, id)
+			case err != nil:
+				return nil, nil, err
+			default:
+				tasks = append(tasks, task)
+			}
+		}
+		return tasks, missing, nil
+	}
+
+	scope, err := s.access.TaskScope(ctx, user)
+	if err != nil {
+		return nil, nil, err
+	}
+	filter := models.TaskFilter{
+		Status:      req.Filter.Status,
+		OwnerID:     req.Filter.OwnerID,
+		ProjectID:   req.Filter.ProjectID,
+		SprintID:    req.Filter.SprintID,
+		MilestoneID: req.Filter.MilestoneID,
+		Search:      req.Filter.Search,
+		Scope:       scope,
+		Limit:       MaxBulkItems + 1,
+	}
+
+	var tasks []*models.Task
+	if restore {
+		tasks, err = s.taskDAO.ListDeleted(ctx, filter)
+	} else {
+		tasks, err = s.taskDAO.List(ctx, filter)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(tasks) > MaxBulkItems {
+		return nil, nil, ErrBulkTooLarge
+	}
+
+	return tasks, nil, nil
+}
+
+// plan returns the write that applies the operation to task, or nil when the
+// task already matches.
+func (op *BulkOperation) plan(task *models.Task) (*models.TaskWrite, error) {
+	write := &models.TaskWrite{Task: task, Action: models.ActivityUpdated, Set: bson.M{}}
+
+	switch op.Type {
+	case BulkSetStatus:
+		if task.Status == op.Status {
+			return nil, nil
+		}
+		write.Set["status"] = op.Status
+
+	case BulkAssign:
+		assignees := uniqueIDs(op.AssigneeIDs)
+		if sameIDs(task.AssigneeIDs, assignees) {
+			return nil, nil
+		}
+		write.Set["assignee_ids"] = assignees
+
+	case BulkLabel:
+		labels := applyLabels(task.Labels, op.AddLabels, op.RemoveLabels)
+		if len(labels) > 20 {
+			return nil, errors.New("a task can have at most 20 labels")
+		}
+		if sameStrings(task.Labels, labels) {
+			return nil, nil
+		}
+		write.Set["labels"] = labels
+
+	case BulkDelete:
+		write.Action = models.ActivityDeleted
+		write.Set = bson.M{"deleted": true, "deleted_at": time.Now()}
+
+	case BulkRestore:
+		write.Action = models.ActivityRestored
+		write.Set["deleted"] = false
+		write.Unset = []string{"deleted_at"}
+	}
+
+	return write, nil
+}
+
+func applyLabels(labels, add, remove []string) []string {
+	removed := make(map[string]bool, len(remove))
+	for _, label := range remove {
+		removed[label] = true
+	}
+
+	result := []string{}
+	seen := map[string]bool{}
+	for _, label := range append(append([]string{}, labels...), add...) {
+		if !removed[label] && !seen[label] {
+			seen[label] = true
+			result = append(result, label)
+		}
+	}
+	return result
+}
+
+func uniqueIDs(ids []primitive.ObjectID) []primitive.ObjectID {
+	seen := make(map[primitive.ObjectID]bool, len(ids))
+	result := make([]primitive.ObjectID, 0, len(ids))
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			result = append(result, id)
+		}
+	}
+	return result
+}
+
+func sameIDs(a, b []primitive.ObjectID) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func sameStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
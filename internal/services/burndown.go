@@ -0,0 +1,134 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"github.com/grewalsk/task-api/internal/models"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// maxBurndownDays bounds the length of a series; longer ranges keep their
+// last maxBurndownDays days.
+const maxBurndownDays = 366
+
+// Burndown is a daily series of task counts. Scope is the number of tasks
+// that existed by the end of the day, Completed those that were done, and
+// Remaining the difference; burnup charts plot Scope and Completed.
+// Counts are nil for days that have not ended yet.
+type Burndown struct {
+	Start    string          `json:"start"`
+	End      string          `json:"end"`
+	Timezone string          `json:"timezone"`
+	Points   []BurndownPoint `json:"points"`
+}
+
+type BurndownPoint struct {
+	Date      string  `json:"date"`
+	Scope     *int    `json:"scope"`
+	Completed *int    `json:"completed"`
+	Remaining *int    `json:"remaining"`
+	Ideal     float64 `json:"ideal"`
+}
+
+type BurndownService struct {
+	taskDAO *models.TaskDAO
+}
+
+func NewBurndownService(taskDAO *models.TaskDAO) *BurndownService {
+	return &BurndownService{taskDAO: taskDAO}
+}
+
+// Sprint covers the sprint's tasks, including those carried over to a later
+// sprint when it was closed, from its start to its end date. Actual values
+// stop at the day the sprint was closed.
+func (s *BurndownService) Sprint(ctx context.Context, sprint *models.Sprint, loc *time.Location) (*Burndown, error) {
+	query := bson.M{"sprint_id": sprint.ID}
+	if len(sprint.CarriedOver) > 0 {
+		query = bson.M{"$or": bson.A{
+			bson.M{"sprint_id": sprint.ID},
+			bson.M{"_id": bson.M{"$in": sprint.CarriedOver}},
+		}}
+	}
+
+	until := time.Now()
+	if sprint.ClosedAt != nil && sprint.ClosedAt.Before(until) {
+		until = *sprint.ClosedAt
+	}
+
+	return s.series(ctx, query, sprint.StartDate, sprint.EndDate, until, loc)
+}
+
+// Milestone covers the milestone's tasks from its creation to its due date.
+func (s *BurndownService) Milestone(ctx context.Context, milestone *models.Milestone, loc *time.Location) (*Burndown, error) {
+	start := milestone.CreatedAt
+	if milestone.DueDate.Before(start) {
+		start = milestone.DueDate
+	}
+
+	return s.series(ctx, bson.M{"milestone_id": milestone.ID}, start, milestone.DueDate, time.Now(), loc)
+}
+
+func (s *BurndownService) series(ctx context.Context, query bson.M, start, end, until time.Time, loc *time.Location) (*Burndown, error) {
+	histories, err := s.taskDAO.StatusHistory(ctx, query, loc.String())
+	if err != nil {
+		return nil, err
+	}
+
+	first := startOfDay(start, loc)
+	last := startOfDay(end, loc)
+	if days := int(last.Sub(first).Hours()/24) + 1; days > maxBurndownDays {
+		first = last.AddDate(0, 0, 1-maxBurndownDays)
+	}
+
+	var days []time.Time
+	for day := first; !day.After(last); day = day.AddDate(0, 0, 1) {
+		days = append(days, day)
+	}
+
+	burndown := &Burndown{
+		Start:    first.Format("2006-01-02"),
+		End:      last.Format("2006-01-02"),
+		Timezone: loc.String(),
+		Points:   make([]BurndownPoint, len(days)),
+	}
+
+	initialScope := 0
+	for i, day := range days {
+		point := BurndownPoint{Date: day.Format("2006-01-02")}
+		dayEnd := day.AddDate(0, 0, 1)
+
+		if !day.After(until) {
+			scope, completed := 0, 0
+			for _, h := range histories {
+				if !h.CreatedAt.Before(dayEnd) {
+					continue
+				}
+				scope++
+				if h.StatusAt(point.Date) == models.StatusDone {
+					completed++
+				}
+			}
+			remaining := scope - completed
+			point.Scope, point.Completed, point.Remaining = &scope, &completed, &remaining
+			if i == 0 {
+				initialScope = remaining
+			}
+		}
+
+		burndown.Points[i] = point
+	}
+
+	for i := range burndown.Points {
+		if n := len(days) - 1; n > 0 {
+			burndown.Points[i].Ideal = float64(initialScope) * float64(n-i) / float64(n)
+		}
+	}
+
+	return burndown, nil
+}
+
+func startOfDay(t time.Time, loc *time.Location) time.Time {
+	y, m, d := t.In(loc).Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, loc)
+}
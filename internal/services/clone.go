@@ -0,0 +1,152 @@
+package services
+
+import (
+	"context"
+	"errors"
+
+	"github.com/grewalsk/task-api/internal/middleware"
+	"github.com/grewalsk/task-api/internal/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// maxCloneTasks bounds how many tasks, the original included, one clone may
+// create.
+const maxCloneTasks = 200
+
+var ErrCloneTooLarge = errors.New("task has too many subtasks to clone")
+
+// CloneOptions selects what is copied besides the task's core fields.
+type CloneOptions struct {
+	Title       *string `json:"title" validate:"omitempty,min=1,max=200"`
+	Subtasks    bool    `json:"include_subtasks"`
+	Checklist   bool    `json:"include_checklist"`
+	Labels      bool    `json:"include_labels"`
+	Attachments bool    `json:"include_attachments"`
+	Comments    bool    `json:"include_comments"`
+}
+
+type CloneService struct {
+	taskDAO           *models.TaskDAO
+	commentDAO        *models.CommentDAO
+	attachmentService *AttachmentService
+	access            *AccessService
+}
+
+func NewCloneService(taskDAO *models.TaskDAO, commentDAO *models.CommentDAO, attachmentService *AttachmentService, access *AccessService) *CloneService {
+	return &CloneService{
+		taskDAO:           taskDAO,
+		commentDAO:        commentDAO,
+		attachmentService: attachmentService,
+		access:            access,
+	}
+}
+
+// Clone copies source, and optionally its subtasks at every depth, as new
+// open tasks owned by the user. Each copy records the task it was cloned
+// from. Subtasks the user cannot read are skipped along with their own
+// subtasks. The returned slice starts with the copy of source.
+func (s *CloneService) Clone(ctx context.Context, user *middleware.Claims, source *models.Task, opts CloneOptions) ([]*models.Task, error) {
+	originals := []*models.Task{source}
+	if opts.Subtasks {
+		parents := []primitive.ObjectID{source.ID}
+		for len(parents) > 0 {
+			children, err := s.taskDAO.ListChildren(ctx, parents)
+			if err != nil {
+				return nil, err
+			}
+
+			parents = nil
+			for _, child := range children {
+				ok, err := s.access.CanAccessTask(ctx, user, child, PermissionRead)
+				if err != nil {
+					return nil, err
+				}
+				if !ok {
+					continue
+				}
+				if len(originals) == maxCloneTasks {
+					return nil, ErrCloneTooLarge
+				}
+				originals = append(originals, child)
+				parents = append(parents, child.ID)
+			}
+		}
+	}
+
+	newIDs := make(map[primitive.ObjectID]primitive.ObjectID, len(originals))
+	for _, original := range originals {
+		newIDs[original.ID] = primitive.NewObjectID()
+	}
+
+	copies := make([]*models.Task, len(originals))
+	for i, original := range originals {
+		clone := cloneTask(original, user.UserID, opts)
+		clone.ID = newIDs[original.ID]
+		if i > 0 {
+			parentID := newIDs[*original.ParentID]
+			clone.ParentID = &parentID
+		}
+		copies[i] = clone
+	}
+	if opts.Title != nil {
+		copies[0].Title = *opts.Title
+	}
+
+	// Comments and attachments are copied in the same transaction as the
+	// tasks, so a failed clone leaves nothing behind.
+	err := s.taskDAO.Transaction(ctx, func(ctx context.Context) error {
+		if err := s.taskDAO.CreateMany(ctx, copies, user.UserID); err != nil {
+			return err
+		}
+
+		for i, original := range originals {
+			if opts.Comments {
+				if err := s.commentDAO.CopyToTask(ctx, original.ID, copies[i].ID); err != nil {
+					return err
+				}
+			}
+			if opts.Attachments {
+				if err := s.attachmentService.CopyToTask(ctx, original.ID, copies[i].ID); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return copies, nil
+}
+
+// cloneTask copies the content of a task. Status, progress, schedule and
+// sharing are reset; the sprint is dropped since it may already be closed.
+func cloneTask(original *models.Task, ownerID primitive.ObjectID, opts CloneOptions) *models.Task {
+	clone := &models.Task{
+		Title:                    original.Title,
+		Description:              original.Description,
+		Status:                   models.StatusOpen,
+		OwnerID:                  ownerID,
+		ProjectID:                original.ProjectID,
+		ParentID:                 original.ParentID,
+		MilestoneID:              original.MilestoneID,
+		AssigneeIDs:              original.AssigneeIDs,
+		CustomFields:             original.CustomFields,
+		OriginalEstimateMinutes:  original.OriginalEstimateMinutes,
+		RemainingEstimateMinutes: original.OriginalEstimateMinutes,
+		ClonedFrom:               &original.ID,
+	}
+
+	if opts.Labels {
+		clone.Labels = original.Labels
+	}
+	if opts.Checklist && len(original.Checklist) > 0 {
+		clone.Checklist = make([]models.ChecklistItem, len(original.Checklist))
+		for i, item := range original.Checklist {
+			clone.Checklist[i] = models.ChecklistItem{Text: item.Text}
+		}
+	}
+
+	return clone
+}
@@ -0,0 +1,166 @@
+package services
+
+import (
+	"context"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/grewalsk/task-api/internal/middleware"
+	"github.com/grewalsk/task-api/internal/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// CycleTimeGrouping names the task field cycle-time groups are keyed by.
+type CycleTimeGrouping string
+
+const (
+	GroupByOwner    CycleTimeGrouping = "owner"
+	GroupByAssignee CycleTimeGrouping = "assignee"
+	GroupByProject  CycleTimeGrouping = "project"
+)
+
+var groupingFields = map[CycleTimeGrouping]string{
+	GroupByOwner:    "owner_id",
+	GroupByAssignee: "assignee_ids",
+	GroupByProject:  "project_id",
+}
+
+// ValidGrouping reports whether g is a supported grouping.
+func ValidGrouping(g CycleTimeGrouping) bool {
+	_, ok := groupingFields[g]
+	return ok
+}
+
+// CycleTimeQuery selects the completed tasks a report covers.
+type CycleTimeQuery struct {
+	From      time.Time
+	To        time.Time
+	GroupBy   CycleTimeGrouping
+	ProjectID *primitive.ObjectID
+	OwnerID   *primitive.ObjectID
+}
+
+// CycleTimeReport summarizes how long tasks completed in [From, To) took.
+// Lead time runs from creation to completion, cycle time from the first
+// start to completion.
+type CycleTimeReport struct {
+	From    time.Time        `json:"from"`
+	To      time.Time        `json:"to"`
+	GroupBy string           `json:"group_by"`
+	Overall CycleTimeSummary `json:"overall"`
+	Groups  []CycleTimeGroup `json:"groups"`
+}
+
+type CycleTimeSummary struct {
+	Count          int            `json:"count"`
+	LeadTimeHours  *DurationStats `json:"lead_time_hours"`
+	CycleTimeHours *DurationStats `json:"cycle_time_hours"`
+}
+
+type CycleTimeGroup struct {
+	Key interface{} `json:"key"`
+	CycleTimeSummary
+}
+
+// DurationStats describes a set of durations in hours. Percentiles are
+// interpolated between the closest ranks.
+type DurationStats struct {
+	Count int     `json:"count"`
+	Mean  float64 `json:"mean"`
+	P50   float64 `json:"p50"`
+	P75   float64 `json:"p75"`
+	P90   float64 `json:"p90"`
+	P95   float64 `json:"p95"`
+	Max   float64 `json:"max"`
+}
+
+type MetricsService struct {
+	taskDAO *models.TaskDAO
+	access  *AccessService
+}
+
+func NewMetricsService(taskDAO *models.TaskDAO, access *AccessService) *MetricsService {
+	return &MetricsService{
+		taskDAO: taskDAO,
+		access:  access,
+	}
+}
+
+// CycleTime reports lead and cycle times over the tasks the user can see.
+func (s *MetricsService) CycleTime(ctx context.Context, user *middleware.Claims, query CycleTimeQuery) (*CycleTimeReport, error) {
+	scope, err := s.access.TaskScope(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+
+	filter := models.TaskFilter{
+		OwnerID:   query.OwnerID,
+		ProjectID: query.ProjectID,
+		Scope:     scope,
+	}
+	overall, groups, err := s.taskDAO.CompletionTimes(ctx, filter, query.From, query.To, groupingFields[query.GroupBy])
+	if err != nil {
+		return nil, err
+	}
+
+	report := &CycleTimeReport{
+		From:    query.From,
+		To:      query.To,
+		GroupBy: string(query.GroupBy),
+		Overall: summarize(overall),
+		Groups:  make([]CycleTimeGroup, len(groups)),
+	}
+	for i, group := range groups {
+		report.Groups[i] = CycleTimeGroup{Key: group.Key, CycleTimeSummary: summarize(group)}
+	}
+
+	return report, nil
+}
+
+func summarize(group *models.CompletionGroup) CycleTimeSummary {
+	return CycleTimeSummary{
+		Count:          len(group.LeadTimes),
+		LeadTimeHours:  durationStats(group.LeadTimes),
+		CycleTimeHours: durationStats(group.CycleTimes),
+	}
+}
+
+// durationStats converts millisecond durations to hour statistics. It
+// returns nil for an empty set.
+func durationStats(millis []int64) *DurationStats {
+	if len(millis) == 0 {
+		return nil
+	}
+
+	hours := make([]float64, len(millis))
+	var sum float64
+	for i, ms := range millis {
+		hours[i] = float64(ms) / float64(time.Hour/time.Millisecond)
+		sum += hours[i]
+	}
+	sort.Float64s(hours)
+
+	return &DurationStats{
+		Count: len(hours),
+		Mean:  roundHours(sum / float64(len(hours))),
+		P50:   roundHours(percentile(hours, 0.50)),
+		P75:   roundHours(percentile(hours, 0.75)),
+		P90:   roundHours(percentile(hours, 0.90)),
+		P95:   roundHours(percentile(hours, 0.95)),
+		Max:   roundHours(hours[len(hours)-1]),
+	}
+}
+
+// percentile returns the p-th quantile of sorted values by linear
+// interpolation.
+func percentile(sorted []float64, p float64) float64 {
+	rank := p * float64(len(sorted)-1)
+	lower := int(math.Floor(rank))
+	upper := int(math.Ceil(rank))
+	return sorted[lower] + (sorted[upper]-sorted[lower])*(rank-float64(lower))
+}
+
+func roundHours(hours float64) float64 {
+	return math.Round(hours*100) / 100
+}
@@ -0,0 +1,112 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"github.com/grewalsk/task-api/internal/models"
+	"github.com/grewalsk/task-api/internal/tenant"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+)
+
+// PurgeService permanently removes tasks and everything that hangs off them.
+type PurgeService struct {
+	taskDAO           *models.TaskDAO
+	commentDAO        *models.CommentDAO
+	worklogDAO        *models.WorklogDAO
+	attachmentService *AttachmentService
+	retention         time.Duration
+	logger            *zap.Logger
+}
+
+func NewPurgeService(taskDAO *models.TaskDAO, commentDAO *models.CommentDAO, worklogDAO *models.WorklogDAO, attachmentService *AttachmentService, retentionDays int, logger *zap.Logger) *PurgeService {
+	return &PurgeService{
+		taskDAO:           taskDAO,
+		commentDAO:        commentDAO,
+		worklogDAO:        worklogDAO,
+		attachmentService: attachmentService,
+		retention:         time.Duration(retentionDays) * 24 * time.Hour,
+		logger:            logger,
+	}
+}
+
+// Purge deletes related data before the task itself, so a failure part way
+// through leaves the task in the trash to be retried by the next run. Its
+// subtasks are kept and become top-level tasks.
+func (s *PurgeService) Purge(ctx context.Context, taskID primitive.ObjectID) error {
+	if err := s.attachmentService.PurgeTask(ctx, taskID); err != nil {
+		return err
+	}
+
+	if err := s.commentDAO.DeleteByTask(ctx, taskID); err != nil {
+		return err
+	}
+
+	if err := s.worklogDAO.DeleteByTask(ctx, taskID); err != nil {
+		return err
+	}
+
+	if err := s.taskDAO.Purge(ctx, taskID); err != nil {
+		return err
+	}
+
+	// An upload that finished while the task was being purged leaves an
+	// attachment behind; nothing else would ever find it once the task is gone.
+	if err := s.attachmentService.PurgeTask(ctx, taskID); err != nil {
+		s.logger.Error("Failed to remove attachments of purged task", zap.String("task_id", taskID.Hex()), zap.Error(err))
+	}
+	return nil
+}
+
+// PurgeExpired purges every task that has outlived the retention period. A
+// task that fails to purge is logged and skipped, so it does not hold up the
+// rest; the next run retries it.
+func (s *PurgeService) PurgeExpired(ctx context.Context) (int, error) {
+	cutoff := time.Now().Add(-s.retention)
+	purged := 0
+	var failed []primitive.ObjectID
+	for {
+		tasks, err := s.taskDAO.ListExpired(ctx, cutoff, failed, 100)
+		if err != nil {
+			return purged, err
+		}
+		if len(tasks) == 0 {
+			return purged, nil
+		}
+
+		for _, task := range tasks {
+			if err := s.Purge(tenant.WithID(ctx, task.TenantID), task.ID); err != nil {
+				s.logger.Error("Failed to purge task", zap.String("task_id", task.ID.Hex()), zap.Error(err))
+				failed = append(failed, task.ID)
+				continue
+			}
+			purged++
+		}
+	}
+}
+
+// Run purges expired trash on every tick. A non-positive retention disables
+// automatic purging.
+func (s *PurgeService) Run(ctx context.Context, interval time.Duration) {
+	if s.retention <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			purged, err := s.PurgeExpired(ctx)
+			if err != nil {
+				s.logger.Error("Failed to purge expired tasks", zap.Error(err))
+			} else if purged > 0 {
+				s.logger.Info("Purged expired tasks", zap.Int("count", purged))
+			}
+		}
+	}
+}
@@ -0,0 +1,188 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/grewalsk/task-api/internal/models"
+	"github.com/grewalsk/task-api/internal/recurrence"
+	"github.com/grewalsk/task-api/internal/tenant"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.uber.org/zap"
+)
+
+// maxSkippedOccurrences bounds how far Prepare fast-forwards a series whose
+// first occurrence is in the past.
+const maxSkippedOccurrences = 100000
+
+type RecurrenceService struct {
+	taskDAO *models.TaskDAO
+	logger  *zap.Logger
+}
+
+func NewRecurrenceService(taskDAO *models.TaskDAO, logger *zap.Logger) *RecurrenceService {
+	return &RecurrenceService{
+		taskDAO: taskDAO,
+		logger:  logger,
+	}
+}
+
+// Prepare validates the client supplied rule and timezone of a new recurring
+// task and resets the server-maintained series fields.
+func (s *RecurrenceService) Prepare(task *models.Task) error {
+	rec := task.Recurrence
+	rule, loc, err := parseRecurrence(rec)
+	if err != nil {
+		return err
+	}
+
+	if rec.OccurrenceAt.IsZero() {
+		rec.OccurrenceAt = time.Now()
+	}
+	rec.OccurrenceAt = rec.OccurrenceAt.In(loc).Truncate(time.Second)
+	rec.SeriesID = primitive.NilObjectID
+	rec.Index = 1
+	rec.Spawned = false
+	rec.NextAt = nil
+
+	// A series that started in the past begins at its current occurrence
+	// rather than generating every one that was missed.
+	now := time.Now()
+	for i := 0; ; i++ {
+		next, ok := rule.Next(rec.OccurrenceAt, rec.Index, loc)
+		if !ok {
+			break
+		}
+		if next.After(now) {
+			rec.NextAt = &next
+			break
+		}
+		if i == maxSkippedOccurrences {
+			return fmt.Errorf("recurrence.occurrence_at is too far in the past")
+		}
+		rec.OccurrenceAt = next
+		rec.Index++
+	}
+
+	return nil
+}
+
+// SpawnNext creates the following occurrence of a recurring task. It is safe
+// to call repeatedly and from several replicas: the unique index on
+// (recurrence.series_id, recurrence.index) turns duplicates into no-ops.
+func (s *RecurrenceService) SpawnNext(ctx context.Context, task *models.Task) error {
+	rec := task.Recurrence
+	if rec == nil || rec.Spawned || rec.NextAt == nil {
+		return nil
+	}
+
+	rule, loc, err := parseRecurrence(rec)
+	if err != nil {
+		return err
+	}
+
+	next := &models.Task{
+		Title:       task.Title,
+		Description: task.Description,
+		Status:      models.StatusOpen,
+		OwnerID:     task.OwnerID,
+		ProjectID:   task.ProjectID,
+		AssigneeIDs: task.AssigneeIDs,
+		Labels:      task.Labels,
+		Recurrence: &models.Recurrence{
+			RRule:        rec.RRule,
+			Timezone:     rec.Timezone,
+			SeriesID:     rec.SeriesID,
+			Index:        rec.Index + 1,
+			OccurrenceAt: *rec.NextAt,
+		},
+	}
+	if at, ok := rule.Next(*rec.NextAt, next.Recurrence.Index, loc); ok {
+		next.Recurrence.NextAt = &at
+	}
+
+	if err := s.taskDAO.Create(ctx, next, primitive.NilObjectID); err != nil && !mongo.IsDuplicateKeyError(err) {
+		return err
+	}
+
+	return s.taskDAO.MarkRecurrenceSpawned(ctx, task.ID)
+}
+
+// GenerateDue spawns the next occurrence for every series whose upcoming
+// period has begun. A task that is still due after it was handled, because
+// it could not be marked as spawned, is left for the next run rather than
+// retried.
+func (s *RecurrenceService) GenerateDue(ctx context.Context) (int, error) {
+	generated := 0
+	handled := make(map[primitive.ObjectID]bool)
+	for {
+		tasks, err := s.taskDAO.ListDueRecurrences(ctx, time.Now(), 100)
+		if err != nil {
+			return generated, err
+		}
+
+		progress := false
+		for _, task := range tasks {
+			if handled[task.ID] {
+				continue
+			}
+			handled[task.ID] = true
+			progress = true
+
+			ctx := tenant.WithID(ctx, task.TenantID)
+			if err := s.SpawnNext(ctx, task); err != nil {
+				s.logger.Error("Failed to generate recurring task",
+					zap.String("task_id", task.ID.Hex()),
+					zap.Error(err),
+				)
+				// Stop the series so a broken rule is not retried forever.
+				if err := s.taskDAO.MarkRecurrenceSpawned(ctx, task.ID); err != nil {
+					return generated, err
+				}
+				continue
+			}
+			generated++
+		}
+		if !progress {
+			return generated, nil
+		}
+	}
+}
+
+func (s *RecurrenceService) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			generated, err := s.GenerateDue(ctx)
+			if err != nil {
+				s.logger.Error("Failed to generate recurring tasks", zap.Error(err))
+			} else if generated > 0 {
+				s.logger.Info("Generated recurring tasks", zap.Int("count", generated))
+			}
+		}
+	}
+}
+
+func parseRecurrence(rec *models.Recurrence) (*recurrence.Rule, *time.Location, error) {
+	rule, err := recurrence.Parse(rec.RRule)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid rrule: %w", err)
+	}
+
+	loc := time.UTC
+	if rec.Timezone != "" {
+		loc, err = time.LoadLocation(rec.Timezone)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid timezone %q", rec.Timezone)
+		}
+	}
+
+	return rule, loc, nil
+}
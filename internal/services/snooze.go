@@ -0,0 +1,85 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"github.com/grewalsk/task-api/internal/events"
+	"github.com/grewalsk/task-api/internal/models"
+	"github.com/grewalsk/task-api/internal/tenant"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.uber.org/zap"
+)
+
+type SnoozeService struct {
+	taskDAO *models.TaskDAO
+	bus     *events.Bus
+	logger  *zap.Logger
+}
+
+func NewSnoozeService(taskDAO *models.TaskDAO, bus *events.Bus, logger *zap.Logger) *SnoozeService {
+	return &SnoozeService{
+		taskDAO: taskDAO,
+		bus:     bus,
+		logger:  logger,
+	}
+}
+
+// WakeDue clears every snooze that has ended and publishes a
+// TaskSnoozeExpired event for each. A snooze cleared by another replica, or
+// extended in the meantime, is skipped, so each expiry is published once.
+func (s *SnoozeService) WakeDue(ctx context.Context) (int, error) {
+	woken := 0
+	for {
+		tasks, err := s.taskDAO.ListSnoozeExpired(ctx, time.Now(), 100)
+		if err != nil {
+			return woken, err
+		}
+		if len(tasks) == 0 {
+			return woken, nil
+		}
+
+		for _, task := range tasks {
+			ctx := tenant.WithID(ctx, task.TenantID)
+			until := *task.SnoozedUntil
+			cleared, err := s.taskDAO.Unsnooze(ctx, task.ID, &until, primitive.NilObjectID)
+			if err != nil {
+				return woken, err
+			}
+			if !cleared {
+				continue
+			}
+
+			s.bus.Publish(ctx, events.Event{
+				Type:     events.TaskSnoozeExpired,
+				TenantID: task.TenantID,
+				TaskID:   task.ID,
+				Data: map[string]interface{}{
+					"snoozed_until": until,
+					"owner_id":      task.OwnerID,
+					"assignee_ids":  task.AssigneeIDs,
+				},
+			})
+			woken++
+		}
+	}
+}
+
+func (s *SnoozeService) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			woken, err := s.WakeDue(ctx)
+			if err != nil {
+				s.logger.Error("Failed to resurface snoozed tasks", zap.Error(err))
+			} else if woken > 0 {
+				s.logger.Info("Resurfaced snoozed tasks", zap.Int("count", woken))
+			}
+		}
+	}
+}
@@ -0,0 +1,128 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/grewalsk/task-api/internal/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var ErrMissingVariables = errors.New("missing template variables")
+
+var placeholderPattern = regexp.MustCompile(`\{\{\s*([a-zA-Z_][a-zA-Z0-9_]*)\s*\}\}`)
+
+type TemplateService struct {
+	taskDAO *models.TaskDAO
+}
+
+func NewTemplateService(taskDAO *models.TaskDAO) *TemplateService {
+	return &TemplateService{
+		taskDAO: taskDAO,
+	}
+}
+
+type InstantiateInput struct {
+	OwnerID   primitive.ObjectID
+	ProjectID *primitive.ObjectID
+	Variables map[string]string
+	Location  *time.Location
+}
+
+// Render builds the tasks described by a template without storing them. The
+// first task is the parent; the rest are its subtasks. Built-in variables
+// (date, datetime, time, user_id, template_name, template_version) can be
+// overridden by the caller.
+func (s *TemplateService) Render(tmpl *models.Template, input InstantiateInput) ([]*models.Task, error) {
+	loc := input.Location
+	if loc == nil {
+		loc = time.UTC
+	}
+	now := time.Now().In(loc)
+
+	vars := map[string]string{
+		"date":             now.Format(time.DateOnly),
+		"datetime":         now.Format(time.RFC3339),
+		"time":             now.Format("15:04"),
+		"user_id":          input.OwnerID.Hex(),
+		"template_name":    tmpl.Name,
+		"template_version": fmt.Sprint(tmpl.Version),
+	}
+	for k, v := range input.Variables {
+		vars[k] = v
+	}
+
+	missing := map[string]bool{}
+	render := func(pattern string) string {
+		return placeholderPattern.ReplaceAllStringFunc(pattern, func(m string) string {
+			name := placeholderPattern.FindStringSubmatch(m)[1]
+			v, ok := vars[name]
+			if !ok {
+				missing[name] = true
+			}
+			return v
+		})
+	}
+
+	status := tmpl.DefaultStatus
+	if status == "" {
+		status = models.StatusOpen
+	}
+
+	parent := &models.Task{
+		ID:          primitive.NewObjectID(),
+		Title:       render(tmpl.TitlePattern),
+		Description: render(tmpl.DescriptionPattern),
+		Status:      status,
+		OwnerID:     input.OwnerID,
+		ProjectID:   input.ProjectID,
+		Labels:      tmpl.Labels,
+		Checklist:   resetChecklist(tmpl.Checklist),
+	}
+
+	tasks := []*models.Task{parent}
+	for _, sub := range tmpl.Subtasks {
+		tasks = append(tasks, &models.Task{
+			Title:       render(sub.TitlePattern),
+			Description: render(sub.DescriptionPattern),
+			Status:      models.StatusOpen,
+			OwnerID:     input.OwnerID,
+			ProjectID:   input.ProjectID,
+			ParentID:    &parent.ID,
+			Checklist:   resetChecklist(sub.Checklist),
+		})
+	}
+
+	if len(missing) > 0 {
+		names := make([]string, 0, len(missing))
+		for name := range missing {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return nil, fmt.Errorf("%w: %s", ErrMissingVariables, strings.Join(names, ", "))
+	}
+
+	return tasks, nil
+}
+
+// Create stores rendered tasks, the parent together with its subtasks.
+func (s *TemplateService) Create(ctx context.Context, tasks []*models.Task, actorID primitive.ObjectID) error {
+	return s.taskDAO.CreateMany(ctx, tasks, actorID)
+}
+
+func resetChecklist(items []models.ChecklistItem) []models.ChecklistItem {
+	if len(items) == 0 {
+		return nil
+	}
+
+	checklist := make([]models.ChecklistItem, len(items))
+	for i, item := range items {
+		checklist[i] = models.ChecklistItem{Text: item.Text}
+	}
+	return checklist
+}
@@ -0,0 +1,106 @@
+package services
+
+import (
+	"context"
+	"errors"
+
+	"github.com/grewalsk/task-api/internal/middleware"
+	"github.com/grewalsk/task-api/internal/models"
+	"github.com/grewalsk/task-api/internal/tenant"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+var (
+	ErrInvalidCredentials = errors.New("invalid credentials")
+	ErrWorkspaceExists    = errors.New("workspace already exists")
+)
+
+type WorkspaceService struct {
+	workspaceDAO *models.WorkspaceDAO
+	userDAO      *models.UserDAO
+	authService  *AuthService
+}
+
+func NewWorkspaceService(workspaceDAO *models.WorkspaceDAO, userDAO *models.UserDAO, authService *AuthService) *WorkspaceService {
+	return &WorkspaceService{
+		workspaceDAO: workspaceDAO,
+		userDAO:      userDAO,
+		authService:  authService,
+	}
+}
+
+// SeedAdmin creates the default admin in a workspace that has no users yet.
+func (s *WorkspaceService) SeedAdmin(ctx context.Context, workspace *models.Workspace) error {
+	ctx = tenant.WithID(ctx, workspace.ID)
+
+	count, err := s.userDAO.Count(ctx)
+	if err != nil || count > 0 {
+		return err
+	}
+
+	return s.userDAO.Create(ctx, s.authService.CreateDefaultUser())
+}
+
+// Authenticate checks credentials against the users of one workspace. An
+// empty slug selects the default workspace.
+func (s *WorkspaceService) Authenticate(ctx context.Context, slug, email, password string) (*models.User, error) {
+	if slug == "" {
+		slug = models.DefaultWorkspaceSlug
+	}
+
+	workspace, err := s.workspaceDAO.GetBySlug(ctx, slug)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrInvalidCredentials
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := s.userDAO.GetByEmail(tenant.WithID(ctx, workspace.ID), email)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrInvalidCredentials
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if !s.authService.VerifyPassword(password, user.Password) {
+		return nil, ErrInvalidCredentials
+	}
+
+	return user, nil
+}
+
+// CanProvision reports whether the user may create workspaces: admins of the
+// default workspace.
+func (s *WorkspaceService) CanProvision(ctx context.Context, user *middleware.Claims) (bool, error) {
+	if user.Role != string(models.RoleAdmin) {
+		return false, nil
+	}
+
+	workspace, err := s.workspaceDAO.GetBySlug(ctx, models.DefaultWorkspaceSlug)
+	if err != nil {
+		return false, err
+	}
+
+	return workspace.ID == user.TenantID, nil
+}
+
+// Provision creates a workspace together with its first admin.
+func (s *WorkspaceService) Provision(ctx context.Context, workspace *models.Workspace, admin *models.User, password string) error {
+	if err := s.workspaceDAO.Create(ctx, workspace); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return ErrWorkspaceExists
+		}
+		return err
+	}
+
+	admin.Role = models.RoleAdmin
+	admin.Password = s.authService.HashPassword(password)
+
+	return s.userDAO.Create(tenant.WithID(ctx, workspace.ID), admin)
+}
+
+func (s *WorkspaceService) Get(ctx context.Context, user *middleware.Claims) (*models.Workspace, error) {
+	return s.workspaceDAO.GetByID(ctx, user.TenantID)
+}
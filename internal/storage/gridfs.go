@@ -0,0 +1,123 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/gridfs"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// GridFSStore uses the blob key as the GridFS file ID so lookups and
+// deletes need no extra index.
+type GridFSStore struct {
+	db         *mongo.Database
+	bucketName string
+}
+
+func NewGridFSStore(db *mongo.Database, bucketName string) (*GridFSStore, error) {
+	if _, err := gridfs.NewBucket(db, options.GridFSBucket().SetName(bucketName)); err != nil {
+		return nil, err
+	}
+	return &GridFSStore{db: db, bucketName: bucketName}, nil
+}
+
+// bucket returns a new bucket for one operation. A Bucket shares a read
+// buffer and deadlines between its callers, so one must not be used by
+// concurrent requests.
+func (s *GridFSStore) bucket(ctx context.Context) (*gridfs.Bucket, error) {
+	bucket, err := gridfs.NewBucket(s.db, options.GridFSBucket().SetName(s.bucketName))
+	if err != nil {
+		return nil, err
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		bucket.SetReadDeadline(deadline)
+		bucket.SetWriteDeadline(deadline)
+	}
+	return bucket, nil
+}
+
+func (s *GridFSStore) Put(ctx context.Context, key string, r io.Reader) (int64, error) {
+	bucket, err := s.bucket(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	stream, err := bucket.OpenUploadStreamWithID(key, key)
+	if err != nil {
+		return 0, err
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		stream.SetWriteDeadline(deadline)
+	}
+
+	n, err := io.Copy(stream, &contextReader{ctx: ctx, r: r})
+	if err != nil {
+		stream.Abort()
+		return n, err
+	}
+	return n, stream.Close()
+}
+
+func (s *GridFSStore) Open(ctx context.Context, key string) (io.ReadCloser, error) {
+	bucket, err := s.bucket(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	stream, err := bucket.OpenDownloadStream(key)
+	if errors.Is(err, gridfs.ErrFileNotFound) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		stream.SetReadDeadline(deadline)
+	}
+	return &contextReadCloser{contextReader: contextReader{ctx: ctx, r: stream}, c: stream}, nil
+}
+
+func (s *GridFSStore) Delete(ctx context.Context, key string) error {
+	bucket, err := s.bucket(ctx)
+	if err != nil {
+		return err
+	}
+
+	err = bucket.DeleteContext(ctx, key)
+	if errors.Is(err, gridfs.ErrFileNotFound) {
+		return ErrNotFound
+	}
+	return err
+}
+
+func (s *GridFSStore) List(ctx context.Context, before time.Time, fn func(key string) error) error {
+	bucket, err := s.bucket(ctx)
+	if err != nil {
+		return err
+	}
+
+	filter := bson.M{"uploadDate": bson.M{"$lt": before}}
+	cursor, err := bucket.FindContext(ctx, filter)
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var file struct {
+			ID string `bson:"_id"`
+		}
+		if err := cursor.Decode(&file); err != nil {
+			return err
+		}
+		if err := fn(file.ID); err != nil {
+			return err
+		}
+	}
+	return cursor.Err()
+}
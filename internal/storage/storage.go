@@ -0,0 +1,67 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+var ErrNotFound = errors.New("blob not found")
+
+// BlobStore persists opaque binary content addressed by a caller-chosen key.
+type BlobStore interface {
+	Put(ctx context.Context, key string, r io.Reader) (int64, error)
+	Open(ctx context.Context, key string) (io.ReadCloser, error)
+	Delete(ctx context.Context, key string) error
+	// List calls fn with the key of every blob stored before the given time.
+	List(ctx context.Context, before time.Time, fn func(key string) error) error
+}
+
+func New(driver, localPath string, db *mongo.Database) (BlobStore, error) {
+	switch driver {
+	case "", "local":
+		return NewLocalStore(localPath)
+	case "gridfs":
+		return NewGridFSStore(db, "attachments")
+	default:
+		return nil, fmt.Errorf("unknown storage driver %q", driver)
+	}
+}
+
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// contextReader stops reading once its context is done, for streams that
+// would otherwise outlive the request.
+type contextReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+func (c *contextReader) Read(p []byte) (int, error) {
+	if err := c.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return c.r.Read(p)
+}
+
+type contextReadCloser struct {
+	contextReader
+	c io.Closer
+}
+
+func (c *contextReadCloser) Close() error {
+	return c.c.Close()
+}
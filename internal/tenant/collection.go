@@ -0,0 +1,297 @@
+package tenant
+
+import (
+	"context"
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Collection wraps a mongo.Collection so that every filter is restricted to
+// the workspace in the context and every inserted document is stamped with
+// it. Operations fail with ErrMissing when the context carries no workspace,
+// and writes fail with ErrReadOnly under a System context.
+type Collection struct {
+	collection collection
+}
+
+// collection is the part of *mongo.Collection that Collection wraps.
+type collection interface {
+	Name() string
+	Database() *mongo.Database
+	Find(ctx context.Context, filter interface{}, opts ...*options.FindOptions) (*mongo.Cursor, error)
+	FindOne(ctx context.Context, filter interface{}, opts ...*options.FindOneOptions) *mongo.SingleResult
+	CountDocuments(ctx context.Context, filter interface{}, opts ...*options.CountOptions) (int64, error)
+	Aggregate(ctx context.Context, pipeline interface{}, opts ...*options.AggregateOptions) (*mongo.Cursor, error)
+	InsertOne(ctx context.Context, document interface{}, opts ...*options.InsertOneOptions) (*mongo.InsertOneResult, error)
+	InsertMany(ctx context.Context, documents []interface{}, opts ...*options.InsertManyOptions) (*mongo.InsertManyResult, error)
+	UpdateOne(ctx context.Context, filter interface{}, update interface{}, opts ...*options.UpdateOptions) (*mongo.UpdateResult, error)
+	UpdateMany(ctx context.Context, filter interface{}, update interface{}, opts ...*options.UpdateOptions) (*mongo.UpdateResult, error)
+	FindOneAndUpdate(ctx context.Context, filter interface{}, update interface{}, opts ...*options.FindOneAndUpdateOptions) *mongo.SingleResult
+	DeleteOne(ctx context.Context, filter interface{}, opts ...*options.DeleteOptions) (*mongo.DeleteResult, error)
+	DeleteMany(ctx context.Context, filter interface{}, opts ...*options.DeleteOptions) (*mongo.DeleteResult, error)
+	BulkWrite(ctx context.Context, models []mongo.WriteModel, opts ...*options.BulkWriteOptions) (*mongo.BulkWriteResult, error)
+}
+
+func NewCollection(db *mongo.Database, name string) *Collection {
+	return &Collection{collection: db.Collection(name)}
+}
+
+func (c *Collection) Name() string {
+	return c.collection.Name()
+}
+
+// StartSession starts a session for multi-document transactions. Operations
+// run with the session context remain scoped by the context they derive from.
+func (c *Collection) StartSession() (mongo.Session, error) {
+	return c.collection.Database().Client().StartSession()
+}
+
+func (c *Collection) scopeFilter(ctx context.Context, filter interface{}, write bool) (interface{}, error) {
+	id, ok := FromContext(ctx)
+	if !ok {
+		if isSystem(ctx) && !write {
+			return filter, nil
+		}
+		if isSystem(ctx) {
+			return nil, ErrReadOnly
+		}
+		return nil, ErrMissing
+	}
+
+	doc, err := toD(filter)
+	if err != nil {
+		return nil, err
+	}
+	return setField(doc, id), nil
+}
+
+func (c *Collection) scopeDocument(ctx context.Context, document interface{}) (bson.D, error) {
+	id, ok := FromContext(ctx)
+	if !ok {
+		if isSystem(ctx) {
+			return nil, ErrReadOnly
+		}
+		return nil, ErrMissing
+	}
+
+	doc, err := toD(document)
+	if err != nil {
+		return nil, err
+	}
+	return setField(doc, id), nil
+}
+
+func (c *Collection) scopePipeline(ctx context.Context, pipeline mongo.Pipeline) (mongo.Pipeline, error) {
+	id, ok := FromContext(ctx)
+	if !ok {
+		if isSystem(ctx) {
+			return pipeline, nil
+		}
+		return nil, ErrMissing
+	}
+
+	scoped := make(mongo.Pipeline, 0, len(pipeline)+1)
+	scoped = append(scoped, bson.D{{Key: "$match", Value: bson.D{{Key: Field, Value: id}}}})
+	return append(scoped, pipeline...), nil
+}
+
+// checkUpdate rejects update documents that would move a document to another
+// workspace.
+func checkUpdate(update interface{}) error {
+	doc, err := toD(update)
+	if err != nil {
+		return err
+	}
+
+	for _, op := range doc {
+		fields, err := toD(op.Value)
+		if err != nil {
+			continue
+		}
+		for _, field := range fields {
+			if field.Key == Field {
+				return fmt.Errorf("tenant: %s cannot be modified", Field)
+			}
+		}
+	}
+
+	return nil
+}
+
+func toD(v interface{}) (bson.D, error) {
+	if v == nil {
+		return bson.D{}, nil
+	}
+	if d, ok := v.(bson.D); ok {
+		return d, nil
+	}
+
+	raw, err := bson.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	var d bson.D
+	if err := bson.Unmarshal(raw, &d); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// setField returns a copy of doc with any existing tenant field replaced.
+func setField(doc bson.D, id primitive.ObjectID) bson.D {
+	scoped := make(bson.D, 0, len(doc)+1)
+	for _, e := range doc {
+		if e.Key != Field {
+			scoped = append(scoped, e)
+		}
+	}
+	return append(scoped, bson.E{Key: Field, Value: id})
+}
+
+func (c *Collection) Find(ctx context.Context, filter interface{}, opts ...*options.FindOptions) (*mongo.Cursor, error) {
+	f, err := c.scopeFilter(ctx, filter, false)
+	if err != nil {
+		return nil, err
+	}
+	return c.collection.Find(ctx, f, opts...)
+}
+
+func (c *Collection) FindOne(ctx context.Context, filter interface{}, opts ...*options.FindOneOptions) *mongo.SingleResult {
+	f, err := c.scopeFilter(ctx, filter, false)
+	if err != nil {
+		return mongo.NewSingleResultFromDocument(bson.D{}, err, nil)
+	}
+	return c.collection.FindOne(ctx, f, opts...)
+}
+
+func (c *Collection) CountDocuments(ctx context.Context, filter interface{}, opts ...*options.CountOptions) (int64, error) {
+	f, err := c.scopeFilter(ctx, filter, false)
+	if err != nil {
+		return 0, err
+	}
+	return c.collection.CountDocuments(ctx, f, opts...)
+}
+
+func (c *Collection) Aggregate(ctx context.Context, pipeline mongo.Pipeline, opts ...*options.AggregateOptions) (*mongo.Cursor, error) {
+	p, err := c.scopePipeline(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	return c.collection.Aggregate(ctx, p, opts...)
+}
+
+func (c *Collection) InsertOne(ctx context.Context, document interface{}, opts ...*options.InsertOneOptions) (*mongo.InsertOneResult, error) {
+	doc, err := c.scopeDocument(ctx, document)
+	if err != nil {
+		return nil, err
+	}
+	return c.collection.InsertOne(ctx, doc, opts...)
+}
+
+func (c *Collection) InsertMany(ctx context.Context, documents []interface{}, opts ...*options.InsertManyOptions) (*mongo.InsertManyResult, error) {
+	docs := make([]interface{}, len(documents))
+	for i, document := range documents {
+		doc, err := c.scopeDocument(ctx, document)
+		if err != nil {
+			return nil, err
+		}
+		docs[i] = doc
+	}
+	return c.collection.InsertMany(ctx, docs, opts...)
+}
+
+func (c *Collection) UpdateOne(ctx context.Context, filter interface{}, update interface{}, opts ...*options.UpdateOptions) (*mongo.UpdateResult, error) {
+	f, err := c.scopeFilter(ctx, filter, true)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkUpdate(update); err != nil {
+		return nil, err
+	}
+	return c.collection.UpdateOne(ctx, f, update, opts...)
+}
+
+func (c *Collection) UpdateMany(ctx context.Context, filter interface{}, update interface{}, opts ...*options.UpdateOptions) (*mongo.UpdateResult, error) {
+	f, err := c.scopeFilter(ctx, filter, true)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkUpdate(update); err != nil {
+		return nil, err
+	}
+	return c.collection.UpdateMany(ctx, f, update, opts...)
+}
+
+func (c *Collection) FindOneAndUpdate(ctx context.Context, filter interface{}, update interface{}, opts ...*options.FindOneAndUpdateOptions) *mongo.SingleResult {
+	f, err := c.scopeFilter(ctx, filter, true)
+	if err == nil {
+		err = checkUpdate(update)
+	}
+	if err != nil {
+		return mongo.NewSingleResultFromDocument(bson.D{}, err, nil)
+	}
+	return c.collection.FindOneAndUpdate(ctx, f, update, opts...)
+}
+
+func (c *Collection) DeleteOne(ctx context.Context, filter interface{}, opts ...*options.DeleteOptions) (*mongo.DeleteResult, error) {
+	f, err := c.scopeFilter(ctx, filter, true)
+	if err != nil {
+		return nil, err
+	}
+	return c.collection.DeleteOne(ctx, f, opts...)
+}
+
+func (c *Collection) DeleteMany(ctx context.Context, filter interface{}, opts ...*options.DeleteOptions) (*mongo.DeleteResult, error) {
+	f, err := c.scopeFilter(ctx, filter, true)
+	if err != nil {
+		return nil, err
+	}
+	return c.collection.DeleteMany(ctx, f, opts...)
+}
+
+// BulkWrite scopes the filter of every update and delete model and stamps
+// inserted documents. Replace models are not supported.
+func (c *Collection) BulkWrite(ctx context.Context, models []mongo.WriteModel, opts ...*options.BulkWriteOptions) (*mongo.BulkWriteResult, error) {
+	scoped := make([]mongo.WriteModel, len(models))
+	for i, model := range models {
+		var err error
+		switch m := model.(type) {
+		case *mongo.InsertOneModel:
+			var doc bson.D
+			if doc, err = c.scopeDocument(ctx, m.Document); err == nil {
+				scoped[i] = mongo.NewInsertOneModel().SetDocument(doc)
+			}
+		case *mongo.UpdateOneModel:
+			copied := *m
+			if copied.Filter, err = c.scopeFilter(ctx, m.Filter, true); err == nil {
+				err = checkUpdate(m.Update)
+			}
+			scoped[i] = &copied
+		case *mongo.UpdateManyModel:
+			copied := *m
+			if copied.Filter, err = c.scopeFilter(ctx, m.Filter, true); err == nil {
+				err = checkUpdate(m.Update)
+			}
+			scoped[i] = &copied
+		case *mongo.DeleteOneModel:
+			copied := *m
+			copied.Filter, err = c.scopeFilter(ctx, m.Filter, true)
+			scoped[i] = &copied
+		case *mongo.DeleteManyModel:
+			copied := *m
+			copied.Filter, err = c.scopeFilter(ctx, m.Filter, true)
+			scoped[i] = &copied
+		default:
+			err = fmt.Errorf("tenant: unsupported write model %T", model)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return c.collection.BulkWrite(ctx, scoped, opts...)
+}
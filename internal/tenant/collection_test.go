@@ -0,0 +1,465 @@
+package tenant
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// recorder stands in for a *mongo.Collection and records what reaches it.
+type recorder struct {
+	filter   interface{}
+	update   interface{}
+	pipeline interface{}
+	docs     []interface{}
+	models   []mongo.WriteModel
+	calls    int
+}
+
+func (r *recorder) Name() string              { return "tasks" }
+func (r *recorder) Database() *mongo.Database { return nil }
+
+func (r *recorder) Find(ctx context.Context, filter interface{}, opts ...*options.FindOptions) (*mongo.Cursor, error) {
+	r.calls++
+	r.filter = filter
+	return mongo.NewCursorFromDocuments(nil, nil, nil)
+}
+
+func (r *recorder) FindOne(ctx context.Context, filter interface{}, opts ...*options.FindOneOptions) *mongo.SingleResult {
+	r.calls++
+	r.filter = filter
+	return mongo.NewSingleResultFromDocument(bson.D{}, nil, nil)
+}
+
+func (r *recorder) CountDocuments(ctx context.Context, filter interface{}, opts ...*options.CountOptions) (int64, error) {
+	r.calls++
+	r.filter = filter
+	return 0, nil
+}
+
+func (r *recorder) Aggregate(ctx context.Context, pipeline interface{}, opts ...*options.AggregateOptions) (*mongo.Cursor, error) {
+	r.calls++
+	r.pipeline = pipeline
+	return mongo.NewCursorFromDocuments(nil, nil, nil)
+}
+
+func (r *recorder) InsertOne(ctx context.Context, document interface{}, opts ...*options.InsertOneOptions) (*mongo.InsertOneResult, error) {
+	r.calls++
+	r.docs = []interface{}{document}
+	return &mongo.InsertOneResult{}, nil
+}
+
+func (r *recorder) InsertMany(ctx context.Context, documents []interface{}, opts ...*options.InsertManyOptions) (*mongo.InsertManyResult, error) {
+	r.calls++
+	r.docs = documents
+	return &mongo.InsertManyResult{}, nil
+}
+
+func (r *recorder) UpdateOne(ctx context.Context, filter interface{}, update interface{}, opts ...*options.UpdateOptions) (*mongo.UpdateResult, error) {
+	r.calls++
+	r.filter, r.update = filter, update
+	return &mongo.UpdateResult{}, nil
+}
+
+func (r *recorder) UpdateMany(ctx context.Context, filter interface{}, update interface{}, opts ...*options.UpdateOptions) (*mongo.UpdateResult, error) {
+	r.calls++
+	r.filter, r.update = filter, update
+	return &mongo.UpdateResult{}, nil
+}
+
+func (r *recorder) FindOneAndUpdate(ctx context.Context, filter interface{}, update interface{}, opts ...*options.FindOneAndUpdateOptions) *mongo.SingleResult {
+	r.calls++
+	r.filter, r.update = filter, update
+	return mongo.NewSingleResultFromDocument(bson.D{}, nil, nil)
+}
+
+func (r *recorder) DeleteOne(ctx context.Context, filter interface{}, opts ...*options.DeleteOptions) (*mongo.DeleteResult, error) {
+	r.calls++
+	r.filter = filter
+	return &mongo.DeleteResult{}, nil
+}
+
+func (r *recorder) DeleteMany(ctx context.Context, filter interface{}, opts ...*options.DeleteOptions) (*mongo.DeleteResult, error) {
+	r.calls++
+	r.filter = filter
+	return &mongo.DeleteResult{}, nil
+}
+
+func (r *recorder) BulkWrite(ctx context.Context, models []mongo.WriteModel, opts ...*options.BulkWriteOptions) (*mongo.BulkWriteResult, error) {
+	r.calls++
+	r.models = models
+	return &mongo.BulkWriteResult{}, nil
+}
+
+var (
+	workspaceA = primitive.NewObjectID()
+	workspaceB = primitive.NewObjectID()
+)
+
+// scopedTo reports the tenant a filter or document is restricted to.
+func scopedTo(t *testing.T, v interface{}) primitive.ObjectID {
+	t.Helper()
+	doc, err := toD(v)
+	if err != nil {
+		t.Fatalf("toD(%v): %v", v, err)
+	}
+	var id primitive.ObjectID
+	found := 0
+	for _, e := range doc {
+		if e.Key == Field {
+			id, _ = e.Value.(primitive.ObjectID)
+			found++
+		}
+	}
+	if found != 1 {
+		t.Fatalf("%v has %d %s fields, want 1", doc, found, Field)
+	}
+	return id
+}
+
+func TestFiltersAreScoped(t *testing.T) {
+	ctx := WithID(context.Background(), workspaceA)
+
+	tests := []struct {
+		name   string
+		filter interface{}
+	}{
+		{"empty", bson.M{}},
+		{"nil", nil},
+		{"by ID", bson.M{"_id": primitive.NewObjectID()}},
+		{"other workspace", bson.M{Field: workspaceB}},
+		{"other workspace in bson.D", bson.D{{Key: Field, Value: workspaceB}, {Key: "status", Value: "open"}}},
+	}
+
+	operations := []struct {
+		name string
+		run  func(c *Collection, filter interface{}) error
+	}{
+		{"Find", func(c *Collection, filter interface{}) error {
+			_, err := c.Find(ctx, filter)
+			return err
+		}},
+		{"FindOne", func(c *Collection, filter interface{}) error {
+			return c.FindOne(ctx, filter).Err()
+		}},
+		{"CountDocuments", func(c *Collection, filter interface{}) error {
+			_, err := c.CountDocuments(ctx, filter)
+			return err
+		}},
+		{"DeleteMany", func(c *Collection, filter interface{}) error {
+			_, err := c.DeleteMany(ctx, filter)
+			return err
+		}},
+	}
+
+	for _, op := range operations {
+		for _, tt := range tests {
+			t.Run(op.name+"/"+tt.name, func(t *testing.T) {
+				r := &recorder{}
+				if err := op.run(&Collection{collection: r}, tt.filter); err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				if got := scopedTo(t, r.filter); got != workspaceA {
+					t.Errorf("filter scoped to %s, want %s", got.Hex(), workspaceA.Hex())
+				}
+			})
+		}
+	}
+}
+
+func TestAggregateIsScoped(t *testing.T) {
+	r := &recorder{}
+	c := &Collection{collection: r}
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.D{{Key: Field, Value: workspaceB}}}},
+		{{Key: "$group", Value: bson.D{{Key: "_id", Value: "$status"}}}},
+	}
+
+	if _, err := c.Aggregate(WithID(context.Background(), workspaceA), pipeline); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	got, ok := r.pipeline.(mongo.Pipeline)
+	if !ok || len(got) != len(pipeline)+1 {
+		t.Fatalf("pipeline = %v, want the original stages after a $match", r.pipeline)
+	}
+	first := got[0]
+	if len(first) != 1 || first[0].Key != "$match" {
+		t.Fatalf("first stage = %v, want $match", first)
+	}
+	if id := scopedTo(t, first[0].Value); id != workspaceA {
+		t.Errorf("first stage matches %s, want %s", id.Hex(), workspaceA.Hex())
+	}
+	if !reflect.DeepEqual(got[1:], pipeline) {
+		t.Errorf("stages = %v, want %v", got[1:], pipeline)
+	}
+}
+
+func TestInsertsAreStamped(t *testing.T) {
+	ctx := WithID(context.Background(), workspaceA)
+
+	tests := []struct {
+		name string
+		doc  interface{}
+	}{
+		{"map", bson.M{"title": "a"}},
+		{"struct", struct {
+			Title string `bson:"title"`
+		}{"a"}},
+		{"other workspace", bson.M{"title": "a", Field: workspaceB}},
+		{"other workspace in struct", struct {
+			Title    string             `bson:"title"`
+			TenantID primitive.ObjectID `bson:"tenant_id"`
+		}{"a", workspaceB}},
+	}
+
+	for _, tt := range tests {
+		t.Run("InsertOne/"+tt.name, func(t *testing.T) {
+			r := &recorder{}
+			if _, err := (&Collection{collection: r}).InsertOne(ctx, tt.doc); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := scopedTo(t, r.docs[0]); got != workspaceA {
+				t.Errorf("document stamped with %s, want %s", got.Hex(), workspaceA.Hex())
+			}
+		})
+		t.Run("InsertMany/"+tt.name, func(t *testing.T) {
+			r := &recorder{}
+			if _, err := (&Collection{collection: r}).InsertMany(ctx, []interface{}{tt.doc, tt.doc}); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			for _, doc := range r.docs {
+				if got := scopedTo(t, doc); got != workspaceA {
+					t.Errorf("document stamped with %s, want %s", got.Hex(), workspaceA.Hex())
+				}
+			}
+		})
+	}
+}
+
+func TestUpdates(t *testing.T) {
+	ctx := WithID(context.Background(), workspaceA)
+
+	tests := []struct {
+		name    string
+		update  interface{}
+		wantErr bool
+	}{
+		{"set", bson.M{"$set": bson.M{"title": "b"}}, false},
+		{"set and inc", bson.D{{Key: "$set", Value: bson.M{"title": "b"}}, {Key: "$inc", Value: bson.M{"version": 1}}}, false},
+		{"set tenant", bson.M{"$set": bson.M{Field: workspaceB}}, true},
+		{"set tenant among others", bson.M{"$set": bson.M{"title": "b", Field: workspaceB}}, true},
+		{"unset tenant", bson.M{"$unset": bson.M{Field: ""}}, true},
+		{"unset tenant in bson.D", bson.D{{Key: "$unset", Value: bson.D{{Key: Field, Value: ""}}}}, true},
+	}
+
+	updates := []struct {
+		name string
+		run  func(c *Collection, update interface{}) error
+	}{
+		{"UpdateOne", func(c *Collection, update interface{}) error {
+			_, err := c.UpdateOne(ctx, bson.M{"_id": primitive.NewObjectID()}, update)
+			return err
+		}},
+		{"UpdateMany", func(c *Collection, update interface{}) error {
+			_, err := c.UpdateMany(ctx, bson.M{}, update)
+			return err
+		}},
+		{"FindOneAndUpdate", func(c *Collection, update interface{}) error {
+			return c.FindOneAndUpdate(ctx, bson.M{}, update).Err()
+		}},
+		{"BulkWrite", func(c *Collection, update interface{}) error {
+			_, err := c.BulkWrite(ctx, []mongo.WriteModel{mongo.NewUpdateOneModel().SetFilter(bson.M{}).SetUpdate(update)})
+			return err
+		}},
+	}
+
+	for _, u := range updates {
+		for _, tt := range tests {
+			t.Run(u.name+"/"+tt.name, func(t *testing.T) {
+				r := &recorder{}
+				err := u.run(&Collection{collection: r}, tt.update)
+				if tt.wantErr {
+					if err == nil {
+						t.Fatal("expected an error")
+					}
+					if r.calls != 0 {
+						t.Error("update reached the collection")
+					}
+					return
+				}
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				if u.name != "BulkWrite" {
+					if got := scopedTo(t, r.filter); got != workspaceA {
+						t.Errorf("filter scoped to %s, want %s", got.Hex(), workspaceA.Hex())
+					}
+				}
+			})
+		}
+	}
+}
+
+func TestBulkWriteIsScoped(t *testing.T) {
+	r := &recorder{}
+	c := &Collection{collection: r}
+	other := bson.M{Field: workspaceB}
+	models := []mongo.WriteModel{
+		mongo.NewInsertOneModel().SetDocument(bson.M{"title": "a", Field: workspaceB}),
+		mongo.NewUpdateOneModel().SetFilter(other).SetUpdate(bson.M{"$set": bson.M{"title": "b"}}),
+		mongo.NewUpdateManyModel().SetFilter(other).SetUpdate(bson.M{"$set": bson.M{"title": "b"}}),
+		mongo.NewDeleteOneModel().SetFilter(other),
+		mongo.NewDeleteManyModel().SetFilter(bson.M{}),
+	}
+
+	if _, err := c.BulkWrite(WithID(context.Background(), workspaceA), models); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(r.models) != len(models) {
+		t.Fatalf("got %d models, want %d", len(r.models), len(models))
+	}
+
+	for i, model := range r.models {
+		var scoped interface{}
+		switch m := model.(type) {
+		case *mongo.InsertOneModel:
+			scoped = m.Document
+		case *mongo.UpdateOneModel:
+			scoped = m.Filter
+		case *mongo.UpdateManyModel:
+			scoped = m.Filter
+		case *mongo.DeleteOneModel:
+			scoped = m.Filter
+		case *mongo.DeleteManyModel:
+			scoped = m.Filter
+		}
+		if got := scopedTo(t, scoped); got != workspaceA {
+			t.Errorf("model %d (%T) scoped to %s, want %s", i, model, got.Hex(), workspaceA.Hex())
+		}
+	}
+
+	// The caller's models are left as they were.
+	if models[1].(*mongo.UpdateOneModel).Filter.(bson.M)[Field] != workspaceB {
+		t.Error("BulkWrite modified the caller's model")
+	}
+}
+
+func TestBulkWriteRejectsReplace(t *testing.T) {
+	r := &recorder{}
+	models := []mongo.WriteModel{mongo.NewReplaceOneModel().SetFilter(bson.M{}).SetReplacement(bson.M{})}
+	if _, err := (&Collection{collection: r}).BulkWrite(WithID(context.Background(), workspaceA), models); err == nil {
+		t.Fatal("expected an error")
+	}
+	if r.calls != 0 {
+		t.Error("write reached the collection")
+	}
+}
+
+func TestContexts(t *testing.T) {
+	operations := []struct {
+		name  string
+		write bool
+		run   func(ctx context.Context, c *Collection) error
+	}{
+		{"Find", false, func(ctx context.Context, c *Collection) error {
+			_, err := c.Find(ctx, bson.M{})
+			return err
+		}},
+		{"FindOne", false, func(ctx context.Context, c *Collection) error {
+			return c.FindOne(ctx, bson.M{}).Err()
+		}},
+		{"CountDocuments", false, func(ctx context.Context, c *Collection) error {
+			_, err := c.CountDocuments(ctx, bson.M{})
+			return err
+		}},
+		{"Aggregate", false, func(ctx context.Context, c *Collection) error {
+			_, err := c.Aggregate(ctx, mongo.Pipeline{})
+			return err
+		}},
+		{"InsertOne", true, func(ctx context.Context, c *Collection) error {
+			_, err := c.InsertOne(ctx, bson.M{"title": "a"})
+			return err
+		}},
+		{"InsertMany", true, func(ctx context.Context, c *Collection) error {
+			_, err := c.InsertMany(ctx, []interface{}{bson.M{"title": "a"}})
+			return err
+		}},
+		{"UpdateOne", true, func(ctx context.Context, c *Collection) error {
+			_, err := c.UpdateOne(ctx, bson.M{}, bson.M{"$set": bson.M{"title": "b"}})
+			return err
+		}},
+		{"UpdateMany", true, func(ctx context.Context, c *Collection) error {
+			_, err := c.UpdateMany(ctx, bson.M{}, bson.M{"$set": bson.M{"title": "b"}})
+			return err
+		}},
+		{"FindOneAndUpdate", true, func(ctx context.Context, c *Collection) error {
+			return c.FindOneAndUpdate(ctx, bson.M{}, bson.M{"$set": bson.M{"title": "b"}}).Err()
+		}},
+		{"DeleteOne", true, func(ctx context.Context, c *Collection) error {
+			_, err := c.DeleteOne(ctx, bson.M{})
+			return err
+		}},
+		{"DeleteMany", true, func(ctx context.Context, c *Collection) error {
+			_, err := c.DeleteMany(ctx, bson.M{})
+			return err
+		}},
+		{"BulkWrite", true, func(ctx context.Context, c *Collection) error {
+			_, err := c.BulkWrite(ctx, []mongo.WriteModel{mongo.NewDeleteOneModel().SetFilter(bson.M{})})
+			return err
+		}},
+	}
+
+	contexts := []struct {
+		name     string
+		ctx      context.Context
+		readErr  error
+		writeErr error
+	}{
+		{"no workspace", context.Background(), ErrMissing, ErrMissing},
+		{"zero workspace", WithID(context.Background(), primitive.NilObjectID), ErrMissing, ErrMissing},
+		{"system", System(context.Background()), nil, ErrReadOnly},
+	}
+
+	for _, cc := range contexts {
+		for _, op := range operations {
+			t.Run(cc.name+"/"+op.name, func(t *testing.T) {
+				r := &recorder{}
+				err := op.run(cc.ctx, &Collection{collection: r})
+
+				want := cc.readErr
+				if op.write {
+					want = cc.writeErr
+				}
+				if !errors.Is(err, want) {
+					t.Fatalf("error = %v, want %v", err, want)
+				}
+				if want != nil && r.calls != 0 {
+					t.Error("operation reached the collection")
+				}
+				if want == nil && r.calls != 1 {
+					t.Errorf("operation reached the collection %d times, want 1", r.calls)
+				}
+			})
+		}
+	}
+}
+
+func TestSystemReadsAreUnscoped(t *testing.T) {
+	r := &recorder{}
+	c := &Collection{collection: r}
+	filter := bson.M{"deleted_at": bson.M{"$ne": nil}}
+
+	if _, err := c.Find(System(context.Background()), filter); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(r.filter, filter) {
+		t.Errorf("filter = %v, want %v", r.filter, filter)
+	}
+}
@@ -0,0 +1,50 @@
+// Package tenant carries the current workspace through request contexts and
+// scopes every MongoDB operation issued by the DAOs to it.
+package tenant
+
+import (
+	"context"
+	"errors"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Field is the document field holding the owning workspace.
+const Field = "tenant_id"
+
+var (
+	ErrMissing  = errors.New("tenant: no workspace in context")
+	ErrReadOnly = errors.New("tenant: cross-workspace context is read-only")
+)
+
+type contextKey struct{}
+
+type scope struct {
+	id     primitive.ObjectID
+	system bool
+}
+
+// WithID returns a context scoped to one workspace.
+func WithID(ctx context.Context, id primitive.ObjectID) context.Context {
+	return context.WithValue(ctx, contextKey{}, scope{id: id})
+}
+
+// System returns a context that may read across all workspaces. It is meant
+// for background jobs, which must switch to WithID before writing anything.
+func System(ctx context.Context) context.Context {
+	return context.WithValue(ctx, contextKey{}, scope{system: true})
+}
+
+// FromContext returns the workspace of a scoped context.
+func FromContext(ctx context.Context) (primitive.ObjectID, bool) {
+	s, ok := ctx.Value(contextKey{}).(scope)
+	if !ok || s.system || s.id.IsZero() {
+		return primitive.NilObjectID, false
+	}
+	return s.id, true
+}
+
+func isSystem(ctx context.Context) bool {
+	s, ok := ctx.Value(contextKey{}).(scope)
+	return ok && s.system
+}
@@ -0,0 +1,123 @@
+package utils
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+const (
+	ProblemContentType = "application/problem+json"
+	// ProblemTypeBase prefixes the code of a problem to form its type URI.
+	ProblemTypeBase = "/v1/problems/"
+	// RequestIDHeader carries the ID of a request in both directions.
+	RequestIDHeader = "X-Request-ID"
+)
+
+// Problem is an RFC 7807 problem details object. Code is the stable,
+// machine-readable identifier of the error; Errors lists the individual
+// fields that failed validation.
+type Problem struct {
+	Type      string       `json:"type"`
+	Title     string       `json:"title"`
+	Status    int          `json:"status"`
+	Detail    string       `json:"detail,omitempty"`
+	Code      string       `json:"code"`
+	RequestID string       `json:"request_id,omitempty"`
+	Errors    []FieldError `json:"errors,omitempty"`
+	Data      interface{}  `json:"data,omitempty"`
+}
+
+// FieldError describes one failed validation rule. Param is the rule's
+// argument, such as the limit of max.
+type FieldError struct {
+	Field   string `json:"field"`
+	Rule    string `json:"rule"`
+	Param   string `json:"param,omitempty"`
+	Message string `json:"message"`
+}
+
+// errorCatalog maps every error code the API returns to its title. Codes are
+// part of the API contract; titles and details may change.
+var errorCatalog = map[string]string{
+	"attachment_too_large":   "Attachment too large",
+	"checksum_mismatch":      "Checksum mismatch",
+	"clone_too_large":        "Too many tasks to clone",
+	"conflict":               "Conflict",
+	"database_error":         "Database error",
+	"forbidden":              "Forbidden",
+	"idempotency_key_in_use": "Idempotency key in use",
+	"idempotency_key_reused": "Idempotency key reused",
+	"internal_error":         "Internal server error",
+	"invalid_authorization":  "Invalid authorization header",
+	"invalid_claims":         "Invalid token claims",
+	"invalid_credentials":    "Invalid credentials",
+	"invalid_cursor":         "Invalid cursor",
+	"invalid_filter":         "Invalid filter",
+	"invalid_id":             "Invalid ID",
+	"invalid_patch":          "Invalid patch",
+	"invalid_request":        "Invalid request",
+	"invalid_state":          "Invalid state",
+	"invalid_token":          "Invalid or expired token",
+	"last_owner":             "Last owner",
+	"method_not_allowed":     "Method not allowed",
+	"missing_authorization":  "Missing authorization",
+	"no_updates":             "No updates",
+	"not_found":              "Not found",
+	"option_in_use":          "Option in use",
+	"patch_test_failed":      "Patch test failed",
+	"precondition_failed":    "Precondition failed",
+	"precondition_required":  "Precondition required",
+	"project_archived":       "Project archived",
+	"project_not_empty":      "Project not empty",
+	"sprint_active":          "Sprint active",
+	"sprint_closed":          "Sprint closed",
+	"storage_error":          "Storage error",
+	"task_done":              "Task done",
+	"timer_running":          "Timer running",
+	"token_error":            "Token error",
+	"unauthorized":           "Unauthorized",
+	"unsupported_media_type": "Unsupported media type",
+	"validation_error":       "Validation failed",
+}
+
+// ErrorTitle returns the title of a catalogued error code.
+func ErrorTitle(code string) (string, bool) {
+	title, ok := errorCatalog[code]
+	return title, ok
+}
+
+// NewProblem builds the problem for an error code. Codes missing from the
+// catalog fall back to the status text as their title.
+func NewProblem(status int, code, detail string) *Problem {
+	title, ok := errorCatalog[code]
+	if !ok {
+		title = http.StatusText(status)
+	}
+	return &Problem{
+		Type:   ProblemTypeBase + code,
+		Title:  title,
+		Status: status,
+		Detail: detail,
+		Code:   code,
+	}
+}
+
+// WriteProblem writes p as application/problem+json. The request ID is taken
+// from the response header set by the request ID middleware, so that the
+// body and header always agree.
+func WriteProblem(w http.ResponseWriter, p *Problem) {
+	if p.RequestID == "" {
+		p.RequestID = w.Header().Get(RequestIDHeader)
+	}
+	w.Header().Set("Content-Type", ProblemContentType)
+	w.WriteHeader(p.Status)
+	json.NewEncoder(w).Encode(p)
+}
+
+// WriteValidationError writes a validation_error problem for err, listing
+// each failed rule when err comes from ValidateStruct.
+func WriteValidationError(w http.ResponseWriter, err error) {
+	p := NewProblem(http.StatusBadRequest, "validation_error", FormatValidationError(err))
+	p.Errors = FieldErrors(err)
+	WriteProblem(w, p)
+}
@@ -5,11 +5,6 @@ import (
 	"net/http"
 )
 
-type ErrorResponse struct {
-	Error   string `json:"error"`
-	Message string `json:"message"`
-}
-
 type SuccessResponse struct {
 	Data interface{} `json:"data,omitempty"`
 }
@@ -20,13 +15,12 @@ func WriteJSON(w http.ResponseWriter, status int, data interface{}) {
 	json.NewEncoder(w).Encode(data)
 }
 
-func WriteError(w http.ResponseWriter, status int, errorType, message string) {
-	WriteJSON(w, status, ErrorResponse{
-		Error:   errorType,
-		Message: message,
-	})
+// WriteError writes a problem details response for an error code from the
+// catalog, with message as its detail.
+func WriteError(w http.ResponseWriter, status int, code, message string) {
+	WriteProblem(w, NewProblem(status, code, message))
 }
 
 func WriteSuccess(w http.ResponseWriter, data interface{}) {
 	WriteJSON(w, http.StatusOK, SuccessResponse{Data: data})
-}
\ No newline at end of file
+}
@@ -1,7 +1,9 @@
 package utils
 
 import (
+	"errors"
 	"fmt"
+	"reflect"
 	"strings"
 
 	"github.com/go-playground/validator/v10"
@@ -11,6 +13,14 @@ var validate *validator.Validate
 
 func init() {
 	validate = validator.New()
+	// Report fields by their JSON names.
+	validate.RegisterTagNameFunc(func(field reflect.StructField) string {
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			return ""
+		}
+		return name
+	})
 }
 
 func ValidateStruct(s interface{}) error {
@@ -18,12 +28,68 @@ func ValidateStruct(s interface{}) error {
 }
 
 func FormatValidationError(err error) string {
-	if validationErrors, ok := err.(validator.ValidationErrors); ok {
+	if fieldErrors := FieldErrors(err); len(fieldErrors) > 0 {
 		var messages []string
-		for _, e := range validationErrors {
-			messages = append(messages, fmt.Sprintf("%s is %s", strings.ToLower(e.Field()), e.Tag()))
+		for _, e := range fieldErrors {
+			messages = append(messages, e.Message)
 		}
 		return strings.Join(messages, ", ")
 	}
 	return err.Error()
-}
\ No newline at end of file
+}
+
+// FieldErrors converts the errors returned by ValidateStruct. Fields are
+// named by their JSON path below the validated struct, such as
+// checklist[0].text.
+func FieldErrors(err error) []FieldError {
+	var validationErrors validator.ValidationErrors
+	if !errors.As(err, &validationErrors) {
+		return nil
+	}
+
+	fieldErrors := make([]FieldError, 0, len(validationErrors))
+	for _, e := range validationErrors {
+		field := e.Namespace()
+		if _, rest, ok := strings.Cut(field, "."); ok {
+			field = rest
+		}
+		fieldErrors = append(fieldErrors, FieldError{
+			Field:   field,
+			Rule:    e.Tag(),
+			Param:   e.Param(),
+			Message: field + " " + ruleMessage(e),
+		})
+	}
+	return fieldErrors
+}
+
+func ruleMessage(e validator.FieldError) string {
+	kind := e.Kind()
+	if kind == reflect.Pointer {
+		kind = e.Type().Elem().Kind()
+	}
+
+	var unit string
+	switch kind {
+	case reflect.String:
+		unit = " characters"
+	case reflect.Slice, reflect.Array, reflect.Map:
+		unit = " items"
+	}
+
+	switch e.Tag() {
+	case "required", "required_if":
+		return "is required"
+	case "min":
+		return fmt.Sprintf("must be at least %s%s", e.Param(), unit)
+	case "max":
+		return fmt.Sprintf("must be at most %s%s", e.Param(), unit)
+	case "oneof":
+		return "must be one of " + strings.Join(strings.Fields(e.Param()), ", ")
+	case "email":
+		return "must be a valid email address"
+	case "gtfield":
+		return "must be after " + e.Param()
+	}
+	return fmt.Sprintf("failed the %s rule", e.Tag())
+}